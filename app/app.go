@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/rohan031/adgytec-api/config"
+	"github.com/rohan031/adgytec-api/identity"
+	"github.com/rohan031/adgytec-api/mailer"
+	"github.com/rohan031/adgytec-api/session"
+	"github.com/rohan031/adgytec-api/storage"
+)
+
+// Store is the part of *pgxpool.Pool used by the application
+type Store interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Ping(ctx context.Context) error
+}
+
+// App holds every external dependency, handlers and middleware are built from
+// it so tests can swap any of them for a fake
+type App struct {
+	Config   *config.Config
+	Store    Store
+	Storage  storage.Storage
+	Identity identity.Provider
+	Mailer   mailer.Mailer
+	// suspension and revocation state for the token middleware, nil turns
+	// caching off
+	Sessions *session.Cache
+}
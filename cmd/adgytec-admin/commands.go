@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rohan031/adgytec-api/v1/custom"
+	"github.com/rohan031/adgytec-api/v1/services"
+	"github.com/rohan031/adgytec-api/v1/validation"
+)
+
+// parse parses the flags of a command, reporting the required ones missing
+func parse(fs *flag.FlagSet, args []string, required ...string) bool {
+	if err := fs.Parse(args); err != nil {
+		return false
+	}
+
+	var missing []string
+	for _, name := range required {
+		if fs.Lookup(name).Value.String() == "" {
+			missing = append(missing, "-"+name)
+		}
+	}
+
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "missing required flags: %s\n", strings.Join(missing, ", "))
+		fs.Usage()
+		return false
+	}
+
+	return true
+}
+
+// fail prints the error of a service call, api errors carry a readable message
+func fail(msg string, err error) int {
+	var mr *custom.MalformedRequest
+	if errors.As(err, &mr) {
+		log.Printf("%s: %s\n", msg, mr.Message)
+		return 1
+	}
+
+	log.Printf("%s: %v\n", msg, err)
+	return 1
+}
+
+func createSuperAdmin(ctx context.Context, svc *services.Service, args []string) int {
+	fs := flag.NewFlagSet("create-super-admin", flag.ContinueOnError)
+	name := fs.String("name", "", "display name of the account")
+	email := fs.String("email", "", "email address to sign in with")
+	invite := fs.Bool("invite", false, "mail an invite to choose a password instead of printing one")
+	if !parse(fs, args, "name", "email") {
+		return 2
+	}
+
+	user := services.User{Name: *name, Email: *email, Role: validation.SuperAdmin}
+	if !user.ValidateInput() {
+		log.Println("The name or email provided is invalid.")
+		return 1
+	}
+
+	if !*invite {
+		password, err := svc.CreateUser(ctx, &user)
+		if err != nil {
+			return fail("Error creating super admin", err)
+		}
+
+		fmt.Printf("created super admin %s, password: %s\n", user.Email, password)
+		return 0
+	}
+
+	details, err := svc.InviteUser(ctx, &user, "")
+	if err != nil {
+		return fail("Error creating super admin", err)
+	}
+
+	err = svc.SendInvite(ctx, details)
+	if err != nil {
+		log.Printf("created super admin %s but couldn't send the invite: %v\n", user.Email, err)
+		fmt.Printf("invite link: %s\n", details.Link)
+		return 1
+	}
+
+	fmt.Printf("created super admin %s, invite sent by email\n", user.Email)
+	return 0
+}
+
+// openImage presents a file on disk the way an uploaded form file looks
+func openImage(path string) (multipart.File, *multipart.FileHeader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		file.Close()
+		return nil, nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	header := &multipart.FileHeader{
+		Filename: filepath.Base(path),
+		Header:   textproto.MIMEHeader{"Content-Type": {http.DetectContentType(sniff[:n])}},
+		Size:     info.Size(),
+	}
+
+	return file, header, nil
+}
+
+func createProject(ctx context.Context, svc *services.Service, args []string) int {
+	fs := flag.NewFlagSet("create-project", flag.ContinueOnError)
+	name := fs.String("name", "", "project name, must be unique")
+	cover := fs.String("cover", "", "path to the cover image")
+	if !parse(fs, args, "name", "cover") {
+		return 2
+	}
+
+	file, header, err := openImage(*cover)
+	if err != nil {
+		return fail("Error reading cover image", err)
+	}
+	defer file.Close()
+
+	project := services.Project{ProjectName: *name}
+	err = svc.CreateProjectWithCover(ctx, &project, file, header)
+	if err != nil {
+		return fail("Error creating project", err)
+	}
+
+	fmt.Printf("created project %s\nid:           %s\nclient token: %s\n", project.ProjectName, project.Id, project.ClientToken)
+	return 0
+}
+
+func listProjects(ctx context.Context, svc *services.Service, args []string) int {
+	fs := flag.NewFlagSet("list-projects", flag.ContinueOnError)
+	if !parse(fs, args) {
+		return 2
+	}
+
+	var project services.Project
+	all, err := svc.GetAllProjects(ctx, &project)
+	if err != nil {
+		return fail("Error listing projects", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tCREATED")
+	for _, p := range *all {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Id, p.ProjectName, p.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	w.Flush()
+
+	return 0
+}
+
+func listServices(ctx context.Context, svc *services.Service, args []string) int {
+	fs := flag.NewFlagSet("list-services", flag.ContinueOnError)
+	if !parse(fs, args) {
+		return 2
+	}
+
+	var project services.Project
+	all, err := svc.GetAllServices(ctx, &project)
+	if err != nil {
+		return fail("Error listing services", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME")
+	for _, s := range *all {
+		fmt.Fprintf(w, "%s\t%s\n", s.Id, s.Name)
+	}
+	w.Flush()
+
+	return 0
+}
+
+func listTokens(ctx context.Context, svc *services.Service, args []string) int {
+	fs := flag.NewFlagSet("list-tokens", flag.ContinueOnError)
+	projectId := fs.String("project", "", "id of the project")
+	if !parse(fs, args, "project") {
+		return 2
+	}
+
+	var token services.ClientToken
+	all, err := svc.GetClientTokensByProjectId(ctx, &token, *projectId)
+	if err != nil {
+		return fail("Error listing client tokens", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tLABEL\tSCOPES\tSTATUS")
+	for _, t := range *all {
+		status := "active"
+		if t.RevokedAt != nil {
+			status = "revoked"
+		} else if t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now()) {
+			status = "expired"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Id, t.Label, strings.Join(t.Scopes, ","), status)
+	}
+	w.Flush()
+
+	return 0
+}
+
+func rotateToken(ctx context.Context, svc *services.Service, args []string) int {
+	fs := flag.NewFlagSet("rotate-token", flag.ContinueOnError)
+	projectId := fs.String("project", "", "id of the project")
+	tokenId := fs.String("token", "", "id of the client token, see list-tokens")
+	if !parse(fs, args, "project", "token") {
+		return 2
+	}
+
+	var token services.ClientToken
+	rotated, err := svc.RotateClientTokenById(ctx, &token, *tokenId, *projectId)
+	if err != nil {
+		return fail("Error rotating client token", err)
+	}
+
+	fmt.Printf("id:           %s\nclient token: %s\n", rotated.Id, rotated.Token)
+	return 0
+}
+
+func addUser(ctx context.Context, svc *services.Service, args []string) int {
+	fs := flag.NewFlagSet("add-user", flag.ContinueOnError)
+	projectId := fs.String("project", "", "id of the project")
+	userId := fs.String("user", "", "id of the user")
+	role := fs.String("role", validation.ProjectViewer, "role in the project: owner, editor, author or viewer")
+	if !parse(fs, args, "project", "user") {
+		return 2
+	}
+
+	member := services.ProjectUserMap{UserId: *userId, Role: *role}
+	err := svc.CreateUserProjectMap(ctx, &member, *projectId)
+	if err != nil {
+		return fail("Error adding user to project", err)
+	}
+
+	fmt.Printf("added user %s to project %s as %s\n", *userId, *projectId, member.Role)
+	return 0
+}
+
+func addServices(ctx context.Context, svc *services.Service, args []string) int {
+	fs := flag.NewFlagSet("add-services", flag.ContinueOnError)
+	projectId := fs.String("project", "", "id of the project")
+	serviceIds := fs.String("services", "", "comma separated service ids, see list-services")
+	if !parse(fs, args, "project", "services") {
+		return 2
+	}
+
+	var ids []string
+	for _, id := range strings.Split(*serviceIds, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	ps := services.ProjectServiceMap{Services: ids}
+	err := svc.CreateProjectServiceMap(ctx, &ps, *projectId)
+	if err != nil {
+		return fail("Error adding services to project", err)
+	}
+
+	fmt.Printf("added %d services to project %s\n", len(ids), *projectId)
+	return 0
+}
+
+func checkUsers(ctx context.Context, svc *services.Service, args []string) int {
+	fs := flag.NewFlagSet("check-users", flag.ContinueOnError)
+	repair := fs.Bool("repair", false, "repair names, role claims and accounts without a users row")
+	if !parse(fs, args) {
+		return 2
+	}
+
+	check := svc.CheckUserConsistency
+	if *repair {
+		check = svc.ReconcileUsers
+	}
+
+	problems, err := check(ctx)
+	if err != nil {
+		return fail("Error checking users", err)
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("identity provider and users table are consistent")
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER ID\tEMAIL\tPROBLEM\tREPAIRED")
+	for _, p := range problems {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", p.UserId, p.Email, p.Problem, p.Repaired)
+	}
+	w.Flush()
+
+	// non zero so scheduled runs can alert on it
+	return 1
+}
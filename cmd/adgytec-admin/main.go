@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"github.com/rohan031/adgytec-api/app"
+	"github.com/rohan031/adgytec-api/config"
+	"github.com/rohan031/adgytec-api/database"
+	"github.com/rohan031/adgytec-api/identity"
+	"github.com/rohan031/adgytec-api/mailer"
+	"github.com/rohan031/adgytec-api/storage"
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+const usage = `usage: adgytec-admin <command> [flags]
+
+commands:
+  create-super-admin  create a super admin account and print its password
+  create-project      create a project with its cover image and client token
+  list-projects       list every project
+  list-services       list every service projects can use
+  list-tokens         list the client tokens of a project
+  rotate-token        replace a client token of a project
+  add-user            add a user to a project
+  add-services        enable services for a project
+  check-users         compare identity provider accounts with the users table,
+                      -repair fixes what the users table can tell
+
+run "adgytec-admin <command> -h" for the flags of a command`
+
+type command func(ctx context.Context, svc *services.Service, args []string) int
+
+var commands = map[string]command{
+	"create-super-admin": createSuperAdmin,
+	"create-project":     createProject,
+	"list-projects":      listProjects,
+	"list-services":      listServices,
+	"list-tokens":        listTokens,
+	"rotate-token":       rotateToken,
+	"add-user":           addUser,
+	"add-services":       addServices,
+	"check-users":        checkUsers,
+}
+
+func main() {
+	log.SetFlags(0)
+
+	// loading environment variables from .env, same as the server
+	err := godotenv.Load()
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("error loading env file: %v\n", err)
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	args := os.Args[2:]
+	for _, arg := range args {
+		// commands parse their flags before touching the service, so help
+		// works without any configuration
+		if arg == "-h" || arg == "-help" || arg == "--help" {
+			os.Exit(cmd(context.Background(), nil, args))
+		}
+	}
+
+	os.Exit(run(cmd, args))
+}
+
+// run builds the service layer the api uses and hands it to the command
+func run(cmd command, args []string) int {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	ctx := context.Background()
+	pool, err := database.CreatePool(ctx, cfg.Database)
+	if err != nil {
+		log.Printf("Error connecting to database: %v\n", err)
+		return 1
+	}
+	defer pool.Close()
+
+	err = database.CheckSchema(ctx, pool)
+	if err != nil {
+		log.Printf("Database schema check failed, run `app migrate up`: %v\n", err)
+		return 1
+	}
+
+	blobStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Printf("Error creating media storage: %v\n", err)
+		return 1
+	}
+
+	identityProvider, err := identity.New(ctx, cfg.Identity, pool)
+	if err != nil {
+		log.Printf("Error creating identity provider: %v\n", err)
+		return 1
+	}
+
+	svc := services.New(&app.App{
+		Config:   cfg,
+		Store:    pool,
+		Storage:  blobStorage,
+		Identity: identityProvider,
+		Mailer:   mailer.NewSMTP(cfg.Email),
+	})
+
+	code := cmd(ctx, svc, args)
+
+	// letting clean-up started by a failed command finish before exiting
+	waitCtx, cancel := context.WithTimeout(ctx, cfg.Timeouts.Background)
+	defer cancel()
+	if err := svc.WaitForBackgroundTasks(waitCtx); err != nil {
+		log.Printf("Error waiting for background tasks: %v\n", err)
+	}
+
+	return code
+}
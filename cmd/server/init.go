@@ -1,73 +1,63 @@
 package main
 
 import (
-	"errors"
+	"context"
+	"log/slog"
+
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/httprate"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"log"
-	"net/http"
-	"time"
+	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/rohan031/adgytec-api/app"
+	"github.com/rohan031/adgytec-api/config"
 	"github.com/rohan031/adgytec-api/database"
-	"github.com/rohan031/adgytec-api/firebase"
-	"github.com/rohan031/adgytec-api/helper"
+	"github.com/rohan031/adgytec-api/identity"
+	"github.com/rohan031/adgytec-api/mailer"
+	"github.com/rohan031/adgytec-api/metrics"
+	"github.com/rohan031/adgytec-api/server"
+	"github.com/rohan031/adgytec-api/session"
 	"github.com/rohan031/adgytec-api/storage"
-	v1Router "github.com/rohan031/adgytec-api/v1/router"
 	"github.com/rohan031/adgytec-api/v1/services"
 )
 
-func handle400(router *chi.Mux) {
-	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
-		err := errors.New("404 route not found")
-
-		helper.ErrorResponse(w, err, http.StatusNotFound)
-	})
-
-	router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
-		err := errors.New("405 invalid request method")
-
-		helper.ErrorResponse(w, err, http.StatusMethodNotAllowed)
-	})
-}
-
-func initApp() (*chi.Mux, *pgxpool.Pool) {
-	// init firebase
-	firebaseClient, err := firebase.InitFirebaseAdminSdk()
+func initApp(ctx context.Context, cfg *config.Config) (*chi.Mux, *pgxpool.Pool, *services.Service) {
+	// init media storage
+	blobStorage, err := storage.New(cfg.Storage)
 	if err != nil {
-		log.Fatal("Error connecting to firebase!!\n", err)
+		fatal("Error creating media storage", err)
 	}
-	log.Println("Successfully connected to firebase!!")
+	slog.Info("Successfully created media storage", "driver", cfg.Storage.Driver)
 
-	// init cloud storage
-	minioClient, err := storage.InitCloudStorage()
+	// getting db connection pool
+	pool, err := database.CreatePool(ctx, cfg.Database)
 	if err != nil {
-		log.Fatal("Error creating minio-client!!\n", err)
+		fatal("Error connecting to database", err)
 	}
-	log.Println("Successfully created mino storage client!!")
 
-	// getting db connection pool
-	pool, err := database.CreatePool()
+	// refusing to serve with a schema older than the code expects
+	err = database.CheckSchema(ctx, pool)
 	if err != nil {
-		log.Fatal("Error connecting to database\n", err)
+		fatal("Database schema check failed, run `app migrate up`", err)
 	}
 
-	// setting database pool for use in services
-	services.SetExternalConnection(pool, minioClient, firebaseClient)
-
-	router := chi.NewRouter()
-
-	// middleware
-	router.Use(httprate.LimitByIP(100, time.Minute))
-	router.Use(middleware.Heartbeat("/"))
-	router.Use(middleware.Logger)
-	router.Use(middleware.Recoverer)
-	router.Use(middleware.AllowContentType("application/json", "multipart/form-data"))
-
-	router.Mount("/v1", v1Router.Router())
+	// init identity provider
+	identityProvider, err := identity.New(ctx, cfg.Identity, pool)
+	if err != nil {
+		fatal("Error creating identity provider", err)
+	}
+	slog.Info("Successfully created identity provider", "provider", cfg.Identity.Provider)
+
+	application := &app.App{
+		Config:   cfg,
+		Store:    pool,
+		Storage:  storage.WithMetrics(blobStorage),
+		Identity: identityProvider,
+		Mailer:   mailer.NewSMTP(cfg.Email),
+		Sessions: session.NewCache(cfg.SessionCacheTTL),
+	}
+	svc := services.New(application)
 
-	handle400(router)
+	prometheus.MustRegister(metrics.NewPoolCollector(pool))
 
-	return router, pool
+	return server.Router(application, svc), pool, svc
 }
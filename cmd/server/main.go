@@ -1,11 +1,30 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
+
+	"github.com/rohan031/adgytec-api/config"
+	"github.com/rohan031/adgytec-api/logger"
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+const (
+	readHeaderTimeout = time.Second * 10
+	readTimeout       = time.Minute * 2 // multipart uploads go up to 25mb
+	writeTimeout      = time.Minute * 2
+	idleTimeout       = time.Minute * 2
+	shutdownTimeout   = time.Second * 30
 )
 
 func main() {
@@ -15,14 +34,80 @@ func main() {
 		log.Printf("error loading env file: %v\n", err)
 	}
 
-	PORT := "8080"
-	if port := os.Getenv("PORT"); port != "" {
-		PORT = port
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// everything after this point logs json through slog, including the
+	// standard log package used by dependencies
+	slog.SetDefault(logger.New(os.Stdout, cfg.LogLevel))
+
+	router, pool, svc := initApp(context.Background(), cfg)
+
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           router,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	svc.StartUserReconciliation(ctx, cfg.UserReconcileInterval)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server is listening", "port", cfg.Port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		// server never started or died unexpectedly
+		pool.Close()
+		fatal("Error running server", err)
+	case <-ctx.Done():
+		// restoring default signal behaviour so a second signal kills the process
+		stop()
+		slog.Info("Shutdown signal received, draining connections")
 	}
 
-	router, pool := initApp()
+	if err := shutdown(server, pool, svc); err != nil {
+		fatal("Error during shutdown", err)
+	}
+	slog.Info("Server stopped gracefully")
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// shutdown stops accepting new connections, waits for in-flight requests and
+// background media clean-up to finish and finally closes the database pool
+func shutdown(server *http.Server, pool *pgxpool.Pool, svc *services.Service) error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 	defer pool.Close()
 
-	log.Printf("Server is listening on PORT: %s", PORT)
-	http.ListenAndServe(":"+PORT, router)
+	var shutdownErr error
+	if err := server.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Error draining connections", "error", err)
+		shutdownErr = err
+	}
+
+	if err := svc.WaitForBackgroundTasks(ctx); err != nil {
+		slog.Error("Error waiting for background tasks", "error", err)
+		shutdownErr = errors.Join(shutdownErr, err)
+	}
+
+	return shutdownErr
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/rohan031/adgytec-api/config"
+	"github.com/rohan031/adgytec-api/database"
+)
+
+const migrateUsage = `usage: app migrate <command>
+
+commands:
+  up          apply every pending migration
+  down [n]    revert the last n applied migrations (default 1)
+  status      list migrations and whether they are applied`
+
+// runMigrate handles the "migrate" sub command and returns the exit code
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	dbConfig, err := config.LoadDatabase()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	ctx := context.Background()
+	pool, err := database.CreatePool(ctx, *dbConfig)
+	if err != nil {
+		log.Printf("Error connecting to database: %v\n", err)
+		return 1
+	}
+	defer pool.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := database.MigrateUp(ctx, pool)
+		for _, m := range applied {
+			log.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		if len(applied) == 0 {
+			log.Println("schema is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+
+		reverted, err := database.MigrateDown(ctx, pool, steps)
+		for _, m := range reverted {
+			log.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		if len(reverted) == 0 {
+			log.Println("no applied migrations to revert")
+		}
+
+	case "status":
+		statuses, err := database.MigrationStatuses(ctx, pool)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, state)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	return 0
+}
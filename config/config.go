@@ -0,0 +1,421 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const devEnv = "dev"
+
+// storage drivers
+const (
+	StorageS3    = "s3"
+	StorageLocal = "local"
+)
+
+// identity providers
+const (
+	IdentityFirebase = "firebase"
+	IdentityLocal    = "local"
+)
+
+// dashboard origins allowed when CORS_ALLOWED_ORIGINS is not set, client
+// websites are allowed per project. The ones allowed here before, like
+// ecrimino.com and prise-rdc.com, are seeded by migration 0004
+var defaultAllowedOrigins = []string{
+	"https://*.adgytec.in",
+}
+
+type Config struct {
+	Env  string
+	Port string
+
+	Database DatabaseConfig
+	Storage  StorageConfig
+	Identity IdentityConfig
+	Email    EmailConfig
+	Timeouts TimeoutConfig
+
+	// requests allowed per ip per minute
+	RateLimit      int
+	AllowedOrigins []string
+
+	// bearer token protecting /metrics, open when empty
+	MetricsToken string
+
+	// dashboard links in emails are built from it, e.g. accepting invites
+	DashboardURL string
+	InviteTTL    time.Duration
+
+	// how often drift between the identity provider and the users table is
+	// repaired, 0 turns it off
+	UserReconcileInterval time.Duration
+
+	// how long the suspension and revocation state of a user is cached, other
+	// instances see a revocation after at most this long
+	SessionCacheTTL time.Duration
+
+	LogLevel slog.Level
+}
+
+type DatabaseConfig struct {
+	DSN               string
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	ConnectTimeout    time.Duration
+}
+
+type StorageConfig struct {
+	// s3 or local
+	Driver string
+	// prepended to every object name, "dev/" when running in dev env
+	Prefix string
+
+	// s3 compatible bucket
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Secure    bool
+
+	// local disk, signed urls are served by the api itself
+	LocalDir   string
+	PublicURL  string
+	SigningKey string
+}
+
+type IdentityConfig struct {
+	// firebase or local
+	Provider string
+
+	Firebase FirebaseConfig
+	Local    LocalIdentityConfig
+}
+
+type FirebaseConfig struct {
+	// service account json
+	Credentials string
+}
+
+// LocalIdentityConfig configures the offline provider, users are kept in
+// postgres and tokens are signed by the api itself
+type LocalIdentityConfig struct {
+	// pem encoded ed25519 private key
+	PrivateKey string
+	Issuer     string
+	TokenTTL   time.Duration
+	// password reset and verification links point here, set from
+	// DASHBOARD_URL
+	ActionURL string
+}
+
+// TimeoutConfig bounds how long a single operation may run before its
+// context is cancelled
+type TimeoutConfig struct {
+	// whole request, handlers see it as the request context deadline
+	Request time.Duration
+	// single object storage call
+	Storage time.Duration
+	// single identity provider call
+	Auth time.Duration
+	// clean-up work that outlives the request, like media deletion
+	Background time.Duration
+}
+
+type EmailConfig struct {
+	From            string
+	Password        string
+	PrivateFrom     string
+	PrivatePassword string
+}
+
+func (c *Config) IsDev() bool {
+	return c.Env == devEnv
+}
+
+// Error lists every missing or malformed setting found while loading
+type Error struct {
+	Missing []string
+	Invalid []string
+}
+
+func (e *Error) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing required settings: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Invalid) > 0 {
+		parts = append(parts, "invalid settings: "+strings.Join(e.Invalid, ", "))
+	}
+
+	return "invalid configuration: " + strings.Join(parts, "; ")
+}
+
+type loader struct {
+	missing []string
+	invalid []string
+}
+
+func (l *loader) required(key string) string {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		l.missing = append(l.missing, key)
+	}
+
+	return val
+}
+
+func (l *loader) optional(key, fallback string) string {
+	if val := strings.TrimSpace(os.Getenv(key)); val != "" {
+		return val
+	}
+
+	return fallback
+}
+
+func (l *loader) integer(key string, fallback int) int {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		l.invalid = append(l.invalid, fmt.Sprintf("%s=%q (expected a non negative integer)", key, val))
+		return fallback
+	}
+
+	return n
+}
+
+func (l *loader) duration(key string, fallback time.Duration) time.Duration {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil || d < 0 {
+		l.invalid = append(l.invalid, fmt.Sprintf("%s=%q (expected a duration like 30s or 5m)", key, val))
+		return fallback
+	}
+
+	return d
+}
+
+func (l *loader) boolean(key string, fallback bool) bool {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		l.invalid = append(l.invalid, fmt.Sprintf("%s=%q (expected true or false)", key, val))
+		return fallback
+	}
+
+	return b
+}
+
+func (l *loader) level(key string, fallback slog.Level) slog.Level {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+
+	var level slog.Level
+	err := level.UnmarshalText([]byte(val))
+	if err != nil {
+		l.invalid = append(l.invalid, fmt.Sprintf("%s=%q (expected debug, info, warn or error)", key, val))
+		return fallback
+	}
+
+	return level
+}
+
+func (l *loader) list(key string, fallback []string) []string {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func (l *loader) database() DatabaseConfig {
+	cfg := DatabaseConfig{
+		DSN:               l.required("DB_DSN"),
+		MaxConns:          int32(l.integer("DB_MAX_CONNS", 200)),
+		MinConns:          int32(l.integer("DB_MIN_CONNS", 50)),
+		MaxConnLifetime:   l.duration("DB_MAX_CONN_LIFETIME", time.Hour),
+		MaxConnIdleTime:   l.duration("DB_MAX_CONN_IDLE_TIME", time.Minute*30),
+		HealthCheckPeriod: l.duration("DB_HEALTH_CHECK_PERIOD", time.Minute),
+		ConnectTimeout:    l.duration("DB_CONNECT_TIMEOUT", time.Second*5),
+	}
+
+	if cfg.MinConns > cfg.MaxConns {
+		l.invalid = append(l.invalid, "DB_MIN_CONNS (must not exceed DB_MAX_CONNS)")
+	}
+
+	return cfg
+}
+
+func (l *loader) storage() StorageConfig {
+	cfg := StorageConfig{
+		Driver: l.optional("STORAGE_DRIVER", StorageS3),
+	}
+
+	switch cfg.Driver {
+	case StorageS3:
+		cfg.Endpoint = l.required("SPACE_STORAGE_ENDPOINT")
+		cfg.AccessKey = l.required("SPACE_STORAGE_ACCESS_KEY")
+		cfg.SecretKey = l.required("SPACE_STORAGE_SECRET_KEY")
+		cfg.Bucket = l.required("SPACE_STORAGE_BUCKET_NAME")
+		cfg.Secure = l.boolean("SPACE_STORAGE_SECURE", true)
+
+	case StorageLocal:
+		cfg.LocalDir = l.optional("STORAGE_LOCAL_DIR", "./data/storage")
+		cfg.PublicURL = l.optional("STORAGE_PUBLIC_URL", "http://localhost:"+l.optional("PORT", "8080"))
+		cfg.SigningKey = l.required("STORAGE_SIGNING_KEY")
+
+	default:
+		l.invalid = append(l.invalid, fmt.Sprintf("STORAGE_DRIVER=%q (expected %s or %s)", cfg.Driver, StorageS3, StorageLocal))
+	}
+
+	return cfg
+}
+
+func (l *loader) identity() IdentityConfig {
+	cfg := IdentityConfig{
+		Provider: l.optional("IDENTITY_PROVIDER", IdentityFirebase),
+	}
+
+	switch cfg.Provider {
+	case IdentityFirebase:
+		cfg.Firebase.Credentials = l.required("CONFIG")
+
+	case IdentityLocal:
+		cfg.Local.PrivateKey = l.required("IDENTITY_PRIVATE_KEY")
+		cfg.Local.Issuer = l.optional("IDENTITY_ISSUER", "adgytec-api")
+		cfg.Local.TokenTTL = l.duration("IDENTITY_TOKEN_TTL", time.Hour)
+
+		if cfg.Local.TokenTTL <= 0 {
+			l.invalid = append(l.invalid, "IDENTITY_TOKEN_TTL (must be greater than 0)")
+		}
+
+	default:
+		l.invalid = append(l.invalid, fmt.Sprintf("IDENTITY_PROVIDER=%q (expected %s or %s)", cfg.Provider, IdentityFirebase, IdentityLocal))
+	}
+
+	return cfg
+}
+
+func (l *loader) err() error {
+	if len(l.missing) > 0 || len(l.invalid) > 0 {
+		return &Error{Missing: l.missing, Invalid: l.invalid}
+	}
+
+	return nil
+}
+
+// LoadDatabase reads only the database settings, for tooling like migrations
+// that doesn't need the rest of the application configured
+func LoadDatabase() (*DatabaseConfig, error) {
+	l := &loader{}
+	cfg := l.database()
+
+	if err := l.err(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// Load reads every setting from the environment once and validates it.
+// All problems are reported together so a deploy can be fixed in one go.
+func Load() (*Config, error) {
+	l := &loader{}
+
+	cfg := &Config{
+		Env:  l.optional("ENV", ""),
+		Port: l.optional("PORT", "8080"),
+
+		Database: l.database(),
+
+		Storage: l.storage(),
+
+		Identity: l.identity(),
+
+		Email: EmailConfig{
+			From:            l.required("FROM"),
+			Password:        l.required("PASS"),
+			PrivateFrom:     l.optional("PVTEMAIL", ""),
+			PrivatePassword: l.optional("PVTPASS", ""),
+		},
+
+		Timeouts: TimeoutConfig{
+			// as long as the server write timeout so slow uploads up to the
+			// size limit aren't cut off
+			Request:    l.duration("REQUEST_TIMEOUT", time.Minute*2),
+			Storage:    l.duration("STORAGE_TIMEOUT", time.Second*30),
+			Auth:       l.duration("AUTH_TIMEOUT", time.Second*10),
+			Background: l.duration("BACKGROUND_TIMEOUT", time.Minute*5),
+		},
+
+		RateLimit:      l.integer("RATE_LIMIT", 100),
+		AllowedOrigins: l.list("CORS_ALLOWED_ORIGINS", defaultAllowedOrigins),
+		MetricsToken:   l.optional("METRICS_TOKEN", ""),
+		DashboardURL:   strings.TrimSuffix(l.optional("DASHBOARD_URL", "https://dashboard.adgytec.in"), "/"),
+		InviteTTL:      l.duration("INVITE_TTL", time.Hour*72),
+		LogLevel:       l.level("LOG_LEVEL", slog.LevelInfo),
+
+		UserReconcileInterval: l.duration("USER_RECONCILE_INTERVAL", time.Hour),
+		SessionCacheTTL:       l.duration("SESSION_CACHE_TTL", time.Second*30),
+	}
+
+	cfg.Identity.Local.ActionURL = cfg.DashboardURL
+
+	if cfg.IsDev() {
+		cfg.Storage.Prefix = "dev/"
+	}
+
+	if cfg.RateLimit == 0 {
+		l.invalid = append(l.invalid, "RATE_LIMIT (must be greater than 0)")
+	}
+
+	timeouts := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"REQUEST_TIMEOUT", cfg.Timeouts.Request},
+		{"STORAGE_TIMEOUT", cfg.Timeouts.Storage},
+		{"AUTH_TIMEOUT", cfg.Timeouts.Auth},
+		{"BACKGROUND_TIMEOUT", cfg.Timeouts.Background},
+	}
+	for _, t := range timeouts {
+		if t.value == 0 {
+			l.invalid = append(l.invalid, t.key+" (must be greater than 0)")
+		}
+	}
+
+	if err := l.err(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
@@ -2,80 +2,64 @@ package database
 
 import (
 	"context"
-	"log"
-	"os"
-	"time"
+	"log/slog"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-)
-
-var ctx context.Context = context.Background()
 
-const (
-	defaultMaxConns          = int32(200)
-	defaultMinConns          = int32(50)
-	defaultMaxConnLifetime   = time.Hour
-	defaultMaxConnIdleTime   = time.Minute * 30
-	defaultHealthCheckPeriod = time.Minute
-	defaultConnectTimeout    = time.Second * 5
+	"github.com/rohan031/adgytec-api/config"
 )
 
-var DB *pgxpool.Pool // for use in middleware
-
-func dbConfig() (*pgxpool.Config, error) {
-	DATABASE_URL := os.Getenv("DB_DSN")
-
-	dbConfig, err := pgxpool.ParseConfig(DATABASE_URL)
+func dbConfig(cfg config.DatabaseConfig) (*pgxpool.Config, error) {
+	dbConfig, err := pgxpool.ParseConfig(cfg.DSN)
 	if err != nil {
 		return nil, err
 	}
 
-	dbConfig.MaxConns = defaultMaxConns
-	dbConfig.MinConns = defaultMinConns
-	dbConfig.MaxConnLifetime = defaultMaxConnLifetime
-	dbConfig.MaxConnIdleTime = defaultMaxConnIdleTime
-	dbConfig.HealthCheckPeriod = defaultHealthCheckPeriod
-	dbConfig.ConnConfig.ConnectTimeout = defaultConnectTimeout
+	dbConfig.MaxConns = cfg.MaxConns
+	dbConfig.MinConns = cfg.MinConns
+	dbConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	dbConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	dbConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	dbConfig.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
 
 	dbConfig.BeforeAcquire = func(ctx context.Context, c *pgx.Conn) bool {
-		log.Println("Before acquiring the connection pool to the database!!")
+		slog.DebugContext(ctx, "Acquiring database connection", "pid", c.PgConn().PID())
 		return true
 	}
 
 	dbConfig.AfterRelease = func(c *pgx.Conn) bool {
-		log.Println("After releasing the connection pool to the database!!")
+		slog.Debug("Released database connection", "pid", c.PgConn().PID())
 		return true
 	}
 
 	dbConfig.BeforeClose = func(c *pgx.Conn) {
-		log.Println("Closed the connection pool to the database!!")
+		slog.Debug("Closed database connection", "pid", c.PgConn().PID())
 	}
 
 	return dbConfig, nil
 }
 
-func CreatePool() (*pgxpool.Pool, error) {
-	config, err := dbConfig()
+func CreatePool(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := dbConfig(cfg)
 	if err != nil {
-		log.Println("Failed to create config!!")
+		slog.Error("Failed to create database pool config", "error", err)
 		return nil, err
 	}
 
-	pool, err := pgxpool.NewWithConfig(ctx, config)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
-		log.Println("Error while creating connection to the database!!")
+		slog.Error("Error while creating connection to the database", "error", err)
 		return nil, err
 	}
 
 	err = pool.Ping(ctx)
 	if err != nil {
-		log.Println("Could not ping database!!")
+		slog.Error("Could not ping database", "error", err)
 		return nil, err
 	}
 
-	log.Println("Connected to the Database!!")
+	slog.Info("Connected to the database", "maxConns", cfg.MaxConns, "minConns", cfg.MinConns)
 
-	DB = pool
 	return pool, nil
 }
@@ -0,0 +1,17 @@
+DROP AGGREGATE IF EXISTS jsonb_set_agg(jsonb, text[], jsonb, boolean);
+DROP FUNCTION IF EXISTS jsonb_set(jsonb, jsonb, text[], jsonb, boolean);
+
+DROP TABLE IF EXISTS "contact_us";
+DROP TABLE IF EXISTS "documents";
+DROP TABLE IF EXISTS "document_cover";
+DROP TABLE IF EXISTS "photos";
+DROP TABLE IF EXISTS "album";
+DROP TABLE IF EXISTS "blogs";
+DROP TABLE IF EXISTS "news";
+DROP TABLE IF EXISTS "category";
+DROP TABLE IF EXISTS "client_token";
+DROP TABLE IF EXISTS "project_to_service";
+DROP TABLE IF EXISTS "user_to_project";
+DROP TABLE IF EXISTS "services";
+DROP TABLE IF EXISTS "project";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,141 @@
+-- baseline schema, written with IF NOT EXISTS so databases created from the
+-- old hand maintained schema file can adopt migrations without changes
+
+CREATE TABLE IF NOT EXISTS "users" (
+  "user_id" varchar PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "email" varchar NOT NULL,
+  "created_at" timestamp DEFAULT (now()),
+  "role" varchar NOT NULL,
+  "cursor" serial NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "project" (
+  "project_id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  "project_name" varchar NOT NULL UNIQUE,
+  "created_at" timestamp DEFAULT (now()),
+  "cover_image" varchar NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "services" (
+  "service_id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  "service_name" varchar NOT NULL,
+  "icon" varchar NOT NULL DEFAULT '',
+  "created_at" timestamp DEFAULT (now())
+);
+
+CREATE TABLE IF NOT EXISTS "user_to_project" (
+  "user_id" varchar REFERENCES "users" ("user_id") ON DELETE CASCADE ON UPDATE CASCADE,
+  "project_id" uuid REFERENCES "project" ("project_id") ON DELETE CASCADE ON UPDATE CASCADE,
+  PRIMARY KEY ("user_id", "project_id")
+);
+
+CREATE TABLE IF NOT EXISTS "project_to_service" (
+  "project_id" uuid REFERENCES "project" ("project_id") ON DELETE CASCADE ON UPDATE CASCADE,
+  "service_id" uuid REFERENCES "services" ("service_id") ON DELETE CASCADE ON UPDATE CASCADE,
+  PRIMARY KEY ("service_id", "project_id")
+);
+
+CREATE TABLE IF NOT EXISTS "client_token" (
+  "token" varchar PRIMARY KEY,
+  "project_id" uuid REFERENCES "project" ("project_id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+/*
+    service schema
+*/
+
+/* category */
+CREATE TABLE IF NOT EXISTS "category" (
+  "category_id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  "parent_id" uuid REFERENCES "category" ("category_id") ON DELETE CASCADE ON UPDATE CASCADE,
+  "project_id" uuid NOT NULL REFERENCES "project" ("project_id") ON DELETE CASCADE ON UPDATE CASCADE,
+  "category_name" varchar NOT NULL,
+  "created_at" timestamp DEFAULT (now())
+);
+
+/* news */
+CREATE TABLE IF NOT EXISTS "news" (
+  "news_id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  "project_id" uuid REFERENCES "project" ("project_id") ON UPDATE CASCADE,
+  "title" varchar NOT NULL,
+  "link" varchar NOT NULL,
+  "text" varchar NOT NULL,
+  "image" varchar NOT NULL,
+  "created_at" timestamp DEFAULT (now())
+);
+
+/* blogs */
+CREATE TABLE IF NOT EXISTS "blogs" (
+  "blog_id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  "user_id" varchar NOT NULL REFERENCES "users" ("user_id") ON UPDATE CASCADE,
+  "project_id" uuid NOT NULL REFERENCES "project" ("project_id") ON UPDATE CASCADE,
+  "category_id" uuid NOT NULL REFERENCES "category" ("category_id") ON UPDATE CASCADE,
+  "author" varchar NOT NULL,
+  "title" varchar NOT NULL,
+  "cover_image" varchar NOT NULL,
+  "short_text" varchar,
+  "content" varchar NOT NULL,
+  "created_at" timestamp DEFAULT (now()),
+  "updated_at" timestamp DEFAULT (now())
+);
+
+/* gallery */
+
+/* album */
+CREATE TABLE IF NOT EXISTS "album" (
+  "album_id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  "project_id" uuid NOT NULL REFERENCES "project" ("project_id") ON UPDATE CASCADE,
+  "user_id" varchar NOT NULL REFERENCES "users" ("user_id") ON UPDATE CASCADE,
+  "name" varchar NOT NULL,
+  "cover" varchar NOT NULL,
+  "created_at" timestamp DEFAULT (now())
+);
+
+/* photos */
+CREATE TABLE IF NOT EXISTS "photos" (
+  "photo_id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  "album_id" uuid NOT NULL REFERENCES "album" ("album_id") ON UPDATE CASCADE ON DELETE CASCADE,
+  "path" varchar NOT NULL,
+  "created_at" timestamp DEFAULT (now()),
+  "user_id" varchar NOT NULL REFERENCES "users" ("user_id") ON UPDATE CASCADE
+);
+
+/* documents */
+
+/* cover */
+CREATE TABLE IF NOT EXISTS "document_cover" (
+  "cover_id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  "project_id" uuid NOT NULL REFERENCES "project" ("project_id") ON UPDATE CASCADE,
+  "user_id" varchar NOT NULL REFERENCES "users" ("user_id") ON UPDATE CASCADE,
+  "name" varchar NOT NULL,
+  "created_at" timestamp DEFAULT (now())
+);
+
+/* document files */
+CREATE TABLE IF NOT EXISTS "documents" (
+  "document_id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  "cover_id" uuid NOT NULL REFERENCES "document_cover" ("cover_id") ON UPDATE CASCADE ON DELETE CASCADE,
+  "path" varchar NOT NULL,
+  "created_at" timestamp DEFAULT (now()),
+  "user_id" varchar NOT NULL REFERENCES "users" ("user_id") ON UPDATE CASCADE,
+  "name" varchar NOT NULL
+);
+
+/* contact us */
+CREATE TABLE IF NOT EXISTS "contact_us" (
+  "id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  "project_id" uuid NOT NULL REFERENCES "project" ("project_id") ON UPDATE CASCADE,
+  "created_at" timestamp DEFAULT (now()),
+  "data" jsonb NOT NULL
+);
+
+/*
+    custom function and aggregate
+*/
+CREATE OR REPLACE FUNCTION jsonb_set(x jsonb, y jsonb, p text[], e jsonb, b boolean)
+RETURNS jsonb LANGUAGE sql AS $$
+SELECT CASE WHEN x IS NULL THEN e ELSE jsonb_set(x, p, e, b) END ; $$ ;
+
+CREATE OR REPLACE AGGREGATE jsonb_set_agg(x jsonb, p text[], e jsonb, b boolean)
+( STYPE = jsonb, SFUNC = jsonb_set );
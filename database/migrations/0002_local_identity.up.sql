@@ -0,0 +1,10 @@
+-- accounts for IDENTITY_PROVIDER=local, unused when firebase holds the users
+
+CREATE TABLE IF NOT EXISTS "identity_users" (
+  "user_id" varchar PRIMARY KEY DEFAULT (gen_random_uuid()::varchar),
+  "email" varchar NOT NULL UNIQUE,
+  "name" varchar NOT NULL DEFAULT '',
+  "password_hash" varchar NOT NULL,
+  "claims" jsonb NOT NULL DEFAULT '{}',
+  "created_at" timestamp DEFAULT (now())
+);
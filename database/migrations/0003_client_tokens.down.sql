@@ -0,0 +1,20 @@
+-- lossy: revoked tokens are deleted and the label, scopes, creation and
+-- expiry of the others are dropped, none of it comes back when migrating up
+-- again. hashes can't be turned back into tokens either, every project needs
+-- a new token after rolling back
+DELETE FROM "client_token" WHERE "revoked_at" IS NOT NULL;
+
+DROP INDEX IF EXISTS "client_token_project_id_idx";
+
+ALTER TABLE "client_token"
+  DROP CONSTRAINT IF EXISTS "client_token_token_hash_key",
+  DROP COLUMN IF EXISTS "token_id",
+  DROP COLUMN IF EXISTS "label",
+  DROP COLUMN IF EXISTS "scopes",
+  DROP COLUMN IF EXISTS "created_at",
+  DROP COLUMN IF EXISTS "expires_at",
+  DROP COLUMN IF EXISTS "revoked_at",
+  ALTER COLUMN "project_id" DROP NOT NULL;
+
+ALTER TABLE "client_token" RENAME COLUMN "token_hash" TO "token";
+ALTER TABLE "client_token" ADD PRIMARY KEY ("token");
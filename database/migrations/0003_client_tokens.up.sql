@@ -0,0 +1,22 @@
+-- a project can hold many client tokens, each limited to a set of scopes.
+-- Only a sha256 hash of a token is stored, the token itself is shown once
+
+ALTER TABLE "client_token" DROP CONSTRAINT IF EXISTS "client_token_pkey";
+ALTER TABLE "client_token" RENAME COLUMN "token" TO "token_hash";
+UPDATE "client_token" SET "token_hash" = encode(sha256(convert_to("token_hash", 'UTF8')), 'hex');
+
+ALTER TABLE "client_token"
+  ALTER COLUMN "project_id" SET NOT NULL,
+  ADD COLUMN "token_id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  ADD COLUMN "label" varchar NOT NULL DEFAULT 'default',
+  ADD COLUMN "scopes" varchar[] NOT NULL DEFAULT '{}',
+  ADD COLUMN "created_at" timestamp DEFAULT (now()),
+  ADD COLUMN "expires_at" timestamp,
+  ADD COLUMN "revoked_at" timestamp,
+  ADD CONSTRAINT "client_token_token_hash_key" UNIQUE ("token_hash");
+
+-- tokens issued before scopes existed keep access to every public endpoint
+UPDATE "client_token"
+SET "scopes" = ARRAY['read:news', 'read:blogs', 'read:gallery', 'read:documents', 'write:contact-us'];
+
+CREATE INDEX IF NOT EXISTS "client_token_project_id_idx" ON "client_token" ("project_id");
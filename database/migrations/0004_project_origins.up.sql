@@ -0,0 +1,22 @@
+-- browser origins allowed to call the public endpoints with a project's
+-- client tokens, CORS is built from the same rows.
+-- The client websites allowed before origins were kept per project are
+-- seeded for every existing project, the old list wasn't per project either.
+-- Origins that don't belong to a project are removed from the dashboard
+
+CREATE TABLE IF NOT EXISTS "project_origin" (
+  "origin_id" uuid UNIQUE NOT NULL DEFAULT (gen_random_uuid()),
+  "project_id" uuid NOT NULL,
+  "origin" varchar NOT NULL,
+  "created_at" timestamp DEFAULT (now()),
+  PRIMARY KEY ("project_id", "origin")
+);
+
+ALTER TABLE "project_origin" ADD FOREIGN KEY ("project_id") REFERENCES "project" ("project_id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "project_origin_origin_idx" ON "project_origin" ("origin");
+
+INSERT INTO "project_origin" ("project_id", "origin")
+SELECT p."project_id", o."origin"
+FROM "project" p
+CROSS JOIN (VALUES ('https://ecrimino.com'), ('https://prise-rdc.com')) AS o ("origin");
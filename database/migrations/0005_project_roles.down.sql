@@ -0,0 +1,2 @@
+ALTER TABLE "user_to_project" DROP CONSTRAINT IF EXISTS "user_to_project_role_check";
+ALTER TABLE "user_to_project" DROP COLUMN IF EXISTS "role";
@@ -0,0 +1,9 @@
+-- every member gets a role in the project instead of relying on the global
+-- role claim. Existing members could already change everything, so they
+-- become editors
+
+ALTER TABLE "user_to_project" ADD COLUMN "role" varchar NOT NULL DEFAULT 'viewer';
+UPDATE "user_to_project" SET "role" = 'editor';
+
+ALTER TABLE "user_to_project"
+  ADD CONSTRAINT "user_to_project_role_check" CHECK ("role" IN ('owner', 'editor', 'author', 'viewer'));
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "user_service_permission";
+
+ALTER TABLE "user_to_project" DROP COLUMN IF EXISTS "permissions_restricted";
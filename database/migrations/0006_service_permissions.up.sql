@@ -0,0 +1,16 @@
+-- limits a project member to some services. Members who were never granted
+-- a service keep the access of their project role. From the first grant on
+-- only the listed services and permissions are allowed, removing grants
+-- never gives back the access of the role, only lifting the restriction does
+
+ALTER TABLE "user_to_project" ADD COLUMN "permissions_restricted" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS "user_service_permission" (
+  "user_id" varchar NOT NULL,
+  "project_id" uuid NOT NULL,
+  "service" varchar NOT NULL,
+  "permissions" varchar[] NOT NULL DEFAULT '{}',
+  "updated_at" timestamp DEFAULT (now()),
+  PRIMARY KEY ("user_id", "project_id", "service"),
+  FOREIGN KEY ("user_id", "project_id") REFERENCES "user_to_project" ("user_id", "project_id") ON DELETE CASCADE ON UPDATE CASCADE
+);
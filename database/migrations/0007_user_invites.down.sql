@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "user_invite";
+
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_invite_status_check";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "invite_status";
@@ -0,0 +1,20 @@
+-- users are invited instead of being mailed a generated password. An invite
+-- holds a sha256 hash of a single use token, the invitee sets their own
+-- password when accepting it
+
+ALTER TABLE "users" ADD COLUMN "invite_status" varchar NOT NULL DEFAULT 'accepted';
+ALTER TABLE "users"
+  ADD CONSTRAINT "users_invite_status_check" CHECK ("invite_status" IN ('pending', 'accepted', 'revoked'));
+
+CREATE TABLE IF NOT EXISTS "user_invite" (
+  "invite_id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  "user_id" varchar NOT NULL REFERENCES "users" ("user_id") ON DELETE CASCADE ON UPDATE CASCADE,
+  "token_hash" varchar NOT NULL UNIQUE,
+  "invited_by" varchar,
+  "created_at" timestamp DEFAULT (now()),
+  "expires_at" timestamp NOT NULL,
+  "accepted_at" timestamp,
+  "revoked_at" timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "user_invite_user_id_idx" ON "user_invite" ("user_id");
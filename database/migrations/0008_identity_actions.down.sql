@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "account_email_request";
+DROP TABLE IF EXISTS "identity_action";
+
+ALTER TABLE "identity_users" DROP COLUMN IF EXISTS "email_verified";
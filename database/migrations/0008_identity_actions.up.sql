@@ -0,0 +1,25 @@
+-- single use codes the local identity provider mails for password resets and
+-- email verification, firebase keeps its own
+
+ALTER TABLE "identity_users" ADD COLUMN "email_verified" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS "identity_action" (
+  "token_hash" varchar PRIMARY KEY,
+  "user_id" varchar NOT NULL REFERENCES "identity_users" ("user_id") ON DELETE CASCADE,
+  "action" varchar NOT NULL CHECK ("action" IN ('password_reset', 'verify_email')),
+  "created_at" timestamp DEFAULT (now()),
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "identity_action_user_id_idx" ON "identity_action" ("user_id");
+
+-- every reset or verification email that was requested, per email address
+-- so the endpoints can be limited without telling if the account exists
+CREATE TABLE IF NOT EXISTS "account_email_request" (
+  "email" varchar NOT NULL,
+  "action" varchar NOT NULL,
+  "created_at" timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "account_email_request_email_idx" ON "account_email_request" ("email", "action", "created_at");
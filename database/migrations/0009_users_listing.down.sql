@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "users_email_cursor_idx";
+DROP INDEX IF EXISTS "users_name_cursor_idx";
+DROP INDEX IF EXISTS "users_cursor_idx";
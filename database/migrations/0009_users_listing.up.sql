@@ -0,0 +1,6 @@
+-- the users listing pages on the cursor column, sorted by creation, name or
+-- email
+
+CREATE UNIQUE INDEX IF NOT EXISTS "users_cursor_idx" ON "users" ("cursor");
+CREATE INDEX IF NOT EXISTS "users_name_cursor_idx" ON "users" ("name", "cursor");
+CREATE INDEX IF NOT EXISTS "users_email_cursor_idx" ON "users" ("email", "cursor");
@@ -0,0 +1,5 @@
+ALTER TABLE "identity_users" DROP COLUMN IF EXISTS "disabled";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "suspended_at";
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_status_check";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "status";
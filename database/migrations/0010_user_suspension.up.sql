@@ -0,0 +1,9 @@
+-- suspended users keep their row, memberships and content but can't sign in.
+-- The local identity provider disables the account like firebase does
+
+ALTER TABLE "users" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+ALTER TABLE "users"
+  ADD CONSTRAINT "users_status_check" CHECK ("status" IN ('active', 'suspended'));
+ALTER TABLE "users" ADD COLUMN "suspended_at" timestamp;
+
+ALTER TABLE "identity_users" ADD COLUMN "disabled" boolean NOT NULL DEFAULT false;
@@ -0,0 +1,4 @@
+-- tokens issued before the second of sessions_revoked_at are rejected, set by
+-- admins and whenever the role of the user changes
+
+ALTER TABLE "users" ADD COLUMN "sessions_revoked_at" timestamp;
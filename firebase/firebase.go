@@ -2,19 +2,18 @@ package firebase
 
 import (
 	"context"
-	"os"
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/auth"
 	"google.golang.org/api/option"
-)
 
-var FirebaseApp *firebase.App
-var FirebaseClient *auth.Client
-var ctx context.Context = context.Background()
+	"github.com/rohan031/adgytec-api/config"
+)
 
-func InitFirebaseAdminSdk() (*auth.Client, error) {
-	configBytes := []byte(os.Getenv("CONFIG"))
+// InitFirebaseAdminSdk creates the auth client, ctx is kept by the sdk for
+// token refreshes so it must live as long as the client
+func InitFirebaseAdminSdk(ctx context.Context, cfg config.FirebaseConfig) (*auth.Client, error) {
+	configBytes := []byte(cfg.Credentials)
 	opt := option.WithCredentialsJSON(configBytes)
 
 	app, err := firebase.NewApp(ctx, nil, opt)
@@ -27,7 +26,19 @@ func InitFirebaseAdminSdk() (*auth.Client, error) {
 		return nil, err
 	}
 
-	FirebaseApp = app
-	FirebaseClient = client
 	return client, nil
 }
+
+// reserved uid that is never assigned, used to probe the auth api
+const pingUID = "adgytec-readiness-probe"
+
+// Ping checks that the auth api is reachable with the configured credentials
+// by looking up a user that never exists
+func Ping(ctx context.Context, client *auth.Client) error {
+	_, err := client.GetUser(ctx, pingUID)
+	if err == nil || auth.IsUserNotFound(err) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,154 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maximum time a single dependency check may take
+const checkTimeout = time.Second * 3
+
+const (
+	statusUp   = "up"
+	statusDown = "down"
+)
+
+// Check verifies a single dependency, returning nil when it is usable.
+// When CacheFor is set the result is reused for that long, for checks calling
+// remote apis that shouldn't be hit on every probe
+type Check struct {
+	Name     string
+	Check    func(ctx context.Context) error
+	CacheFor time.Duration
+}
+
+// checkResult is public, errors are only logged since they can carry
+// details of the infrastructure
+type checkResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// cachedResult is the last result of a check with CacheFor
+type cachedResult struct {
+	mu      sync.Mutex
+	result  checkResult
+	expires time.Time
+}
+
+// response has the shape of the api responses, kept here so health doesn't
+// depend on a versioned api
+type response struct {
+	Error bool   `json:"error"`
+	Data  report `json:"data"`
+}
+
+type report struct {
+	Status string        `json:"status"`
+	Checks []checkResult `json:"checks,omitempty"`
+}
+
+// Liveness reports that the process is running and able to serve requests,
+// it never touches external dependencies
+func Liveness(w http.ResponseWriter, r *http.Request) {
+	payload := response{Error: false, Data: report{Status: statusUp}}
+
+	writeJSON(w, http.StatusOK, payload)
+}
+
+// Readiness runs every check concurrently and responds with 503 when any of
+// them fails so traffic is routed away from the instance
+func Readiness(checks ...Check) http.HandlerFunc {
+	cache := make([]cachedResult, len(checks))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		results := make([]checkResult, len(checks))
+
+		wg := new(sync.WaitGroup)
+		for i, c := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = cache[i].run(r.Context(), c)
+			}()
+		}
+		wg.Wait()
+
+		status := http.StatusOK
+		data := report{Status: statusUp, Checks: results}
+		for _, res := range results {
+			if res.Status == statusDown {
+				status = http.StatusServiceUnavailable
+				data.Status = statusDown
+			}
+		}
+
+		payload := response{Error: status != http.StatusOK, Data: data}
+
+		writeJSON(w, status, payload)
+	}
+}
+
+// writeJSON writes the payload like helper.EncodeJSON, which depends on the
+// api packages
+func writeJSON(w http.ResponseWriter, status int, payload response) {
+	data, err := json.MarshalIndent(payload, "", "\t")
+	if err != nil {
+		slog.Error("Error encoding response", "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, err = w.Write(data)
+	if err != nil {
+		slog.Error("Error writing response", "error", err)
+	}
+}
+
+// run reuses the cached result while it is fresh, concurrent probes wait for
+// the one running the check
+func (cr *cachedResult) run(ctx context.Context, c Check) checkResult {
+	if c.CacheFor <= 0 {
+		return runCheck(ctx, c)
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if time.Now().Before(cr.expires) {
+		return cr.result
+	}
+
+	cr.result = runCheck(ctx, c)
+	cr.expires = time.Now().Add(c.CacheFor)
+	return cr.result
+}
+
+func runCheck(ctx context.Context, c Check) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Check(ctx)
+	latency := time.Since(start)
+
+	res := checkResult{
+		Name:      c.Name,
+		Status:    statusUp,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error checking dependency", "check", c.Name, "error", err)
+		res.Status = statusDown
+	}
+
+	return res
+}
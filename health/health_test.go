@@ -0,0 +1,47 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadiness(t *testing.T) {
+	var calls atomic.Int32
+	failing := errors.New("dial tcp 10.0.0.12:5432: connection refused")
+
+	readiness := Readiness(
+		Check{Name: "database", Check: func(ctx context.Context) error { return failing }},
+		Check{Name: "auth", CacheFor: time.Minute, Check: func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		}},
+	)
+
+	for i := 0; i < 3; i++ {
+		res := httptest.NewRecorder()
+		readiness.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		if res.Code != http.StatusServiceUnavailable {
+			t.Fatalf("unexpected status code: got %v want %v", res.Code, http.StatusServiceUnavailable)
+		}
+
+		// the error details stay in the logs
+		body := res.Body.String()
+		if strings.Contains(body, "10.0.0.12") || strings.Contains(body, "connection refused") {
+			t.Errorf("readiness exposed the error of a check: %s", body)
+		}
+		if !strings.Contains(body, `"status": "down"`) {
+			t.Errorf("readiness didn't report the failed check: %s", body)
+		}
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("cached check ran %v times, want 1", got)
+	}
+}
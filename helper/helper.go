@@ -5,7 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 
@@ -75,7 +75,7 @@ func DecodeJSON[T Constraint](w http.ResponseWriter, r *http.Request, maxBytes i
 			}
 
 		default:
-			log.Printf("Error decoding request body: %v\n", err)
+			slog.ErrorContext(r.Context(), "Error decoding request body", "error", err)
 			return payload, err
 		}
 	}
@@ -95,7 +95,7 @@ func EncodeJSON[T any](w http.ResponseWriter, status int, data T) {
 	jsonRes, err := json.MarshalIndent(data, "", "\t")
 
 	if err != nil {
-		log.Println(err)
+		slog.Error("Error encoding response", "error", err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
@@ -105,7 +105,7 @@ func EncodeJSON[T any](w http.ResponseWriter, status int, data T) {
 
 	_, err = w.Write(jsonRes)
 	if err != nil {
-		log.Println(err)
+		slog.Error("Error writing response", "error", err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
@@ -141,7 +141,7 @@ func ParseMultipartForm(w http.ResponseWriter, r *http.Request, maxSize int) err
 	r.Body = http.MaxBytesReader(w, r.Body, int64(maxSize))
 	err := r.ParseMultipartForm(int64(maxSize))
 	if err != nil {
-		log.Println(err)
+		slog.DebugContext(r.Context(), "Error parsing multipart form data", "error", err)
 		if strings.Contains(err.Error(), "http: request body too large") {
 			messgage := "request body too large. Limit 10MB"
 			HandleError(w, &custom.MalformedRequest{Status: http.StatusRequestEntityTooLarge, Message: messgage})
@@ -164,7 +164,7 @@ func ParseMultipartForm(w http.ResponseWriter, r *http.Request, maxSize int) err
 			return err
 		}
 
-		log.Printf("Error parsing multipart form data: %v\n", err)
+		slog.ErrorContext(r.Context(), "Error parsing multipart form data", "error", err)
 		HandleError(w, err)
 		return err
 	}
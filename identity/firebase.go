@@ -0,0 +1,158 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"google.golang.org/api/iterator"
+
+	"github.com/rohan031/adgytec-api/config"
+	"github.com/rohan031/adgytec-api/firebase"
+)
+
+// Firebase adapts the firebase admin sdk, sdk errors are translated to the
+// errors of this package so callers never import the sdk
+type Firebase struct {
+	client *auth.Client
+}
+
+func NewFirebase(ctx context.Context, cfg config.FirebaseConfig) (*Firebase, error) {
+	client, err := firebase.InitFirebaseAdminSdk(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Firebase{client: client}, nil
+}
+
+func firebaseError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case auth.IsUserNotFound(err):
+		return ErrUserNotFound
+	case auth.IsEmailAlreadyExists(err):
+		return ErrEmailExists
+	case auth.IsIDTokenExpired(err):
+		return ErrTokenExpired
+	case auth.IsIDTokenInvalid(err):
+		return ErrTokenInvalid
+	}
+
+	return err
+}
+
+func firebaseUser(u *auth.UserRecord) *User {
+	claims := u.CustomClaims
+	if claims == nil {
+		claims = map[string]any{}
+	}
+
+	return &User{
+		UID:      u.UID,
+		Email:    u.Email,
+		Name:     u.DisplayName,
+		Claims:   claims,
+		Disabled: u.Disabled,
+	}
+}
+
+func (f *Firebase) VerifyToken(ctx context.Context, token string) (*Token, error) {
+	t, err := f.client.VerifyIDToken(ctx, token)
+	if err != nil {
+		return nil, firebaseError(err)
+	}
+
+	return &Token{UID: t.UID, Claims: t.Claims, IssuedAt: time.Unix(t.IssuedAt, 0)}, nil
+}
+
+func (f *Firebase) GetUser(ctx context.Context, uid string) (*User, error) {
+	u, err := f.client.GetUser(ctx, uid)
+	if err != nil {
+		return nil, firebaseError(err)
+	}
+
+	return firebaseUser(u), nil
+}
+
+func (f *Firebase) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	u, err := f.client.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, firebaseError(err)
+	}
+
+	return firebaseUser(u), nil
+}
+
+func (f *Firebase) ListUsers(ctx context.Context) ([]*User, error) {
+	var users []*User
+
+	iter := f.client.Users(ctx, "")
+	for {
+		u, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, firebaseError(err)
+		}
+
+		users = append(users, firebaseUser(u.UserRecord))
+	}
+
+	return users, nil
+}
+
+func (f *Firebase) CreateUser(ctx context.Context, user UserToCreate) (*User, error) {
+	params := (&auth.UserToCreate{}).Email(user.Email).DisplayName(user.Name).Password(user.Password)
+	u, err := f.client.CreateUser(ctx, params)
+	if err != nil {
+		return nil, firebaseError(err)
+	}
+
+	return firebaseUser(u), nil
+}
+
+func (f *Firebase) UpdateUser(ctx context.Context, uid string, user UserToUpdate) error {
+	params := &auth.UserToUpdate{}
+	if user.Name != "" {
+		params = params.DisplayName(user.Name)
+	}
+	if user.Password != "" {
+		params = params.Password(user.Password)
+	}
+	if user.Disabled != nil {
+		params = params.Disabled(*user.Disabled)
+	}
+
+	_, err := f.client.UpdateUser(ctx, uid, params)
+	return firebaseError(err)
+}
+
+func (f *Firebase) DeleteUser(ctx context.Context, uid string) error {
+	return firebaseError(f.client.DeleteUser(ctx, uid))
+}
+
+func (f *Firebase) SetClaims(ctx context.Context, uid string, claims map[string]any) error {
+	return firebaseError(f.client.SetCustomUserClaims(ctx, uid, claims))
+}
+
+func (f *Firebase) PasswordResetLink(ctx context.Context, email string) (string, error) {
+	link, err := f.client.PasswordResetLink(ctx, email)
+	return link, firebaseError(err)
+}
+
+func (f *Firebase) EmailVerificationLink(ctx context.Context, email string) (string, error) {
+	link, err := f.client.EmailVerificationLink(ctx, email)
+	return link, firebaseError(err)
+}
+
+func (f *Firebase) RevokeSessions(ctx context.Context, uid string) error {
+	return firebaseError(f.client.RevokeRefreshTokens(ctx, uid))
+}
+
+func (f *Firebase) Ping(ctx context.Context) error {
+	return firebase.Ping(ctx, f.client)
+}
@@ -0,0 +1,109 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/rohan031/adgytec-api/config"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrEmailExists        = errors.New("email already exists")
+	ErrTokenExpired       = errors.New("token expired")
+	ErrTokenInvalid       = errors.New("token invalid")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrActionCodeInvalid  = errors.New("action code invalid, used or expired")
+	ErrUserDisabled       = errors.New("user disabled")
+)
+
+// Token is a verified id token
+type Token struct {
+	UID    string
+	Claims map[string]any
+	// second precision, like the iat claim
+	IssuedAt time.Time
+}
+
+type User struct {
+	UID      string
+	Email    string
+	Name     string
+	Claims   map[string]any
+	Disabled bool
+}
+
+type UserToCreate struct {
+	Email    string
+	Name     string
+	Password string
+}
+
+// UserToUpdate holds the fields to change, empty fields are left as they are
+type UserToUpdate struct {
+	Name     string
+	Password string
+	// disabled accounts can't sign in, nil leaves it as it is
+	Disabled *bool
+}
+
+// Provider is where dashboard accounts live and who vouches for their tokens
+type Provider interface {
+	VerifyToken(ctx context.Context, token string) (*Token, error)
+	GetUser(ctx context.Context, uid string) (*User, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	// ListUsers returns every account, meant for maintenance tasks only
+	ListUsers(ctx context.Context) ([]*User, error)
+	CreateUser(ctx context.Context, user UserToCreate) (*User, error)
+	UpdateUser(ctx context.Context, uid string, user UserToUpdate) error
+	DeleteUser(ctx context.Context, uid string) error
+	// SetClaims replaces every custom claim of the user
+	SetClaims(ctx context.Context, uid string, claims map[string]any) error
+	// PasswordResetLink and EmailVerificationLink return a link to mail to
+	// the user, ErrUserNotFound when no account has the email
+	PasswordResetLink(ctx context.Context, email string) (string, error)
+	EmailVerificationLink(ctx context.Context, email string) (string, error)
+	// RevokeSessions stops the user from getting new tokens without signing
+	// in again, tokens already issued stay valid until they expire
+	RevokeSessions(ctx context.Context, uid string) error
+	Ping(ctx context.Context) error
+}
+
+// PasswordSignIn is implemented by providers that issue their own tokens,
+// with firebase the frontend signs in against firebase directly
+type PasswordSignIn interface {
+	SignIn(ctx context.Context, email, password string) (string, error)
+}
+
+// ActionCodes is implemented by providers whose email links point at the
+// dashboard, the code from the link is handed back through the api. Firebase
+// links open firebase hosted pages instead
+type ActionCodes interface {
+	ResetPassword(ctx context.Context, code, password string) error
+	VerifyEmail(ctx context.Context, code string) error
+}
+
+// DB is the part of *pgxpool.Pool the local provider needs
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// New creates the provider selected by cfg.Provider, db is only used by the
+// local provider. ctx must outlive the provider, see firebase.InitFirebaseAdminSdk
+func New(ctx context.Context, cfg config.IdentityConfig, db DB) (Provider, error) {
+	switch cfg.Provider {
+	case config.IdentityFirebase:
+		return NewFirebase(ctx, cfg.Firebase)
+	case config.IdentityLocal:
+		return NewLocal(cfg.Local, db)
+	}
+
+	return nil, fmt.Errorf("unknown identity provider %q", cfg.Provider)
+}
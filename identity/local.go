@@ -0,0 +1,475 @@
+package identity
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/rohan031/adgytec-api/config"
+)
+
+const (
+	getLocalUser = `
+		SELECT user_id, email, name, claims, disabled
+		FROM identity_users
+		WHERE user_id = @userId
+	`
+
+	getLocalUserByEmail = `
+		SELECT user_id, email, name, claims, disabled
+		FROM identity_users
+		WHERE email = @email
+	`
+
+	listLocalUsers = `
+		SELECT user_id, email, name, claims, disabled
+		FROM identity_users
+		ORDER BY created_at
+	`
+
+	getLocalCredentials = `
+		SELECT user_id, password_hash, disabled
+		FROM identity_users
+		WHERE email = @email
+	`
+
+	createLocalUser = `
+		INSERT INTO identity_users (email, name, password_hash)
+		VALUES (@email, @name, @passwordHash)
+		RETURNING user_id, email, name, claims, disabled
+	`
+
+	updateLocalUserName = `
+		UPDATE identity_users
+		SET name = @name
+		WHERE user_id = @userId
+	`
+
+	updateLocalUserPassword = `
+		UPDATE identity_users
+		SET password_hash = @passwordHash
+		WHERE user_id = @userId
+	`
+
+	updateLocalUserDisabled = `
+		UPDATE identity_users
+		SET disabled = @disabled
+		WHERE user_id = @userId
+	`
+
+	deleteLocalUser = `
+		DELETE FROM identity_users
+		WHERE user_id = @userId
+	`
+
+	setLocalUserClaims = `
+		UPDATE identity_users
+		SET claims = @claims
+		WHERE user_id = @userId
+	`
+
+	pingLocalUsers = `SELECT 1 FROM identity_users LIMIT 1`
+
+	createLocalAction = `
+		INSERT INTO identity_action (token_hash, user_id, action, expires_at)
+		VALUES (@tokenHash, @userId, @action, @expiresAt)
+	`
+
+	// expiry is compared with the time of the api, timestamps are utc
+	useLocalAction = `
+		UPDATE identity_action
+		SET used_at = @now
+		WHERE token_hash = @tokenHash AND action = @action
+			AND used_at IS NULL AND expires_at > @now
+		RETURNING user_id
+	`
+
+	// a password reset makes every other outstanding reset link useless
+	useLocalActions = `
+		UPDATE identity_action
+		SET used_at = @now
+		WHERE user_id = @userId AND action = @action AND used_at IS NULL
+	`
+
+	verifyLocalEmail = `
+		UPDATE identity_users
+		SET email_verified = true
+		WHERE user_id = @userId
+	`
+)
+
+// actions of the links mailed by the local provider
+const (
+	actionPasswordReset = "password_reset"
+	actionVerifyEmail   = "verify_email"
+)
+
+const actionTTL = time.Hour
+
+const minPasswordLength = 6
+
+// claims set by the provider itself, custom claims can't shadow them
+var reservedClaims = map[string]bool{
+	"sub": true, "iss": true, "aud": true, "exp": true, "iat": true,
+	"nbf": true, "jti": true, "email": true, "name": true, "user_id": true,
+}
+
+// compared against when the email is unknown so both paths take as long
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("adgytec-dummy-password"), bcrypt.DefaultCost)
+
+// Local keeps users in the identity_users table and signs EdDSA jwts with
+// the configured key, letting the whole auth flow run without firebase
+type Local struct {
+	db         DB
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+	issuer     string
+	ttl        time.Duration
+	actionURL  string
+}
+
+func NewLocal(cfg config.LocalIdentityConfig, db DB) (*Local, error) {
+	key, err := jwt.ParseEdPrivateKeyFromPEM([]byte(cfg.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("parsing identity private key: %w", err)
+	}
+
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("identity private key is not an ed25519 key")
+	}
+
+	return &Local{
+		db:         db,
+		privateKey: privateKey,
+		publicKey:  privateKey.Public().(ed25519.PublicKey),
+		issuer:     cfg.Issuer,
+		ttl:        cfg.TokenTTL,
+		actionURL:  strings.TrimSuffix(cfg.ActionURL, "/"),
+	}, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (l *Local) getUser(ctx context.Context, query string, args pgx.NamedArgs) (*User, error) {
+	var u User
+	err := l.db.QueryRow(ctx, query, args).Scan(&u.UID, &u.Email, &u.Name, &u.Claims, &u.Disabled)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// IssueToken signs a token carrying the current claims of the user
+func (l *Local) IssueToken(ctx context.Context, uid string) (string, error) {
+	u, err := l.GetUser(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{}
+	for key, value := range u.Claims {
+		claims[key] = value
+	}
+	claims["sub"] = u.UID
+	claims["user_id"] = u.UID
+	claims["email"] = u.Email
+	claims["name"] = u.Name
+	claims["iss"] = l.issuer
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(l.ttl).Unix()
+
+	return jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(l.privateKey)
+}
+
+func (l *Local) SignIn(ctx context.Context, email, password string) (string, error) {
+	var uid, hash string
+	var disabled bool
+	args := pgx.NamedArgs{"email": normalizeEmail(email)}
+	err := l.db.QueryRow(ctx, getLocalCredentials, args).Scan(&uid, &hash, &disabled)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+			return "", ErrInvalidCredentials
+		}
+		return "", err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err != nil {
+		return "", ErrInvalidCredentials
+	}
+
+	if disabled {
+		return "", ErrUserDisabled
+	}
+
+	return l.IssueToken(ctx, uid)
+}
+
+func (l *Local) VerifyToken(ctx context.Context, token string) (*Token, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		return l.publicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, fmt.Errorf("%w: %v", ErrTokenInvalid, err)
+	}
+
+	uid, _ := claims["sub"].(string)
+	if uid == "" || !claims.VerifyIssuer(l.issuer, true) || !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, ErrTokenInvalid
+	}
+
+	iat, _ := claims["iat"].(float64)
+	return &Token{UID: uid, Claims: claims, IssuedAt: time.Unix(int64(iat), 0)}, nil
+}
+
+func (l *Local) GetUser(ctx context.Context, uid string) (*User, error) {
+	return l.getUser(ctx, getLocalUser, pgx.NamedArgs{"userId": uid})
+}
+
+func (l *Local) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	return l.getUser(ctx, getLocalUserByEmail, pgx.NamedArgs{"email": normalizeEmail(email)})
+}
+
+func (l *Local) ListUsers(ctx context.Context) ([]*User, error) {
+	rows, err := l.db.Query(ctx, listLocalUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		var u User
+		err := rows.Scan(&u.UID, &u.Email, &u.Name, &u.Claims, &u.Disabled)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, &u)
+	}
+
+	return users, rows.Err()
+}
+
+func (l *Local) CreateUser(ctx context.Context, user UserToCreate) (*User, error) {
+	if len(user.Password) < minPasswordLength {
+		return nil, fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	args := pgx.NamedArgs{
+		"email":        normalizeEmail(user.Email),
+		"name":         user.Name,
+		"passwordHash": string(hash),
+	}
+	u, err := l.getUser(ctx, createLocalUser, args)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrEmailExists
+		}
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// exec runs a statement touching a single user, reporting ErrUserNotFound
+// when no row matched
+func (l *Local) exec(ctx context.Context, query string, args pgx.NamedArgs) error {
+	tag, err := l.db.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (l *Local) UpdateUser(ctx context.Context, uid string, user UserToUpdate) error {
+	if user.Name == "" && user.Password == "" && user.Disabled == nil {
+		// nothing to change, still reporting unknown users
+		_, err := l.GetUser(ctx, uid)
+		return err
+	}
+
+	if user.Password != "" {
+		if len(user.Password) < minPasswordLength {
+			return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+
+		err = l.exec(ctx, updateLocalUserPassword, pgx.NamedArgs{"userId": uid, "passwordHash": string(hash)})
+		if err != nil {
+			return err
+		}
+	}
+
+	if user.Disabled != nil {
+		err := l.exec(ctx, updateLocalUserDisabled, pgx.NamedArgs{"userId": uid, "disabled": *user.Disabled})
+		if err != nil {
+			return err
+		}
+	}
+
+	if user.Name == "" {
+		return nil
+	}
+
+	return l.exec(ctx, updateLocalUserName, pgx.NamedArgs{"userId": uid, "name": user.Name})
+}
+
+func (l *Local) DeleteUser(ctx context.Context, uid string) error {
+	return l.exec(ctx, deleteLocalUser, pgx.NamedArgs{"userId": uid})
+}
+
+func (l *Local) SetClaims(ctx context.Context, uid string, claims map[string]any) error {
+	for key := range claims {
+		if reservedClaims[key] {
+			return fmt.Errorf("claim %q is reserved", key)
+		}
+	}
+
+	if claims == nil {
+		claims = map[string]any{}
+	}
+
+	return l.exec(ctx, setLocalUserClaims, pgx.NamedArgs{"userId": uid, "claims": claims})
+}
+
+// actionLink stores a single use code for the user and returns the dashboard
+// page that hands it back
+func (l *Local) actionLink(ctx context.Context, email, action, page string) (string, error) {
+	u, err := l.GetUserByEmail(ctx, email)
+	if err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := hex.EncodeToString(b)
+
+	args := pgx.NamedArgs{
+		"tokenHash": hashCode(code),
+		"userId":    u.UID,
+		"action":    action,
+		"expiresAt": time.Now().Add(actionTTL).UTC(),
+	}
+	_, err = l.db.Exec(ctx, createLocalAction, args)
+	if err != nil {
+		return "", err
+	}
+
+	return l.actionURL + page + "?token=" + code, nil
+}
+
+// useAction consumes a code, returning the user it was issued for
+func (l *Local) useAction(ctx context.Context, code, action string) (string, error) {
+	var uid string
+	args := pgx.NamedArgs{"tokenHash": hashCode(code), "action": action, "now": time.Now().UTC()}
+	err := l.db.QueryRow(ctx, useLocalAction, args).Scan(&uid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrActionCodeInvalid
+		}
+		return "", err
+	}
+
+	return uid, nil
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// RevokeSessions only checks the user exists, tokens are signed in one go
+// without refresh tokens
+func (l *Local) RevokeSessions(ctx context.Context, uid string) error {
+	_, err := l.GetUser(ctx, uid)
+	return err
+}
+
+func (l *Local) PasswordResetLink(ctx context.Context, email string) (string, error) {
+	return l.actionLink(ctx, email, actionPasswordReset, "/reset-password")
+}
+
+func (l *Local) EmailVerificationLink(ctx context.Context, email string) (string, error) {
+	return l.actionLink(ctx, email, actionVerifyEmail, "/verify-email")
+}
+
+func (l *Local) ResetPassword(ctx context.Context, code, password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+
+	uid, err := l.useAction(ctx, code, actionPasswordReset)
+	if err != nil {
+		return err
+	}
+
+	err = l.UpdateUser(ctx, uid, UserToUpdate{Password: password})
+	if err != nil {
+		return err
+	}
+
+	// whoever could read the mailbox can't reuse older links
+	args := pgx.NamedArgs{"userId": uid, "action": actionPasswordReset, "now": time.Now().UTC()}
+	_, err = l.db.Exec(ctx, useLocalActions, args)
+	return err
+}
+
+func (l *Local) VerifyEmail(ctx context.Context, code string) error {
+	uid, err := l.useAction(ctx, code, actionVerifyEmail)
+	if err != nil {
+		return err
+	}
+
+	return l.exec(ctx, verifyLocalEmail, pgx.NamedArgs{"userId": uid})
+}
+
+func (l *Local) Ping(ctx context.Context) error {
+	var one int
+	err := l.db.QueryRow(ctx, pingLocalUsers).Scan(&one)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/rohan031/adgytec-api/v1/custom"
+)
+
+// contextHandler adds the request id, project id and user id found in the
+// context to every record logged with one of the slog *Context functions
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil {
+		record.AddAttrs(contextAttrs(ctx)...)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+func contextAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+
+	if requestId := middleware.GetReqID(ctx); requestId != "" {
+		attrs = append(attrs, slog.String("requestId", requestId))
+	}
+
+	// client token routes put the project in the context, dashboard routes
+	// carry it in the url
+	projectId, _ := ctx.Value(custom.ProjectId).(string)
+	if projectId == "" {
+		if rctx := chi.RouteContext(ctx); rctx != nil {
+			projectId = rctx.URLParam("projectId")
+		}
+	}
+	if projectId != "" {
+		attrs = append(attrs, slog.String("projectId", projectId))
+	}
+
+	if userId, _ := ctx.Value(custom.UserID).(string); userId != "" {
+		attrs = append(attrs, slog.String("userId", userId))
+	}
+
+	return attrs
+}
+
+// New returns a json logger writing records at or above level to w
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+
+	return slog.New(contextHandler{handler})
+}
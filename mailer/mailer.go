@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+
+	"github.com/rohan031/adgytec-api/config"
+)
+
+const (
+	smtpServer = "smtp.gmail.com"
+	smtpPort   = "587"
+)
+
+type Message struct {
+	To      []string
+	Subject string
+	// rendered html body
+	HTML string
+	// send from the private account instead of the default one
+	Private bool
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTP sends mail through gmail with the configured accounts
+type SMTP struct {
+	cfg config.EmailConfig
+}
+
+func NewSMTP(cfg config.EmailConfig) *SMTP {
+	return &SMTP{cfg: cfg}
+}
+
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("mail has no recipients")
+	}
+
+	from := m.cfg.From
+	password := m.cfg.Password
+
+	if msg.Private {
+		from = m.cfg.PrivateFrom
+		password = m.cfg.PrivatePassword
+	}
+
+	auth := smtp.PlainAuth("", from, password, smtpServer)
+
+	mime := "MIME-version: 1.0;\nContent-Type: multipart/related; boundary=\"MIMEBOUNDARY\"\n\n"
+	mime += "--MIMEBOUNDARY\n"
+	mime += "Content-Type: text/html; charset=\"UTF-8\"\n\n"
+	mime += msg.HTML + "\n"
+	mime += "--MIMEBOUNDARY--"
+
+	toHeader := "To: " + msg.To[0] + "\r\n"
+	subjectHeader := "Subject: " + msg.Subject + "\r\n"
+	headers := toHeader + subjectHeader
+
+	// net/smtp has no context support, bail out early if the caller gave up
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return smtp.SendMail(smtpServer+":"+smtpPort, auth, from, msg.To, []byte(headers+mime))
+}
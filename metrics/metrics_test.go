@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		token          string
+		open           bool
+		authorization  string
+		expectedStatus int
+	}{
+		{"open without token", "", true, "", http.StatusOK},
+		{"closed without token", "", false, "", http.StatusUnauthorized},
+		{"closed without token ignores header", "", false, "Bearer ", http.StatusUnauthorized},
+		{"missing token", "secret", false, "", http.StatusUnauthorized},
+		{"wrong token", "secret", false, "Bearer wrong", http.StatusUnauthorized},
+		{"token", "secret", false, "Bearer secret", http.StatusOK},
+		{"token required even when open", "secret", true, "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			res := httptest.NewRecorder()
+			Handler(tt.token, tt.open).ServeHTTP(res, req)
+			if res.Code != tt.expectedStatus {
+				t.Errorf("unexpected status code: got %v want %v", res.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/httprate"
+
+	"github.com/rohan031/adgytec-api/app"
+	"github.com/rohan031/adgytec-api/health"
+	"github.com/rohan031/adgytec-api/helper"
+	"github.com/rohan031/adgytec-api/logger"
+	"github.com/rohan031/adgytec-api/metrics"
+	"github.com/rohan031/adgytec-api/storage"
+	"github.com/rohan031/adgytec-api/v1/controllers"
+	v1Middleware "github.com/rohan031/adgytec-api/v1/middleware"
+	v1Router "github.com/rohan031/adgytec-api/v1/router"
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func handle400(router *chi.Mux) {
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		err := errors.New("404 route not found")
+
+		helper.ErrorResponse(w, err, http.StatusNotFound)
+	})
+
+	router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		err := errors.New("405 invalid request method")
+
+		helper.ErrorResponse(w, err, http.StatusMethodNotAllowed)
+	})
+}
+
+// Router builds the handler serving the whole api from its dependencies, the
+// server binary and the integration tests share it
+func Router(a *app.App, svc *services.Service) *chi.Mux {
+	cfg := a.Config
+
+	router := chi.NewRouter()
+	router.Use(middleware.Heartbeat("/"))
+
+	// probes for the orchestrator, kept out of rate limiting and request logs
+	router.Get("/healthz", health.Liveness)
+	router.Get("/readyz", health.Readiness(
+		health.Check{Name: "database", Check: a.Store.Ping},
+		health.Check{Name: "storage", Check: a.Storage.Ping},
+		// firebase is probed through its api, not on every poll
+		health.Check{Name: "auth", Check: a.Identity.Ping, CacheFor: time.Second * 30},
+	))
+
+	// open without a token only in development
+	router.Handle("/metrics", metrics.Handler(cfg.MetricsToken, cfg.IsDev()))
+
+	router.Group(func(r chi.Router) {
+		// middleware
+		r.Use(metrics.Middleware)
+		r.Use(middleware.RequestID)
+		r.Use(logger.Middleware)
+		r.Use(logger.Recoverer)
+		r.Use(middleware.Timeout(cfg.Timeouts.Request))
+		r.Use(httprate.LimitByIP(cfg.RateLimit, time.Minute))
+		r.Use(middleware.AllowContentType("application/json", "multipart/form-data"))
+
+		r.Mount("/v1", v1Router.Router(cfg, controllers.New(svc), v1Middleware.New(a)))
+
+		// local disk storage serves its signed urls through the api
+		if local, ok := storage.Unwrap(a.Storage).(*storage.Local); ok {
+			r.Handle(storage.LocalRoute+"/*", local)
+		}
+	})
+
+	handle400(router)
+
+	return router
+}
@@ -0,0 +1,90 @@
+// Package session caches what the token middleware needs to know about a user
+// beyond the token, so it doesn't hit the database on every request
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// entries kept before expired ones are dropped
+const maxEntries = 10_000
+
+// State of a user's sessions
+type State struct {
+	Suspended bool
+	// the users row is gone
+	Deleted bool
+	// tokens issued before its second are rejected, the ones issued in the
+	// same second are accepted like firebase compares them
+	RevokedAt *time.Time
+}
+
+type entry struct {
+	state   State
+	expires time.Time
+}
+
+// Cache keeps states for ttl. Changes made by this instance invalidate the
+// entry right away, other instances pick them up once it expires.
+// A nil *Cache caches nothing
+type Cache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: make(map[string]entry)}
+}
+
+func (c *Cache) Get(uid string) (State, bool) {
+	if c == nil {
+		return State{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[uid]
+	if !ok || time.Now().After(e.expires) {
+		return State{}, false
+	}
+
+	return e.state, true
+}
+
+func (c *Cache) Set(uid string, state State) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= maxEntries {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxEntries {
+			c.entries = make(map[string]entry)
+		}
+	}
+
+	c.entries[uid] = entry{state: state, expires: now.Add(c.ttl)}
+}
+
+func (c *Cache) Invalidate(uid string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, uid)
+}
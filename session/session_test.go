@@ -0,0 +1,69 @@
+package session
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	revokedAt := time.Now()
+	state := State{Suspended: true, RevokedAt: &revokedAt}
+
+	c := NewCache(time.Minute)
+	if _, ok := c.Get("uid"); ok {
+		t.Fatal("empty cache returned a state")
+	}
+
+	c.Set("uid", state)
+	got, ok := c.Get("uid")
+	if !ok || !got.Suspended || got.RevokedAt != &revokedAt {
+		t.Errorf("unexpected cached state: %+v %v", got, ok)
+	}
+
+	c.Invalidate("uid")
+	if _, ok := c.Get("uid"); ok {
+		t.Error("invalidated state was returned")
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c := NewCache(time.Millisecond * 10)
+	c.Set("uid", State{Deleted: true})
+
+	time.Sleep(time.Millisecond * 20)
+	if _, ok := c.Get("uid"); ok {
+		t.Error("expired state was returned")
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	var nilCache *Cache
+	nilCache.Set("uid", State{Suspended: true})
+	nilCache.Invalidate("uid")
+	if _, ok := nilCache.Get("uid"); ok {
+		t.Error("nil cache returned a state")
+	}
+
+	c := NewCache(0)
+	c.Set("uid", State{Suspended: true})
+	if _, ok := c.Get("uid"); ok {
+		t.Error("cache without ttl returned a state")
+	}
+}
+
+func TestCacheLimit(t *testing.T) {
+	c := NewCache(time.Minute)
+	for i := 0; i < maxEntries; i++ {
+		c.Set(strconv.Itoa(i), State{})
+	}
+
+	// nothing expired, the cache starts over instead of growing
+	c.Set("uid", State{})
+	if len(c.entries) != 1 {
+		t.Errorf("cache grew past its limit: %v entries", len(c.entries))
+	}
+	if _, ok := c.Get("uid"); !ok {
+		t.Error("state set after the reset wasn't cached")
+	}
+}
@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rohan031/adgytec-api/config"
+)
+
+// route the api serves local objects from, see Local.ServeHTTP
+const LocalRoute = "/storage"
+
+var errInvalidName = errors.New("invalid object name")
+
+// Local stores objects as files under a directory, meant for development and
+// ci where no bucket is available. Signed urls point back at the api, which
+// checks the signature before serving the file.
+type Local struct {
+	root      string
+	publicURL string
+	key       []byte
+}
+
+func NewLocal(cfg config.StorageConfig) (*Local, error) {
+	root, err := filepath.Abs(cfg.LocalDir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(root, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Local{
+		root:      root,
+		publicURL: strings.TrimRight(cfg.PublicURL, "/"),
+		key:       []byte(cfg.SigningKey),
+	}, nil
+}
+
+// file maps an object name to its path on disk, refusing names escaping root
+func (l *Local) file(name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if name == "" || clean == "/" || strings.Contains(name, "\\") {
+		return "", errInvalidName
+	}
+
+	return filepath.Join(l.root, filepath.FromSlash(clean)), nil
+}
+
+func (l *Local) Put(ctx context.Context, name string, r io.Reader, size int64, contentType string) error {
+	file, err := l.file(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(file), 0o755)
+	if err != nil {
+		return err
+	}
+
+	// written next to the target and renamed so readers never see half a file
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+func (l *Local) Delete(ctx context.Context, names ...string) error {
+	var errs []error
+	for _, name := range names {
+		file, err := l.file(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("removing %s: %w", name, err))
+			continue
+		}
+
+		err = os.Remove(file)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (l *Local) DeletePrefix(ctx context.Context, prefix string) error {
+	names, err := l.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	return l.Delete(ctx, names...)
+}
+
+func (l *Local) List(ctx context.Context, prefix string) ([]string, error) {
+	// walking only the deepest directory the prefix names
+	dir := path.Dir("/" + prefix + "_")
+	start := filepath.Join(l.root, filepath.FromSlash(dir))
+
+	var names []string
+	err := filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.root, p)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	return names, err
+}
+
+func (l *Local) sign(name string, expires int64) string {
+	mac := hmac.New(sha256.New, l.key)
+	fmt.Fprintf(mac, "%s\n%d", name, expires)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (l *Local) SignedURL(ctx context.Context, name string, expires time.Duration) (string, error) {
+	if _, err := l.file(name); err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(expires).Unix()
+
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt, 10))
+	query.Set("signature", l.sign(name, expiresAt))
+
+	return l.publicURL + LocalRoute + "/" + strings.Join(segments, "/") + "?" + query.Encode(), nil
+}
+
+// Ping checks the storage directory is still there
+func (l *Local) Ping(ctx context.Context) error {
+	info, err := os.Stat(l.root)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", l.root)
+	}
+
+	return nil
+}
+
+// ServeHTTP serves objects behind urls produced by SignedURL, it must be
+// mounted at LocalRoute + "/*"
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "*")
+	if r.URL.RawPath != "" {
+		// chi matched against the escaped path
+		unescaped, err := url.PathUnescape(name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		name = unescaped
+	}
+	query := r.URL.Query()
+
+	expiresAt, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		http.Error(w, "url expired", http.StatusForbidden)
+		return
+	}
+
+	signature := query.Get("signature")
+	if !hmac.Equal([]byte(signature), []byte(l.sign(name, expiresAt))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	file, err := l.file(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
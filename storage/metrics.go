@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/rohan031/adgytec-api/metrics"
+)
+
+// instrumented records the latency and outcome of every call on the wrapped
+// storage
+type instrumented struct {
+	Storage
+}
+
+func WithMetrics(s Storage) Storage {
+	return instrumented{s}
+}
+
+// Unwrap returns the storage behind WithMetrics, or s itself
+func Unwrap(s Storage) Storage {
+	if i, ok := s.(instrumented); ok {
+		return i.Storage
+	}
+
+	return s
+}
+
+func (i instrumented) Put(ctx context.Context, name string, r io.Reader, size int64, contentType string) error {
+	start := time.Now()
+	err := i.Storage.Put(ctx, name, r, size, contentType)
+	metrics.ObserveStorage(metrics.StoragePut, start, err)
+
+	return err
+}
+
+func (i instrumented) Delete(ctx context.Context, names ...string) error {
+	start := time.Now()
+	err := i.Storage.Delete(ctx, names...)
+	metrics.ObserveStorage(metrics.StorageRemove, start, err)
+
+	return err
+}
+
+func (i instrumented) DeletePrefix(ctx context.Context, prefix string) error {
+	start := time.Now()
+	err := i.Storage.DeletePrefix(ctx, prefix)
+	metrics.ObserveStorage(metrics.StorageRemovePrefix, start, err)
+
+	return err
+}
+
+func (i instrumented) List(ctx context.Context, prefix string) ([]string, error) {
+	start := time.Now()
+	names, err := i.Storage.List(ctx, prefix)
+	metrics.ObserveStorage(metrics.StorageList, start, err)
+
+	return names, err
+}
+
+func (i instrumented) SignedURL(ctx context.Context, name string, expires time.Duration) (string, error) {
+	start := time.Now()
+	u, err := i.Storage.SignedURL(ctx, name, expires)
+	metrics.ObserveStorage(metrics.StoragePresign, start, err)
+
+	return u, err
+}
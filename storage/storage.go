@@ -1,23 +1,156 @@
 package storage
 
 import (
-	"os"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/rohan031/adgytec-api/config"
 )
 
-var SpaceStorage *minio.Client
+// Storage keeps media objects addressed by their name, a slash separated
+// path inside the bucket
+type Storage interface {
+	Put(ctx context.Context, name string, r io.Reader, size int64, contentType string) error
+	// Delete removes the named objects, missing objects are not an error
+	Delete(ctx context.Context, names ...string) error
+	// DeletePrefix removes every object whose name starts with prefix
+	DeletePrefix(ctx context.Context, prefix string) error
+	List(ctx context.Context, prefix string) ([]string, error)
+	// SignedURL returns a url granting read access to the object until expires
+	// has elapsed
+	SignedURL(ctx context.Context, name string, expires time.Duration) (string, error)
+	// Ping checks the storage is reachable and usable
+	Ping(ctx context.Context) error
+}
+
+// New returns the storage backend selected by cfg.Driver
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case config.StorageLocal:
+		return NewLocal(cfg)
+	default:
+		return NewS3(cfg)
+	}
+}
 
-func InitCloudStorage() (*minio.Client, error) {
-	minioClient, err := minio.New(os.Getenv("SPACE_STORAGE_ENDPOINT"), &minio.Options{
-		Creds:  credentials.NewStaticV4(os.Getenv("SPACE_STORAGE_ACCESS_KEY"), os.Getenv("SPACE_STORAGE_SECRET_KEY"), ""),
-		Secure: true,
+// S3 stores objects in an s3 compatible bucket
+type S3 struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3(cfg config.StorageConfig) (*S3, error) {
+	minioClient, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.Secure,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	SpaceStorage = minioClient
-	return minioClient, nil
+	return &S3{client: minioClient, bucket: cfg.Bucket}, nil
+}
+
+func (s *S3) Put(ctx context.Context, name string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, name, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *S3) Delete(ctx context.Context, names ...string) error {
+	if len(names) == 1 {
+		return s.client.RemoveObject(ctx, s.bucket, names[0], minio.RemoveObjectOptions{})
+	}
+
+	objectsCh := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(objectsCh)
+		for _, name := range names {
+			select {
+			case objectsCh <- minio.ObjectInfo{Key: name}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return s.removeObjects(ctx, objectsCh)
+}
+
+func (s *S3) DeletePrefix(ctx context.Context, prefix string) error {
+	var listErr error
+	objectsCh := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(objectsCh)
+
+		opts := minio.ListObjectsOptions{Prefix: prefix, Recursive: true}
+		for object := range s.client.ListObjects(ctx, s.bucket, opts) {
+			if object.Err != nil {
+				listErr = object.Err
+				return
+			}
+
+			select {
+			case objectsCh <- object:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	err := s.removeObjects(ctx, objectsCh)
+
+	// objectsCh is closed once the listing goroutine is done with listErr
+	return errors.Join(listErr, err)
+}
+
+func (s *S3) removeObjects(ctx context.Context, objectsCh <-chan minio.ObjectInfo) error {
+	var errs []error
+	for rErr := range s.client.RemoveObjects(ctx, s.bucket, objectsCh, minio.RemoveObjectsOptions{}) {
+		errs = append(errs, fmt.Errorf("removing %s: %w", rErr.ObjectName, rErr.Err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+
+	opts := minio.ListObjectsOptions{Prefix: prefix, Recursive: true}
+	for object := range s.client.ListObjects(ctx, s.bucket, opts) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		names = append(names, object.Key)
+	}
+
+	return names, nil
+}
+
+func (s *S3) SignedURL(ctx context.Context, name string, expires time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, name, expires, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return u.String(), nil
+}
+
+// Ping checks the storage credentials and that the bucket exists
+func (s *S3) Ping(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("bucket %q does not exist", s.bucket)
+	}
+
+	return nil
 }
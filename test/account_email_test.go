@@ -0,0 +1,124 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+var actionToken = regexp.MustCompile(`(?:reset-password|verify-email)\?token=([0-9a-f]+)`)
+
+// requestAccountEmail posts to an account endpoint and waits for the email,
+// which is sent after the response
+func (h *harness) requestAccountEmail(path, email string) {
+	h.t.Helper()
+
+	h.do(http.MethodPost, path, "", services.AccountEmail{Email: email}).
+		expect(h.t, http.StatusOK, "If an account exists for the email, we've sent a link to it.")
+
+	if err := h.svc.WaitForBackgroundTasks(context.Background()); err != nil {
+		h.t.Fatalf("Error waiting for background tasks: %v", err)
+	}
+}
+
+func (h *harness) lastActionToken(email string) string {
+	h.t.Helper()
+
+	sent := h.mailer.sentTo(email)
+	if len(sent) == 0 {
+		h.t.Fatalf("no email sent to %s", email)
+	}
+
+	match := actionToken.FindStringSubmatch(sent[len(sent)-1].HTML)
+	if match == nil {
+		h.t.Fatalf("email to %s has no link: %s", email, sent[len(sent)-1].HTML)
+	}
+
+	return match[1]
+}
+
+func TestPasswordReset(t *testing.T) {
+	h := newHarness(t)
+	invalid := "The link is invalid, has expired or has already been used."
+
+	h.requestAccountEmail("/v1/auth/password-reset", h.user.email)
+	first := h.lastActionToken(h.user.email)
+	h.requestAccountEmail("/v1/auth/password-reset", h.user.email)
+	second := h.lastActionToken(h.user.email)
+
+	h.do(http.MethodPost, "/v1/auth/password-reset/confirm", "", services.PasswordReset{Token: second, Password: "short"}).
+		expect(t, http.StatusBadRequest, "Password must be between 8 and 128 characters.")
+	h.do(http.MethodPost, "/v1/auth/password-reset/confirm", "", services.PasswordReset{Token: second, Password: "new-password"}).
+		expect(t, http.StatusOK, "Password reset, you can now sign in with your new password.")
+	h.do(http.MethodPost, "/v1/auth/password-reset/confirm", "", services.PasswordReset{Token: second, Password: "other-password"}).
+		expect(t, http.StatusBadRequest, invalid)
+
+	// older links stop working once the password was reset
+	h.do(http.MethodPost, "/v1/auth/password-reset/confirm", "", services.PasswordReset{Token: first, Password: "other-password"}).
+		expect(t, http.StatusBadRequest, invalid)
+
+	h.do(http.MethodPost, "/v1/auth/token", "", services.Credentials{Email: h.user.email, Password: "new-password"}).
+		expect(t, http.StatusOK, "")
+	h.do(http.MethodPost, "/v1/auth/token", "", services.Credentials{Email: h.user.email, Password: testPassword}).
+		expect(t, http.StatusUnauthorized, "The email or password provided is incorrect.")
+
+	// unknown emails get the same response and no email
+	h.requestAccountEmail("/v1/auth/password-reset", "nobody@adgytec.in")
+	if sent := h.mailer.sentTo("nobody@adgytec.in"); len(sent) != 0 {
+		t.Errorf("password reset sent email to unknown address: %v", len(sent))
+	}
+
+	// at most three emails per hour
+	h.requestAccountEmail("/v1/auth/password-reset", h.user.email)
+	h.requestAccountEmail("/v1/auth/password-reset", h.user.email)
+	if sent := h.mailer.sentTo(h.user.email); len(sent) != 3 {
+		t.Errorf("password reset sent unexpected number of emails: got %v want 3", len(sent))
+	}
+}
+
+func TestEmailVerification(t *testing.T) {
+	h := newHarness(t)
+
+	h.requestAccountEmail("/v1/auth/email-verification", h.user.email)
+	token := h.lastActionToken(h.user.email)
+
+	h.do(http.MethodPost, "/v1/auth/email-verification/confirm", "", services.EmailVerification{Token: token}).
+		expect(t, http.StatusOK, "Email verified.")
+	h.do(http.MethodPost, "/v1/auth/email-verification/confirm", "", services.EmailVerification{Token: token}).
+		expect(t, http.StatusBadRequest, "The link is invalid, has expired or has already been used.")
+
+	var verified bool
+	err := h.pool.QueryRow(context.Background(), "SELECT email_verified FROM identity_users WHERE user_id = $1", h.user.id).Scan(&verified)
+	if err != nil {
+		t.Fatalf("Error reading email_verified: %v", err)
+	}
+	if !verified {
+		t.Errorf("email of %s wasn't verified", h.user.email)
+	}
+}
+
+func TestAccountEmailLimitConcurrent(t *testing.T) {
+	h := newHarness(t)
+
+	// the requests of an email are counted one after the other
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.svc.RequestPasswordReset(context.Background(), &services.AccountEmail{Email: h.user.email})
+		}()
+	}
+	wg.Wait()
+
+	if err := h.svc.WaitForBackgroundTasks(context.Background()); err != nil {
+		t.Fatalf("Error waiting for background tasks: %v", err)
+	}
+	if sent := h.mailer.sentTo(h.user.email); len(sent) != 3 {
+		t.Errorf("concurrent password resets sent unexpected number of emails: got %v want 3", len(sent))
+	}
+}
@@ -0,0 +1,167 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/identity"
+	"github.com/rohan031/adgytec-api/v1/dbqueries"
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func TestRotateClientToken(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+
+	var token services.ClientToken
+	tokens, err := h.svc.GetClientTokensByProjectId(ctx, &token, h.project.id)
+	if err != nil || len(*tokens) != 1 {
+		t.Fatalf("GetClientTokensByProjectId returned unexpected result: %v %v", tokens, err)
+	}
+
+	rotated, err := h.svc.RotateClientTokenById(ctx, &token, (*tokens)[0].Id, h.project.id)
+	if err != nil {
+		t.Fatalf("RotateClientTokenById returned unexpected error: %v", err)
+	}
+
+	h.do(http.MethodGet, "/v1/services/news", h.project.clientToken, nil).
+		expect(t, http.StatusUnauthorized, "The client token provided has been revoked.")
+	h.do(http.MethodGet, "/v1/services/news", rotated.Token, nil).
+		expect(t, http.StatusOK, "")
+
+	_, err = h.svc.RotateClientTokenById(ctx, &token, services.GenerateUUID().String(), h.project.id)
+	if err == nil {
+		t.Errorf("RotateClientTokenById succeeded for an unknown token")
+	}
+}
+
+func TestCheckUserConsistency(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+
+	problems, err := h.svc.CheckUserConsistency(ctx)
+	if err != nil {
+		t.Fatalf("CheckUserConsistency returned unexpected error: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("CheckUserConsistency reported problems for seeded users: %+v", problems)
+	}
+
+	// account without a users row, a users row without account and a stale role
+	orphan, err := h.identity.CreateUser(ctx, identity.UserToCreate{Email: "orphan@adgytec.in", Name: "Orphan", Password: testPassword})
+	if err != nil {
+		t.Fatalf("Error creating identity user: %v", err)
+	}
+	_, err = h.pool.Exec(ctx, dbqueries.CreateUser, dbqueries.CreateUserArgs("ghost", "ghost@adgytec.in", "Ghost", "user"))
+	if err != nil {
+		t.Fatalf("Error adding user: %v", err)
+	}
+	err = h.identity.SetClaims(ctx, h.admin.id, map[string]any{"role": "user"})
+	if err != nil {
+		t.Fatalf("Error setting claims: %v", err)
+	}
+
+	problems, err = h.svc.CheckUserConsistency(ctx)
+	if err != nil {
+		t.Fatalf("CheckUserConsistency returned unexpected error: %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, p := range problems {
+		found[p.UserId] = true
+	}
+	for _, id := range []string{orphan.UID, "ghost", h.admin.id} {
+		if !found[id] {
+			t.Errorf("CheckUserConsistency didn't report user %s: %+v", id, problems)
+		}
+	}
+	if len(problems) != 3 {
+		t.Errorf("CheckUserConsistency reported unexpected number of problems: got %v want 3", len(problems))
+	}
+}
+
+func TestReconcileUsers(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+
+	orphan, err := h.identity.CreateUser(ctx, identity.UserToCreate{Email: "orphan@adgytec.in", Name: "Orphan", Password: testPassword})
+	if err != nil {
+		t.Fatalf("Error creating identity user: %v", err)
+	}
+	err = h.identity.SetClaims(ctx, h.admin.id, map[string]any{"role": "user"})
+	if err != nil {
+		t.Fatalf("Error setting claims: %v", err)
+	}
+	err = h.identity.UpdateUser(ctx, h.user.id, identity.UserToUpdate{Name: "Renamed"})
+	if err != nil {
+		t.Fatalf("Error renaming user: %v", err)
+	}
+
+	h.do(http.MethodPost, "/v1/users/consistency/repair", h.admin.token, nil).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+
+	var repaired []services.UserInconsistency
+	res := h.do(http.MethodPost, "/v1/users/consistency/repair", h.superAdmin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &repaired)
+	if len(repaired) != 3 {
+		t.Fatalf("ReconcileUsers returned unexpected problems: %s", res.data)
+	}
+	for _, p := range repaired {
+		if !p.Repaired {
+			t.Errorf("ReconcileUsers didn't repair %s: %s", p.UserId, p.Problem)
+		}
+	}
+
+	var remaining []services.UserInconsistency
+	res = h.do(http.MethodGet, "/v1/users/consistency", h.superAdmin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &remaining)
+	if len(remaining) != 0 {
+		t.Errorf("drift left after ReconcileUsers: %s", res.data)
+	}
+
+	if _, err := h.identity.GetUser(ctx, orphan.UID); err == nil {
+		t.Errorf("ReconcileUsers kept the account without a users row")
+	}
+}
+
+// failingClaims fails every claim update, as if the identity provider went
+// away halfway through an update
+type failingClaims struct {
+	identity.Provider
+}
+
+func (f failingClaims) SetClaims(ctx context.Context, uid string, claims map[string]any) error {
+	return errors.New("identity provider unavailable")
+}
+
+func TestUpdateUserCompensation(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+
+	provider := h.svc.Identity
+	h.svc.Identity = failingClaims{provider}
+	err := h.svc.UpdateUser(ctx, &services.User{UserId: h.user.id, Name: "Renamed", Role: "admin"})
+	h.svc.Identity = provider
+	if err == nil {
+		t.Fatalf("UpdateUser succeeded without the identity provider")
+	}
+
+	// neither the row nor the account kept the half applied update
+	var user services.User
+	h.do(http.MethodGet, "/v1/user/"+h.user.id, h.admin.token, nil).decode(t, &user)
+	if user.Name != h.user.name || user.Role != h.user.role {
+		t.Errorf("UpdateUser changed the users row: got %v %v", user.Name, user.Role)
+	}
+
+	account, err := h.identity.GetUser(ctx, h.user.id)
+	if err != nil {
+		t.Fatalf("Error getting identity user: %v", err)
+	}
+	if account.Name != h.user.name {
+		t.Errorf("UpdateUser didn't restore the account name: got %v want %v", account.Name, h.user.name)
+	}
+}
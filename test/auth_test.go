@@ -0,0 +1,105 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func TestTokenAuthentication(t *testing.T) {
+	h := newHarness(t)
+
+	tests := []struct {
+		name            string
+		header          string
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{"missing header", "", http.StatusUnauthorized, "The request lacks an authorization header."},
+		{"invalid scheme", "Basic " + h.admin.token, http.StatusUnauthorized, "The authentication scheme provided is invalid."},
+		{"invalid header", "Bearer " + h.admin.token + " extra", http.StatusUnauthorized, "The authentication header provided is invalid."},
+		{"unsigned token", "Bearer " + idToken, http.StatusUnauthorized, "The provided ID token is invalid and cannot be used for authentication."},
+		{"valid token", "Bearer " + h.admin.token, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, h.server.URL+"/v1/uuid", nil)
+			if err != nil {
+				t.Fatalf("Error creating request: %v", err)
+			}
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			res := h.send(req, "")
+			res.expect(t, tt.expectedStatus, tt.expectedMessage)
+		})
+	}
+
+	res := h.do(http.MethodGet, "/v1/uuid", h.user.token, nil)
+	res.expect(t, http.StatusOK, "")
+
+	var data struct {
+		UUID string `json:"uuid"`
+	}
+	res.decode(t, &data)
+	if data.UUID == "" {
+		t.Errorf("GetUUID returned no uuid: %s", res.raw)
+	}
+}
+
+func TestSignIn(t *testing.T) {
+	h := newHarness(t)
+
+	res := h.do(http.MethodPost, "/v1/auth/token", "", services.Credentials{Email: h.admin.email, Password: testPassword})
+	res.expect(t, http.StatusOK, "")
+
+	var data struct {
+		Token string `json:"token"`
+	}
+	res.decode(t, &data)
+
+	// the issued token is accepted by the dashboard routes
+	h.do(http.MethodGet, "/v1/projects", data.Token, nil).expect(t, http.StatusOK, "")
+
+	res = h.do(http.MethodPost, "/v1/auth/token", "", services.Credentials{Email: h.admin.email, Password: "wrong-password"})
+	res.expect(t, http.StatusUnauthorized, "The email or password provided is incorrect.")
+
+	res = h.do(http.MethodPost, "/v1/auth/token", "", services.Credentials{Email: "nobody@adgytec.in", Password: testPassword})
+	res.expect(t, http.StatusUnauthorized, "The email or password provided is incorrect.")
+}
+
+func TestClientTokenAuthentication(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(http.MethodGet, "/v1/services/news", "", nil).
+		expect(t, http.StatusUnauthorized, "The request lacks an authorization header.")
+	h.do(http.MethodGet, "/v1/services/news", "unknown-token", nil).
+		expect(t, http.StatusNotFound, "Project with the provided client token does not exist.")
+	h.do(http.MethodGet, "/v1/services/news", h.project.clientToken, nil).
+		expect(t, http.StatusOK, "")
+}
+
+func TestProbes(t *testing.T) {
+	h := newHarness(t)
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		res := h.do(http.MethodGet, path, "", nil)
+		if res.status != http.StatusOK {
+			t.Errorf("%s returned unexpected status code: got %v want %v", path, res.status, http.StatusOK)
+		}
+	}
+}
+
+func TestNewsletter(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(http.MethodGet, "/v1/newsletter", "", nil).expect(t, http.StatusOK, "no records yet")
+
+	res := h.do(http.MethodPost, "/v1/newsletter", "", services.Newsletter{Email: "reader@adgytec.in"})
+	if res.status != http.StatusOK {
+		t.Errorf("PostNewsletterEmail returned unexpected status code: got %v want %v", res.status, http.StatusOK)
+	}
+}
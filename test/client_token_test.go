@@ -0,0 +1,58 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func TestClientTokens(t *testing.T) {
+	h := newHarness(t)
+	tokens := "/v1/project/" + h.project.id + "/tokens"
+
+	var token services.ClientToken
+	res := h.do(http.MethodPost, tokens, h.admin.token, services.ClientTokenInput{Label: "news site", Scopes: []string{"read:news"}})
+	res.expect(t, http.StatusCreated, "")
+	res.decode(t, &token)
+
+	// only the scopes of the token are reachable
+	h.do(http.MethodGet, "/v1/services/news", token.Token, nil).
+		expect(t, http.StatusOK, "")
+	h.do(http.MethodGet, "/v1/services/blogs", token.Token, nil).
+		expect(t, http.StatusForbidden, "The client token provided doesn't grant access to this resource.")
+
+	var all []services.ClientToken
+	h.do(http.MethodGet, tokens, h.admin.token, nil).decode(t, &all)
+	if len(all) != 2 {
+		t.Errorf("GetClientTokens returned unexpected number of tokens: got %v want 2", len(all))
+	}
+	for _, item := range all {
+		if item.Token != "" {
+			t.Errorf("GetClientTokens returned the token itself for %v", item.Id)
+		}
+	}
+
+	var patched services.ClientToken
+	h.do(http.MethodPatch, tokens+"/"+token.Id, h.admin.token, services.ClientTokenInput{Label: "renamed"}).decode(t, &patched)
+	if patched.Label != "renamed" {
+		t.Errorf("PatchClientToken returned unexpected label: got %v want renamed", patched.Label)
+	}
+
+	h.do(http.MethodDelete, tokens+"/"+token.Id, h.admin.token, nil).
+		expect(t, http.StatusOK, "Successfully revoked client token.")
+	h.do(http.MethodGet, "/v1/services/news", token.Token, nil).
+		expect(t, http.StatusUnauthorized, "The client token provided has been revoked.")
+	h.do(http.MethodPatch, tokens+"/"+token.Id, h.admin.token, services.ClientTokenInput{Label: "revoked"}).
+		expect(t, http.StatusNotFound, "Client token with the provided ID does not exist.")
+
+	h.do(http.MethodPost, tokens, h.admin.token, services.ClientTokenInput{Label: "bad", Scopes: []string{"x"}}).
+		expect(t, http.StatusBadRequest, "Invalid client token scope: x")
+
+	// tokens of one project can't be managed through another
+	h.do(http.MethodPost, "/v1/project/"+h.otherProject.id+"/tokens/"+token.Id+"/rotate", h.admin.token, nil).
+		expect(t, http.StatusNotFound, "Client token with the provided ID does not exist.")
+
+	h.do(http.MethodGet, tokens, h.user.token, nil).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/rohan031/adgytec-api/config"
+	"github.com/rohan031/adgytec-api/identity"
+)
+
+func newLocalIdentity(t *testing.T) (*identity.Local, ed25519.PrivateKey) {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error encoding key: %v", err)
+	}
+
+	cfg := config.LocalIdentityConfig{
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		Issuer:     "adgytec-test",
+		TokenTTL:   time.Hour,
+	}
+
+	// verification never touches the database
+	provider, err := identity.NewLocal(cfg, nil)
+	if err != nil {
+		t.Fatalf("Error creating local identity provider: %v", err)
+	}
+
+	return provider, key
+}
+
+func TestLocalIdentityVerifyToken(t *testing.T) {
+	provider, key := newLocalIdentity(t)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	sign := func(key ed25519.PrivateKey, claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("Error signing token: %v", err)
+		}
+		return token
+	}
+
+	now := time.Now()
+	valid := jwt.MapClaims{"sub": "user-1", "iss": "adgytec-test", "exp": now.Add(time.Hour).Unix(), "role": "admin"}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid token", sign(key, valid), nil},
+		{"expired token", sign(key, jwt.MapClaims{"sub": "user-1", "iss": "adgytec-test", "exp": now.Add(-time.Minute).Unix()}), identity.ErrTokenExpired},
+		{"foreign key", sign(otherKey, valid), identity.ErrTokenInvalid},
+		{"wrong issuer", sign(key, jwt.MapClaims{"sub": "user-1", "iss": "someone-else", "exp": now.Add(time.Hour).Unix()}), identity.ErrTokenInvalid},
+		{"unsigned token", idToken, identity.ErrTokenInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := provider.VerifyToken(context.Background(), tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyToken returned unexpected error: got %v want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && (token.UID != "user-1" || token.Claims["role"] != "admin") {
+				t.Errorf("VerifyToken returned unexpected token: %+v", token)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+var inviteToken = regexp.MustCompile(`accept-invite\?token=([0-9a-f]+)`)
+
+// lastInviteToken reads the token from the newest invite mailed to email
+func (h *harness) lastInviteToken(email string) string {
+	h.t.Helper()
+
+	sent := h.mailer.sentTo(email)
+	if len(sent) == 0 {
+		h.t.Fatalf("no invite sent to %s", email)
+	}
+
+	match := inviteToken.FindStringSubmatch(sent[len(sent)-1].HTML)
+	if match == nil {
+		h.t.Fatalf("invite to %s has no link: %s", email, sent[len(sent)-1].HTML)
+	}
+
+	return match[1]
+}
+
+// invite goes through the service as PostUser validates emails against dns
+func (h *harness) invite(name, email string) *services.User {
+	h.t.Helper()
+	ctx := context.Background()
+
+	user := services.User{Name: name, Email: email, Role: "user"}
+	details, err := h.svc.InviteUser(ctx, &user, h.admin.id)
+	if err != nil {
+		h.t.Fatalf("InviteUser returned unexpected error: %v", err)
+	}
+
+	err = h.svc.SendInvite(ctx, details)
+	if err != nil {
+		h.t.Fatalf("SendInvite returned unexpected error: %v", err)
+	}
+
+	return &user
+}
+
+func TestInvites(t *testing.T) {
+	h := newHarness(t)
+	invitee := h.invite("Invited User", "invitee@adgytec.in")
+	first := h.lastInviteToken(invitee.Email)
+	invalid := "The invite link is invalid or has already been used."
+
+	var user services.User
+	h.do(http.MethodGet, "/v1/user/"+invitee.UserId, h.admin.token, nil).decode(t, &user)
+	if user.InviteStatus != "pending" {
+		t.Errorf("invited user has unexpected invite status: got %v want pending", user.InviteStatus)
+	}
+
+	h.do(http.MethodPost, "/v1/invite/accept", "", services.InviteAccept{Token: first, Password: "short"}).
+		expect(t, http.StatusBadRequest, "Password must be between 8 and 128 characters.")
+
+	// resending replaces the first invite
+	h.do(http.MethodPost, "/v1/user/"+invitee.UserId+"/invite", h.user.token, nil).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+	h.do(http.MethodPost, "/v1/user/"+invitee.UserId+"/invite", h.admin.token, nil).
+		expect(t, http.StatusOK, "Successfully sent a new invite, earlier invites no longer work.")
+	second := h.lastInviteToken(invitee.Email)
+
+	h.do(http.MethodPost, "/v1/invite/accept", "", services.InviteAccept{Token: first, Password: "chosen-password"}).
+		expect(t, http.StatusBadRequest, invalid)
+	h.do(http.MethodPost, "/v1/invite/accept", "", services.InviteAccept{Token: second, Password: "chosen-password"}).
+		expect(t, http.StatusOK, "Invite accepted, you can now sign in with your password.")
+	h.do(http.MethodPost, "/v1/invite/accept", "", services.InviteAccept{Token: second, Password: "other-password"}).
+		expect(t, http.StatusBadRequest, invalid)
+
+	h.do(http.MethodPost, "/v1/auth/token", "", services.Credentials{Email: invitee.Email, Password: "chosen-password"}).
+		expect(t, http.StatusOK, "")
+	h.do(http.MethodGet, "/v1/user/"+invitee.UserId, h.admin.token, nil).decode(t, &user)
+	if user.InviteStatus != "accepted" {
+		t.Errorf("invited user has unexpected invite status: got %v want accepted", user.InviteStatus)
+	}
+
+	h.do(http.MethodPost, "/v1/user/"+invitee.UserId+"/invite", h.admin.token, nil).
+		expect(t, http.StatusBadRequest, "The user has already accepted the invite.")
+
+	// revoked invites can't be accepted
+	revoked := h.invite("Revoked User", "revoked@adgytec.in")
+	h.do(http.MethodDelete, "/v1/user/"+revoked.UserId+"/invite", h.admin.token, nil).
+		expect(t, http.StatusOK, "Successfully revoked the invite.")
+	h.do(http.MethodDelete, "/v1/user/"+revoked.UserId+"/invite", h.admin.token, nil).
+		expect(t, http.StatusBadRequest, "The user has no pending invite.")
+	h.do(http.MethodPost, "/v1/invite/accept", "", services.InviteAccept{Token: h.lastInviteToken(revoked.Email), Password: "chosen-password"}).
+		expect(t, http.StatusBadRequest, invalid)
+
+	// expired invites
+	expired := h.invite("Expired User", "expired@adgytec.in")
+	_, err := h.pool.Exec(context.Background(), `UPDATE user_invite SET expires_at = '2000-01-01' WHERE user_id = $1`, expired.UserId)
+	if err != nil {
+		t.Fatalf("Error expiring invite: %v", err)
+	}
+	h.do(http.MethodPost, "/v1/invite/accept", "", services.InviteAccept{Token: h.lastInviteToken(expired.Email), Password: "chosen-password"}).
+		expect(t, http.StatusBadRequest, "The invite link has expired, ask for a new one.")
+}
@@ -0,0 +1,221 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+// the user is a member of h.project only, every request below names that
+// project in the url but targets rows of h.otherProject
+func TestProjectIsolation(t *testing.T) {
+	h := newHarness(t)
+	own, other := h.project.id, h.otherProject.id
+
+	// content of the other project, created by an admin
+	blogId := services.GenerateUUID().String()
+	blogFields := map[string]string{"title": "other blog", "content": "<p>other</p>", "category": other}
+	h.doForm(http.MethodPost, fmt.Sprintf("/v1/services/blogs/%v/%v", other, blogId), h.admin.token, blogFields, nil).
+		expect(t, http.StatusCreated, "Successfully added new blog")
+
+	newsFields := map[string]string{"title": "other news", "text": "other", "link": "https://adgytec.in"}
+	h.doForm(http.MethodPost, "/v1/services/news/"+other, h.admin.token, newsFields, map[string][]byte{"image": pngImage(t)}).
+		expect(t, http.StatusCreated, "")
+	var news []services.News
+	h.do(http.MethodGet, "/v1/services/news/"+other, h.admin.token, nil).decode(t, &news)
+
+	h.doForm(http.MethodPost, fmt.Sprintf("/v1/services/gallery/%v/albums", other), h.admin.token, map[string]string{"name": "other album"}, map[string][]byte{"cover": pngImage(t)}).
+		expect(t, http.StatusCreated, "")
+	var albums struct {
+		Albums []services.Album `json:"albums"`
+	}
+	h.do(http.MethodGet, fmt.Sprintf("/v1/services/gallery/%v/albums", other), h.admin.token, nil).decode(t, &albums)
+	albumId := albums.Albums[0].Id
+
+	var photo struct {
+		Id string `json:"id"`
+	}
+	h.doForm(http.MethodPost, fmt.Sprintf("/v1/services/gallery/%v/album/%v", other, albumId), h.admin.token, nil, map[string][]byte{"photo": pngImage(t)}).
+		decode(t, &photo)
+
+	h.do(http.MethodPost, fmt.Sprintf("/v1/services/documents/%v/cover", other), h.admin.token, map[string]string{"name": "other reports"}).
+		expect(t, http.StatusOK, "")
+	var covers []services.DocumentCover
+	h.do(http.MethodGet, fmt.Sprintf("/v1/services/documents/%v/cover", other), h.admin.token, nil).decode(t, &covers)
+
+	h.do(http.MethodPost, "/v1/services/contact-us", h.otherProject.clientToken, map[string]string{"name": "visitor"}).
+		expect(t, http.StatusCreated, "")
+	var contact struct {
+		Responses []services.ContactUs `json:"responses"`
+	}
+	h.do(http.MethodGet, "/v1/services/contact-us/"+other, h.admin.token, nil).decode(t, &contact)
+
+	var category services.CategoryId
+	h.do(http.MethodPost, fmt.Sprintf("/v1/project/%v/category", other), h.admin.token, services.Category{ParentId: other, CategoryName: "other"}).
+		decode(t, &category)
+
+	blog := fmt.Sprintf("/v1/services/blogs/%v/%v", own, blogId)
+	album := fmt.Sprintf("/v1/services/gallery/%v/albums/%v", own, albumId)
+	photos := fmt.Sprintf("/v1/services/gallery/%v/album/%v", own, albumId)
+	cover := fmt.Sprintf("/v1/services/documents/%v/cover/%v", own, covers[0].Id)
+	ownCategory := fmt.Sprintf("/v1/project/%v/category/%v", own, category.CategoryId)
+	otherMedia := fmt.Sprintf(`[{"path": "services/blogs/%v/%v/media.png"}]`, other, blogId)
+	otherImage := fmt.Sprintf(`<p><img data-path="services/blogs/%v/%v/media.png"></p>`, other, blogId)
+
+	// the media of the other blog exists, so it could be signed
+	h.doForm(http.MethodPost, fmt.Sprintf("/v1/services/blogs/%v/%v/media", other, blogId), h.admin.token, map[string]string{"metadata": otherMedia}, map[string][]byte{"media_0": pngImage(t)}).
+		expect(t, http.StatusCreated, "uploaded media files")
+
+	ownBlogId := services.GenerateUUID().String()
+	ownBlog := fmt.Sprintf("/v1/services/blogs/%v/%v", own, ownBlogId)
+	h.doForm(http.MethodPost, ownBlog, h.user.token, map[string]string{"title": "own blog", "content": "<p>own</p>", "category": own}, nil).
+		expect(t, http.StatusCreated, "Successfully added new blog")
+
+	tests := []struct {
+		name            string
+		send            func() *response
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{"get blog", func() *response {
+			return h.do(http.MethodGet, blog, h.user.token, nil)
+		}, http.StatusNotFound, "Blog with the provided ID does not exist."},
+		{"get blog with client token", func() *response {
+			return h.do(http.MethodGet, "/v1/services/blog/"+blogId, h.project.clientToken, nil)
+		}, http.StatusNotFound, "Blog with the provided ID does not exist."},
+		{"patch blog metadata", func() *response {
+			return h.do(http.MethodPatch, blog, h.user.token, services.BlogMetadata{Title: "taken", Category: own})
+		}, http.StatusNotFound, "Blog or category with the provided ID does not exist."},
+		{"patch blog content", func() *response {
+			return h.do(http.MethodPatch, blog+"/content", h.user.token, map[string]string{"content": "<p>taken</p>"})
+		}, http.StatusNotFound, "Blog with the provided ID does not exist."},
+		{"patch blog cover", func() *response {
+			return h.doForm(http.MethodPatch, blog+"/cover", h.user.token, nil, map[string][]byte{"cover": pngImage(t)})
+		}, http.StatusNotFound, "blog with the following id doesn't exist"},
+		{"delete blog", func() *response {
+			return h.do(http.MethodDelete, blog, h.user.token, nil)
+		}, http.StatusNotFound, "Blog with the provided ID does not exist."},
+		{"post blog in other category", func() *response {
+			path := fmt.Sprintf("/v1/services/blogs/%v/%v", own, services.GenerateUUID())
+			return h.doForm(http.MethodPost, path, h.user.token, blogFields, nil)
+		}, http.StatusBadRequest, "Category with the provided ID does not exist."},
+		{"upload media into other project", func() *response {
+			return h.doForm(http.MethodPost, blog+"/media", h.user.token, map[string]string{"metadata": otherMedia}, map[string][]byte{"media_0": pngImage(t)})
+		}, http.StatusBadRequest, "Invalid media path."},
+		{"post blog with media of other project", func() *response {
+			path := fmt.Sprintf("/v1/services/blogs/%v/%v", own, services.GenerateUUID())
+			fields := map[string]string{"title": "own blog", "content": otherImage, "category": own}
+			return h.doForm(http.MethodPost, path, h.user.token, fields, nil)
+		}, http.StatusBadRequest, "Invalid media path."},
+		{"patch own blog content with media of other project", func() *response {
+			return h.do(http.MethodPatch, ownBlog+"/content", h.user.token, map[string]string{"content": otherImage})
+		}, http.StatusBadRequest, "Invalid media path."},
+		{"delete media of other project", func() *response {
+			paths := services.BlogMedia{Paths: []string{fmt.Sprintf("services/blogs/%v/%v/media.png", other, blogId)}}
+			return h.do(http.MethodDelete, blog+"/media", h.user.token, paths)
+		}, http.StatusBadRequest, "Invalid media path."},
+		{"put news", func() *response {
+			path := fmt.Sprintf("/v1/services/news/%v/%v", own, news[0].Id)
+			return h.do(http.MethodPut, path, h.user.token, services.NewsPut{Title: "taken", Link: "https://adgytec.in", Text: "taken"})
+		}, http.StatusNotFound, "News item not found"},
+		{"delete news", func() *response {
+			return h.do(http.MethodDelete, fmt.Sprintf("/v1/services/news/%v/%v", own, news[0].Id), h.user.token, nil)
+		}, http.StatusNotFound, "News item not found"},
+		{"delete multiple news", func() *response {
+			return h.do(http.MethodDelete, "/v1/services/news/"+own, h.user.token, services.NewsDelete{NewsId: []string{news[0].Id}})
+		}, http.StatusNotFound, "News not found"},
+		{"patch album metadata", func() *response {
+			return h.do(http.MethodPatch, album+"/metadata", h.user.token, map[string]string{"name": "taken"})
+		}, http.StatusNotFound, "Album with the provided ID does not exist."},
+		{"patch album cover", func() *response {
+			return h.doForm(http.MethodPatch, album+"/cover", h.user.token, nil, map[string][]byte{"cover": pngImage(t)})
+		}, http.StatusNotFound, "album with the following id doesn't exist"},
+		{"delete album", func() *response {
+			return h.do(http.MethodDelete, album, h.user.token, nil)
+		}, http.StatusNotFound, "Album with the provided ID does not exist."},
+		{"get album name with client token", func() *response {
+			return h.do(http.MethodGet, "/v1/services/gallery/album/"+albumId+"/name", h.project.clientToken, nil)
+		}, http.StatusNotFound, "Album with the provided ID does not exist."},
+		{"post photo", func() *response {
+			return h.doForm(http.MethodPost, photos, h.user.token, nil, map[string][]byte{"photo": pngImage(t)})
+		}, http.StatusNotFound, "Album with the provided ID does not exist."},
+		{"delete photos", func() *response {
+			return h.do(http.MethodDelete, photos, h.user.token, services.PhotoDelete{Id: []string{photo.Id}})
+		}, http.StatusNotFound, "Photos not found"},
+		{"patch document cover", func() *response {
+			return h.do(http.MethodPatch, cover, h.user.token, map[string]string{"name": "taken"})
+		}, http.StatusNotFound, "Document cover with the provided ID does not exist."},
+		{"delete document cover", func() *response {
+			return h.do(http.MethodDelete, cover, h.user.token, nil)
+		}, http.StatusNotFound, "Document cover with the provided ID does not exist."},
+		{"delete contact us record", func() *response {
+			path := fmt.Sprintf("/v1/services/contact-us/%v/%v", own, contact.Responses[0].Id)
+			return h.do(http.MethodDelete, path, h.user.token, nil)
+		}, http.StatusNotFound, "Record with the provided ID does not exist."},
+		{"patch category", func() *response {
+			return h.do(http.MethodPatch, ownCategory, h.admin.token, services.Category{CategoryName: "taken"})
+		}, http.StatusNotFound, "Category with the provided ID does not exist."},
+		{"delete category", func() *response {
+			return h.do(http.MethodDelete, ownCategory, h.admin.token, nil)
+		}, http.StatusNotFound, "Category with the provided ID does not exist."},
+		{"post category under other parent", func() *response {
+			return h.do(http.MethodPost, fmt.Sprintf("/v1/project/%v/category", own), h.admin.token, services.Category{ParentId: other, CategoryName: "taken"})
+		}, http.StatusBadRequest, "Invalid parent for the category."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.send().expect(t, tt.expectedStatus, tt.expectedMessage)
+		})
+	}
+
+	// content saved before paths were checked doesn't sign media of other projects
+	_, err := h.pool.Exec(context.Background(), `UPDATE blogs SET content = $1 WHERE blog_id = $2`, otherImage, ownBlogId)
+	if err != nil {
+		t.Fatalf("Error updating blog content: %v", err)
+	}
+	var ownContent services.Blog
+	h.do(http.MethodGet, ownBlog, h.user.token, nil).decode(t, &ownContent)
+	if !strings.Contains(ownContent.Content, "images.unsplash.com") {
+		t.Errorf("media of the other project was signed: %s", ownContent.Content)
+	}
+
+	// nothing of the other project changed
+	var otherBlog services.Blog
+	res := h.do(http.MethodGet, "/v1/services/blog/"+blogId, h.otherProject.clientToken, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &otherBlog)
+	if otherBlog.Title != "other blog" || !strings.Contains(otherBlog.Content, "<p>other</p>") {
+		t.Errorf("blog of the other project was modified: %s", res.data)
+	}
+
+	h.do(http.MethodGet, "/v1/services/gallery/album/"+albumId+"/name", h.otherProject.clientToken, nil).
+		expect(t, http.StatusOK, "")
+
+	var otherPhotos struct {
+		Photos []services.Photos `json:"photos"`
+	}
+	h.do(http.MethodGet, "/v1/services/gallery/album/"+albumId, h.otherProject.clientToken, nil).decode(t, &otherPhotos)
+	if len(otherPhotos.Photos) != 1 {
+		t.Errorf("photos of the other project were modified: got %v want 1", len(otherPhotos.Photos))
+	}
+
+	h.do(http.MethodGet, "/v1/services/news/"+other, h.admin.token, nil).decode(t, &news)
+	if len(news) != 1 || news[0].Title != "other news" {
+		t.Errorf("news of the other project were modified: %+v", news)
+	}
+
+	h.do(http.MethodGet, "/v1/services/documents/cover", h.otherProject.clientToken, nil).decode(t, &covers)
+	if len(covers) != 1 || covers[0].Name != "other reports" {
+		t.Errorf("document covers of the other project were modified: %+v", covers)
+	}
+
+	h.do(http.MethodGet, "/v1/services/contact-us/"+other, h.admin.token, nil).decode(t, &contact)
+	if len(contact.Responses) != 1 {
+		t.Errorf("contact us records of the other project were modified: got %v want 1", len(contact.Responses))
+	}
+}
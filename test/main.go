@@ -1,20 +1,55 @@
 package test
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
-	"log"
+	"encoding/pem"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/rohan031/adgytec-api/app"
+	"github.com/rohan031/adgytec-api/config"
+	"github.com/rohan031/adgytec-api/database"
+	"github.com/rohan031/adgytec-api/identity"
+	"github.com/rohan031/adgytec-api/mailer"
+	"github.com/rohan031/adgytec-api/server"
+	"github.com/rohan031/adgytec-api/session"
+	"github.com/rohan031/adgytec-api/storage"
+	"github.com/rohan031/adgytec-api/v1/dbqueries"
+	"github.com/rohan031/adgytec-api/v1/services"
+	"github.com/rohan031/adgytec-api/v1/validation"
 )
 
-const baseUrl = "http://localhost:8080"
-const idToken = "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.eyJuYW1lIjoicm9oYW4gdmVybWEiLCJyb2xlIjoic3VwZXJfYWRtaW4iLCJlbWFpbCI6InJvaGFudmVybWEwMzFAZ21haWwuY29tIiwiZW1haWxfdmVyaWZpZWQiOmZhbHNlLCJhdXRoX3RpbWUiOjE3MTYyODI0NjgsInVzZXJfaWQiOiJKRlY5dmFkMWNQNFk0UFp6YmtrdnFZZlRIVU5hIiwiZmlyZWJhc2UiOnsiaWRlbnRpdGllcyI6eyJlbWFpbCI6WyJyb2hhbnZlcm1hMDMxQGdtYWlsLmNvbSJdfSwic2lnbl9pbl9wcm92aWRlciI6InBhc3N3b3JkIn0sImlhdCI6MTcxNjI4MjQ2OCwiZXhwIjoxNzE2Mjg2MDY4LCJhdWQiOiJhZGd5dGVjLWFmYTMzIiwiaXNzIjoiaHR0cHM6Ly9zZWN1cmV0b2tlbi5nb29nbGUuY29tL2FkZ3l0ZWMtYWZhMzMiLCJzdWIiOiJKRlY5dmFkMWNQNFk0UFp6YmtrdnFZZlRIVU5hIn0."
+// integration tests run against this database and are skipped without it,
+// every test gets its own schema which is dropped afterwards
+const testDSNEnv = "TEST_DB_DSN"
 
-func encodejson(data any) string {
-	jsonRes, err := json.MarshalIndent(data, "", "\t")
-	if err != nil {
-		log.Fatalf("Erorr encoding json: %v", err)
-	}
-	return string(jsonRes)
-}
+const testPassword = "harness-password"
+
+// unsigned token, must be rejected by every provider
+const idToken = "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.eyJuYW1lIjoicm9oYW4gdmVybWEiLCJyb2xlIjoic3VwZXJfYWRtaW4iLCJlbWFpbCI6InJvaGFudmVybWEwMzFAZ21haWwuY29tIiwiZW1haWxfdmVyaWZpZWQiOmZhbHNlLCJhdXRoX3RpbWUiOjE3MTYyODI0NjgsInVzZXJfaWQiOiJKRlY5dmFkMWNQNFk0UFp6YmtrdnFZZlRIVU5hIiwiZmlyZWJhc2UiOnsiaWRlbnRpdGllcyI6eyJlbWFpbCI6WyJyb2hhbnZlcm1hMDMxQGdtYWlsLmNvbSJdfSwic2lnbl9pbl9wcm92aWRlciI6InBhc3N3b3JkIn0sImlhdCI6MTcxNjI4MjQ2OCwiZXhwIjoxNzE2Mjg2MDY4LCJhdWQiOiJhZGd5dGVjLWFmYTMzIiwiaXNzIjoiaHR0cHM6Ly9zZWN1cmV0b2tlbi5nb29nbGUuY29tL2FkZ3l0ZWMtYWZhMzMiLCJzdWIiOiJKRlY5dmFkMWNQNFk0UFp6YmtrdnFZZlRIVU5hIn0."
 
 type test struct {
 	name            string
@@ -22,3 +57,508 @@ type test struct {
 	expectedMessage string
 	reqBody         interface{}
 }
+
+func TestMain(m *testing.M) {
+	// email templates are read relative to the repository root, like in the
+	// container image
+	if err := os.Chdir(".."); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	level := slog.LevelError
+	if testing.Verbose() {
+		level = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	os.Exit(m.Run())
+}
+
+// fakeMailer records every message instead of sending it
+type fakeMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *fakeMailer) sentTo(email string) []mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sent []mailer.Message
+	for _, msg := range m.messages {
+		for _, to := range msg.To {
+			if to == email {
+				sent = append(sent, msg)
+			}
+		}
+	}
+
+	return sent
+}
+
+type testUser struct {
+	id    string
+	name  string
+	email string
+	role  string
+	token string
+}
+
+type testProject struct {
+	id          string
+	name        string
+	clientToken string
+}
+
+// harness is the api served in process with fixtures seeded for a test
+type harness struct {
+	t        *testing.T
+	server   *httptest.Server
+	pool     *pgxpool.Pool
+	storage  storage.Storage
+	identity *identity.Local
+	mailer   *fakeMailer
+	// the service layer behind the server, for tests of non http callers
+	svc *services.Service
+
+	superAdmin testUser
+	admin      testUser
+	user       testUser
+	// not a member of any project
+	outsider testUser
+
+	project testProject
+	// project user is not a member of
+	otherProject testProject
+	serviceId    string
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set, skipping integration test", testDSNEnv)
+	}
+
+	ctx := context.Background()
+	schema := "test_" + randomHex(t, 6)
+
+	admin, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("Error connecting to test database: %v", err)
+	}
+	defer admin.Close(ctx)
+
+	_, err = admin.Exec(ctx, "CREATE SCHEMA "+schema)
+	if err != nil {
+		t.Fatalf("Error creating schema: %v", err)
+	}
+	t.Cleanup(func() {
+		conn, err := pgx.Connect(context.Background(), dsn)
+		if err != nil {
+			t.Errorf("Error connecting to drop schema %s: %v", schema, err)
+			return
+		}
+		defer conn.Close(context.Background())
+
+		_, err = conn.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		if err != nil {
+			t.Errorf("Error dropping schema %s: %v", schema, err)
+		}
+	})
+
+	poolDSN, err := withSearchPath(dsn, schema)
+	if err != nil {
+		t.Fatalf("Error building pool dsn: %v", err)
+	}
+
+	pool, err := database.CreatePool(ctx, config.DatabaseConfig{
+		DSN:               poolDSN,
+		MaxConns:          10,
+		MaxConnLifetime:   time.Hour,
+		MaxConnIdleTime:   time.Minute,
+		HealthCheckPeriod: time.Minute,
+		ConnectTimeout:    time.Second * 5,
+	})
+	if err != nil {
+		t.Fatalf("Error creating pool: %v", err)
+	}
+
+	_, err = database.MigrateUp(ctx, pool)
+	if err != nil {
+		pool.Close()
+		t.Fatalf("Error migrating test schema: %v", err)
+	}
+
+	// listening first so signed storage urls can point at the server
+	srv := httptest.NewUnstartedServer(nil)
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{
+			Driver:     config.StorageLocal,
+			LocalDir:   t.TempDir(),
+			PublicURL:  "http://" + srv.Listener.Addr().String(),
+			SigningKey: randomHex(t, 16),
+		},
+		Identity: config.IdentityConfig{
+			Provider: config.IdentityLocal,
+			Local: config.LocalIdentityConfig{
+				PrivateKey: newPrivateKeyPEM(t),
+				Issuer:     "adgytec-test",
+				TokenTTL:   time.Hour,
+				ActionURL:  "https://app.dashboard.test",
+			},
+		},
+		Timeouts: config.TimeoutConfig{
+			Request:    time.Second * 30,
+			Storage:    time.Second * 10,
+			Auth:       time.Second * 10,
+			Background: time.Second * 10,
+		},
+		RateLimit:      10_000,
+		AllowedOrigins: []string{"https://*.dashboard.test"},
+		DashboardURL:   "https://app.dashboard.test",
+		InviteTTL:      time.Hour,
+	}
+
+	blobStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		t.Fatalf("Error creating storage: %v", err)
+	}
+
+	provider, err := identity.NewLocal(cfg.Identity.Local, pool)
+	if err != nil {
+		t.Fatalf("Error creating identity provider: %v", err)
+	}
+
+	fake := &fakeMailer{}
+	application := &app.App{
+		Config:   cfg,
+		Store:    pool,
+		Storage:  blobStorage,
+		Identity: provider,
+		Mailer:   fake,
+		Sessions: session.NewCache(time.Minute),
+	}
+	svc := services.New(application)
+
+	srv.Config.Handler = server.Router(application, svc)
+	srv.Start()
+
+	t.Cleanup(func() {
+		srv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		if err := svc.WaitForBackgroundTasks(ctx); err != nil {
+			t.Errorf("Error waiting for background tasks: %v", err)
+		}
+
+		pool.Close()
+	})
+
+	h := &harness{
+		t:        t,
+		server:   srv,
+		pool:     pool,
+		storage:  blobStorage,
+		identity: provider,
+		mailer:   fake,
+		svc:      svc,
+	}
+	h.seed()
+
+	return h
+}
+
+func withSearchPath(dsn, schema string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+func randomHex(t *testing.T, n int) string {
+	t.Helper()
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("Error generating random bytes: %v", err)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+func newPrivateKeyPEM(t *testing.T) string {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error encoding key: %v", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// pngImage is a tiny valid png accepted by the image upload handling
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 40, B: 90, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Error encoding png: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+/*
+fixtures
+*/
+func (h *harness) seed() {
+	h.superAdmin = h.createUser("Super Admin", "super.admin@adgytec.in", "super_admin")
+	h.admin = h.createUser("Project Admin", "admin@adgytec.in", "admin")
+	h.user = h.createUser("Project User", "user@adgytec.in", "user")
+	h.outsider = h.createUser("Outside User", "outsider@adgytec.in", "user")
+
+	h.project = h.createProject("Harness Project")
+	h.otherProject = h.createProject("Other Project")
+	h.addMember(h.user.id, h.project.id, validation.ProjectEditor)
+
+	h.serviceId = h.createService("news")
+}
+
+// createUser adds the user to the identity provider and the users table the
+// same way CreateUser does and signs a token for it
+func (h *harness) createUser(name, email, role string) testUser {
+	h.t.Helper()
+	ctx := context.Background()
+
+	u, err := h.identity.CreateUser(ctx, identity.UserToCreate{Email: email, Name: name, Password: testPassword})
+	if err != nil {
+		h.t.Fatalf("Error creating identity user %s: %v", email, err)
+	}
+
+	err = h.identity.SetClaims(ctx, u.UID, map[string]any{"role": role})
+	if err != nil {
+		h.t.Fatalf("Error setting claims for %s: %v", email, err)
+	}
+
+	_, err = h.pool.Exec(ctx, dbqueries.CreateUser, dbqueries.CreateUserArgs(u.UID, email, name, role))
+	if err != nil {
+		h.t.Fatalf("Error adding user %s: %v", email, err)
+	}
+
+	token, err := h.identity.IssueToken(ctx, u.UID)
+	if err != nil {
+		h.t.Fatalf("Error issuing token for %s: %v", email, err)
+	}
+
+	return testUser{id: u.UID, name: name, email: email, role: role, token: token}
+}
+
+func (h *harness) createProject(name string) testProject {
+	h.t.Helper()
+	ctx := context.Background()
+
+	projectId := services.GenerateUUID().String()
+	cover := fmt.Sprintf("projects/%v/cover.png", projectId)
+	clientToken := randomHex(h.t, 12)
+
+	content := pngImage(h.t)
+	err := h.storage.Put(ctx, cover, bytes.NewReader(content), int64(len(content)), "image/png")
+	if err != nil {
+		h.t.Fatalf("Error uploading cover for %s: %v", name, err)
+	}
+
+	_, err = h.pool.Exec(ctx, dbqueries.CreateProject, dbqueries.CreateProjectArgs(name, cover, projectId, services.HashClientToken(clientToken), validation.Scopes))
+	if err != nil {
+		h.t.Fatalf("Error creating project %s: %v", name, err)
+	}
+
+	return testProject{id: projectId, name: name, clientToken: clientToken}
+}
+
+func (h *harness) addMember(userId, projectId, role string) {
+	h.t.Helper()
+
+	_, err := h.pool.Exec(context.Background(), dbqueries.AddUserToProject, dbqueries.AddUserToProjectArgs(userId, projectId, role))
+	if err != nil {
+		h.t.Fatalf("Error adding user %s to project %s: %v", userId, projectId, err)
+	}
+}
+
+func (h *harness) createService(name string) string {
+	h.t.Helper()
+
+	var serviceId string
+	err := h.pool.QueryRow(context.Background(),
+		`INSERT INTO services (service_name) VALUES ($1) RETURNING service_id`, name,
+	).Scan(&serviceId)
+	if err != nil {
+		h.t.Fatalf("Error creating service %s: %v", name, err)
+	}
+
+	return serviceId
+}
+
+/*
+requests
+*/
+type response struct {
+	status int
+	raw    []byte
+	body   services.JSONResponse
+	// data of the body, kept raw so tests decode it into the type they expect
+	data json.RawMessage
+}
+
+// decode reads the data of the response into v
+func (r *response) decode(t *testing.T, v any) {
+	t.Helper()
+
+	if err := json.Unmarshal(r.data, v); err != nil {
+		t.Fatalf("Error decoding response data %s: %v", r.data, err)
+	}
+}
+
+// expect checks the status and, when message is not empty, the message of
+// the response. Error must be set exactly for failed requests.
+func (r *response) expect(t *testing.T, status int, message string) {
+	t.Helper()
+
+	if r.status != status {
+		t.Fatalf("unexpected status code: got %v want %v, body %s", r.status, status, r.raw)
+	}
+
+	if r.body.Error != (status >= http.StatusBadRequest) {
+		t.Errorf("unexpected error flag %v for status %v", r.body.Error, status)
+	}
+
+	if message != "" && r.body.Message != message {
+		t.Errorf("unexpected message: got %q want %q", r.body.Message, message)
+	}
+}
+
+func (h *harness) send(req *http.Request, token string) *response {
+	h.t.Helper()
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := h.server.Client().Do(req)
+	if err != nil {
+		h.t.Fatalf("Error making %s %s request: %v", req.Method, req.URL.Path, err)
+	}
+	defer res.Body.Close()
+
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		h.t.Fatalf("Error reading response body: %v", err)
+	}
+
+	r := &response{status: res.StatusCode, raw: raw}
+	if strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+		var envelope struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &r.body); err != nil {
+			h.t.Fatalf("Error decoding response body %s: %v", raw, err)
+		}
+		json.Unmarshal(raw, &envelope)
+		r.data = envelope.Data
+	}
+
+	return r
+}
+
+// do sends body encoded as json, nil sends no body
+func (h *harness) do(method, path, token string, body any) *response {
+	h.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatalf("Error encoding request body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, h.server.URL+path, reader)
+	if err != nil {
+		h.t.Fatalf("Error creating request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return h.send(req, token)
+}
+
+// doForm sends a multipart form, files are uploaded as png images
+func (h *harness) doForm(method, path, token string, fields map[string]string, files map[string][]byte) *response {
+	h.t.Helper()
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			h.t.Fatalf("Error writing form field: %v", err)
+		}
+	}
+
+	for name, content := range files {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, name, name+".png"))
+		header.Set("Content-Type", "image/png")
+
+		part, err := form.CreatePart(header)
+		if err != nil {
+			h.t.Fatalf("Error creating form file: %v", err)
+		}
+		part.Write(content)
+	}
+
+	if err := form.Close(); err != nil {
+		h.t.Fatalf("Error closing form: %v", err)
+	}
+
+	req, err := http.NewRequest(method, h.server.URL+path, &buf)
+	if err != nil {
+		h.t.Fatalf("Error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	return h.send(req, token)
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func (h *harness) me(token string) services.Me {
+	h.t.Helper()
+
+	var me services.Me
+	res := h.do(http.MethodGet, "/v1/me", token, nil)
+	res.expect(h.t, http.StatusOK, "")
+	res.decode(h.t, &me)
+
+	return me
+}
+
+func TestGetMe(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(http.MethodGet, "/v1/me", "", nil).
+		expect(t, http.StatusUnauthorized, "")
+
+	me := h.me(h.user.token)
+	if me.Profile.UserId != h.user.id || me.Profile.Name != h.user.name {
+		t.Fatalf("expected the profile of %v, got %+v", h.user.id, me.Profile)
+	}
+	if len(me.Capabilities) != 0 {
+		t.Fatalf("expected no global capabilities, got %v", me.Capabilities)
+	}
+	if len(me.Memberships) != 1 || me.Memberships[0].ProjectId != h.project.id {
+		t.Fatalf("expected a membership of %v, got %+v", h.project.id, me.Memberships)
+	}
+
+	membership := me.Memberships[0]
+	if len(membership.Services) != 0 {
+		t.Fatalf("expected no enabled services, got %+v", membership.Services)
+	}
+	for _, c := range []string{"news.read", "news.write", "news.delete", "gallery.delete"} {
+		if !slices.Contains(membership.Capabilities, c) {
+			t.Fatalf("expected editor capability %v, got %v", c, membership.Capabilities)
+		}
+	}
+	if slices.Contains(membership.Capabilities, services.CapabilityManageMembers) {
+		t.Fatalf("editors can't manage members, got %v", membership.Capabilities)
+	}
+
+	// granted permissions limit the member like ServicePermissionAuthorization
+	permissions := fmt.Sprintf("/v1/project/%v/user/%v/permissions/news", h.project.id, h.user.id)
+	h.do(http.MethodPut, permissions, h.admin.token, services.ServicePermission{Permissions: []string{"read"}}).
+		expect(t, http.StatusOK, "")
+
+	membership = h.me(h.user.token).Memberships[0]
+	if !slices.Contains(membership.Capabilities, "news.read") ||
+		slices.Contains(membership.Capabilities, "news.write") ||
+		slices.Contains(membership.Capabilities, "gallery.read") {
+		t.Fatalf("expected only news.read, got %v", membership.Capabilities)
+	}
+
+	me = h.me(h.superAdmin.token)
+	for _, c := range []string{services.CapabilityManageUsers, services.CapabilityManageAdmins, services.CapabilityReconcileUsers} {
+		if !slices.Contains(me.Capabilities, c) {
+			t.Fatalf("expected super admin capability %v, got %v", c, me.Capabilities)
+		}
+	}
+
+	me = h.me(h.admin.token)
+	if !slices.Contains(me.Capabilities, services.CapabilityManageProjects) ||
+		slices.Contains(me.Capabilities, services.CapabilityReconcileUsers) {
+		t.Fatalf("unexpected admin capabilities %v", me.Capabilities)
+	}
+}
+
+func TestPatchMe(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(http.MethodPatch, "/v1/me", h.user.token, services.MeUpdate{Name: ""}).
+		expect(t, http.StatusBadRequest, "The request body contains invalid input values.")
+
+	var me services.Me
+	res := h.do(http.MethodPatch, "/v1/me", h.user.token, services.MeUpdate{Name: "Renamed User"})
+	res.expect(t, http.StatusOK, "Successfully updated your profile.")
+	res.decode(t, &me)
+	if me.Profile.Name != "Renamed User" {
+		t.Fatalf("expected the new name, got %v", me.Profile.Name)
+	}
+
+	// the role can't be changed through the profile
+	if me.Profile.Role != h.user.role {
+		t.Fatalf("expected role %v, got %v", h.user.role, me.Profile.Role)
+	}
+}
@@ -0,0 +1,28 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/rohan031/adgytec-api/database"
+)
+
+func TestMigrationsAreWellFormed(t *testing.T) {
+	migrations, err := database.Migrations()
+	if err != nil {
+		t.Fatalf("Error reading embedded migrations: %v", err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatal("No migrations embedded")
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("Migration %s has version %d, want %d (versions must be contiguous)", m.Name, m.Version, i+1)
+		}
+
+		if m.Down == "" {
+			t.Errorf("Migration %04d_%s has no down script", m.Version, m.Name)
+		}
+	}
+}
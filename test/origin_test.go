@@ -0,0 +1,84 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func TestProjectOrigins(t *testing.T) {
+	h := newHarness(t)
+	origins := "/v1/project/" + h.project.id + "/origins"
+
+	fromOrigin := func(token, origin string) *response {
+		req, err := http.NewRequest(http.MethodGet, h.server.URL+"/v1/services/news", nil)
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		req.Header.Set("Origin", origin)
+		return h.send(req, token)
+	}
+
+	preflight := func(origin string) string {
+		req, err := http.NewRequest(http.MethodOptions, h.server.URL+"/v1/services/news", nil)
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		res, err := h.server.Client().Do(req)
+		if err != nil {
+			t.Fatalf("Error making preflight request: %v", err)
+		}
+		res.Body.Close()
+		return res.Header.Get("Access-Control-Allow-Origin")
+	}
+
+	// nothing registered, only requests without an origin work
+	h.do(http.MethodGet, "/v1/services/news", h.project.clientToken, nil).
+		expect(t, http.StatusOK, "")
+	fromOrigin(h.project.clientToken, "https://client.example").
+		expect(t, http.StatusForbidden, "Requests from this origin are not allowed for the project.")
+	if got := preflight("https://client.example"); got != "" {
+		t.Errorf("preflight allowed unregistered origin: %q", got)
+	}
+	if got := preflight("https://app.dashboard.test"); got != "https://app.dashboard.test" {
+		t.Errorf("preflight didn't allow dashboard origin: %q", got)
+	}
+
+	h.do(http.MethodPost, origins, h.admin.token, services.ProjectOrigin{Origin: "https://Client.example/"}).
+		expect(t, http.StatusCreated, "Successfully allowed origin: https://client.example")
+	h.do(http.MethodPost, origins, h.admin.token, services.ProjectOrigin{Origin: "https://client.example"}).
+		expect(t, http.StatusBadRequest, "The origin is already allowed for the project.")
+	h.do(http.MethodPost, origins, h.admin.token, services.ProjectOrigin{Origin: "client.example/path"}).
+		expect(t, http.StatusBadRequest, "Invalid origin, expected scheme and host e.g. https://example.com")
+
+	var all []services.ProjectOrigin
+	h.do(http.MethodGet, origins, h.admin.token, nil).decode(t, &all)
+	if len(all) != 1 || all[0].Origin != "https://client.example" {
+		t.Errorf("GetProjectOrigins returned unexpected origins: %+v", all)
+	}
+
+	fromOrigin(h.project.clientToken, "https://client.example").
+		expect(t, http.StatusOK, "")
+	if got := preflight("https://client.example"); got != "https://client.example" {
+		t.Errorf("preflight didn't allow registered origin: %q", got)
+	}
+
+	// the origin belongs to one project only
+	fromOrigin(h.otherProject.clientToken, "https://client.example").
+		expect(t, http.StatusForbidden, "Requests from this origin are not allowed for the project.")
+
+	h.do(http.MethodDelete, origins+"/"+all[0].OriginId, h.admin.token, nil).
+		expect(t, http.StatusOK, "Successfully removed origin: https://client.example")
+	h.do(http.MethodDelete, origins+"/"+all[0].OriginId, h.admin.token, nil).
+		expect(t, http.StatusNotFound, "The origin isn't allowed for the project.")
+	h.do(http.MethodDelete, origins+"/not-an-id", h.admin.token, nil).
+		expect(t, http.StatusBadRequest, "Invalid project id or origin id.")
+	fromOrigin(h.project.clientToken, "https://client.example").
+		expect(t, http.StatusForbidden, "Requests from this origin are not allowed for the project.")
+
+	h.do(http.MethodGet, origins, h.user.token, nil).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+}
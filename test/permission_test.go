@@ -0,0 +1,72 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func TestServicePermissions(t *testing.T) {
+	h := newHarness(t)
+	permissions := fmt.Sprintf("/v1/project/%v/user/%v/permissions", h.project.id, h.user.id)
+	albums := fmt.Sprintf("/v1/services/gallery/%v/albums", h.project.id)
+	documents := fmt.Sprintf("/v1/services/documents/%v/cover", h.project.id)
+
+	// without permissions the project role decides
+	h.do(http.MethodGet, documents, h.user.token, nil).
+		expect(t, http.StatusOK, "")
+
+	h.do(http.MethodPut, permissions+"/gallery", h.admin.token, services.ServicePermission{Permissions: []string{"read", "write", "write"}}).
+		expect(t, http.StatusOK, fmt.Sprintf("Successfully updated gallery permissions of user-id: %v", h.user.id))
+
+	var all []services.ServicePermission
+	h.do(http.MethodGet, permissions, h.admin.token, nil).decode(t, &all)
+	if len(all) != 1 || all[0].Service != "gallery" || len(all[0].Permissions) != 2 {
+		t.Errorf("GetServicePermissions returned unexpected permissions: %+v", all)
+	}
+
+	// only the granted service and permissions are left
+	h.doForm(http.MethodPost, albums, h.user.token, map[string]string{"name": "team"}, map[string][]byte{"cover": pngImage(t)}).
+		expect(t, http.StatusCreated, "")
+	var gallery struct {
+		Albums []services.Album `json:"albums"`
+	}
+	h.do(http.MethodGet, albums, h.user.token, nil).decode(t, &gallery)
+	h.do(http.MethodDelete, albums+"/"+gallery.Albums[0].Id, h.user.token, nil).
+		expect(t, http.StatusForbidden, "You don't have delete permission for gallery.")
+	h.do(http.MethodGet, documents, h.user.token, nil).
+		expect(t, http.StatusForbidden, "You don't have read permission for documents.")
+
+	// admins aren't limited
+	h.do(http.MethodGet, documents, h.admin.token, nil).
+		expect(t, http.StatusOK, "")
+
+	h.do(http.MethodPut, permissions+"/payments", h.admin.token, services.ServicePermission{Permissions: []string{"read"}}).
+		expect(t, http.StatusBadRequest, "Invalid service, expected news, blogs, gallery, documents or contact-us.")
+	h.do(http.MethodPut, permissions+"/news", h.admin.token, services.ServicePermission{Permissions: []string{"publish"}}).
+		expect(t, http.StatusBadRequest, "Invalid permission: publish")
+	h.do(http.MethodPut, fmt.Sprintf("/v1/project/%v/user/%v/permissions/news", h.project.id, h.outsider.id), h.admin.token, services.ServicePermission{Permissions: []string{"read"}}).
+		expect(t, http.StatusNotFound, "User isn't a member of the project.")
+	h.do(http.MethodGet, permissions, h.user.token, nil).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+
+	h.do(http.MethodDelete, permissions+"/gallery", h.admin.token, nil).
+		expect(t, http.StatusOK, fmt.Sprintf("Successfully removed gallery permissions of user-id: %v", h.user.id))
+	h.do(http.MethodDelete, permissions+"/gallery", h.admin.token, nil).
+		expect(t, http.StatusNotFound, "No permission for the service was granted to the user.")
+
+	// removing the last grant doesn't give back the access of the role
+	h.do(http.MethodGet, albums, h.user.token, nil).
+		expect(t, http.StatusForbidden, "You don't have read permission for gallery.")
+	h.do(http.MethodGet, documents, h.user.token, nil).
+		expect(t, http.StatusForbidden, "You don't have read permission for documents.")
+
+	h.do(http.MethodDelete, permissions, h.admin.token, nil).
+		expect(t, http.StatusOK, fmt.Sprintf("Successfully restored the project role access of user-id: %v", h.user.id))
+	h.do(http.MethodDelete, permissions, h.admin.token, nil).
+		expect(t, http.StatusNotFound, "The user isn't limited to service permissions in the project.")
+	h.do(http.MethodGet, documents, h.user.token, nil).
+		expect(t, http.StatusOK, "")
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+	"github.com/rohan031/adgytec-api/v1/validation"
+)
+
+// the outsider gets a different role in each project and is checked by the
+// method of the request
+func TestProjectRoles(t *testing.T) {
+	h := newHarness(t)
+	h.addMember(h.outsider.id, h.project.id, validation.ProjectAuthor)
+	h.addMember(h.outsider.id, h.otherProject.id, validation.ProjectViewer)
+
+	own := fmt.Sprintf("/v1/services/documents/%v/cover", h.project.id)
+	other := fmt.Sprintf("/v1/services/documents/%v/cover", h.otherProject.id)
+	denied := "Your role in the project doesn't allow this action."
+
+	// viewers only read
+	h.do(http.MethodGet, other, h.outsider.token, nil).
+		expect(t, http.StatusOK, "")
+	h.do(http.MethodPost, other, h.outsider.token, map[string]string{"name": "reports"}).
+		expect(t, http.StatusForbidden, denied)
+
+	// authors add and edit but can't delete
+	h.do(http.MethodPost, own, h.outsider.token, map[string]string{"name": "reports"}).
+		expect(t, http.StatusOK, "")
+	var covers []services.DocumentCover
+	h.do(http.MethodGet, own, h.outsider.token, nil).decode(t, &covers)
+	h.do(http.MethodPatch, own+"/"+covers[0].Id, h.outsider.token, map[string]string{"name": "yearly reports"}).
+		expect(t, http.StatusOK, "")
+	h.do(http.MethodDelete, own+"/"+covers[0].Id, h.outsider.token, nil).
+		expect(t, http.StatusForbidden, denied)
+
+	// editors delete
+	h.do(http.MethodDelete, own+"/"+covers[0].Id, h.user.token, nil).
+		expect(t, http.StatusOK, "")
+
+	// only admins and owners manage members
+	members := fmt.Sprintf("/v1/project/%v/user", h.project.id)
+	h.do(http.MethodPatch, members, h.outsider.token, services.ProjectUserMap{UserId: h.outsider.id, Role: validation.ProjectOwner}).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+	h.do(http.MethodPatch, members, h.admin.token, services.ProjectUserMap{UserId: h.outsider.id, Role: "publisher"}).
+		expect(t, http.StatusBadRequest, "Invalid project role, expected owner, editor, author or viewer.")
+	h.do(http.MethodPatch, members, h.admin.token, services.ProjectUserMap{UserId: h.admin.id, Role: validation.ProjectViewer}).
+		expect(t, http.StatusNotFound, "User isn't a member of the project.")
+	h.do(http.MethodPatch, members, h.admin.token, services.ProjectUserMap{UserId: h.outsider.id, Role: validation.ProjectOwner}).
+		expect(t, http.StatusOK, fmt.Sprintf("Successfully changed role of user-id: %v to owner", h.outsider.id))
+
+	h.do(http.MethodPatch, members, h.outsider.token, services.ProjectUserMap{UserId: h.user.id, Role: validation.ProjectViewer}).
+		expect(t, http.StatusOK, "")
+	h.do(http.MethodPost, own, h.user.token, map[string]string{"name": "reports"}).
+		expect(t, http.StatusForbidden, denied)
+
+	// the last owner can't be demoted or removed, not even by admins
+	lastOwner := "The project needs an owner, make another member owner first."
+	h.do(http.MethodPatch, members, h.outsider.token, services.ProjectUserMap{UserId: h.outsider.id, Role: validation.ProjectEditor}).
+		expect(t, http.StatusConflict, lastOwner)
+	h.do(http.MethodDelete, members, h.outsider.token, services.ProjectUserMap{UserId: h.outsider.id}).
+		expect(t, http.StatusConflict, lastOwner)
+	h.do(http.MethodDelete, members, h.admin.token, services.ProjectUserMap{UserId: h.outsider.id}).
+		expect(t, http.StatusConflict, lastOwner)
+
+	h.do(http.MethodPatch, members, h.outsider.token, services.ProjectUserMap{UserId: h.user.id, Role: validation.ProjectOwner}).
+		expect(t, http.StatusOK, "")
+	h.do(http.MethodPatch, members, h.outsider.token, services.ProjectUserMap{UserId: h.outsider.id, Role: validation.ProjectEditor}).
+		expect(t, http.StatusOK, "")
+	h.do(http.MethodDelete, members, h.user.token, services.ProjectUserMap{UserId: h.user.id}).
+		expect(t, http.StatusConflict, lastOwner)
+
+	// ownership of one project doesn't carry over
+	h.do(http.MethodPost, fmt.Sprintf("/v1/project/%v/user", h.otherProject.id), h.outsider.token, services.ProjectUserMap{UserId: h.user.id}).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+}
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func TestPostProject(t *testing.T) {
+	h := newHarness(t)
+	cover := map[string][]byte{"cover": pngImage(t)}
+
+	var created struct {
+		ProjectId   string `json:"projectId"`
+		ClientToken string `json:"clientToken"`
+	}
+	res := h.doForm(http.MethodPost, "/v1/project", h.admin.token, map[string]string{"projectName": "New Project"}, cover)
+	res.expect(t, http.StatusCreated, "Successfully created new project: New Project")
+	res.decode(t, &created)
+
+	// the token returned once on creation works for every public route
+	h.do(http.MethodGet, "/v1/services/news", created.ClientToken, nil).
+		expect(t, http.StatusOK, "")
+
+	h.doForm(http.MethodPost, "/v1/project", h.admin.token, map[string]string{"projectName": "New Project"}, cover).
+		expect(t, http.StatusBadRequest, "A project with that name already exists.")
+	h.doForm(http.MethodPost, "/v1/project", h.admin.token, map[string]string{"projectName": "Coverless"}, nil).
+		expect(t, http.StatusBadRequest, "Missing required file: cover")
+	h.doForm(http.MethodPost, "/v1/project", h.user.token, map[string]string{"projectName": "Forbidden"}, cover).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+
+	var all []services.Project
+	res = h.do(http.MethodGet, "/v1/projects", h.admin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &all)
+	if len(all) != 3 {
+		t.Errorf("GetAllProjects returned unexpected number of projects: got %v want 3", len(all))
+	}
+}
+
+func TestGetProject(t *testing.T) {
+	h := newHarness(t)
+
+	var project services.ProjectDetail
+	res := h.do(http.MethodGet, "/v1/project/"+h.project.id, h.admin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &project)
+
+	if project.Name != h.project.name {
+		t.Errorf("GetProjectById returned unexpected name: got %v want %v", project.Name, h.project.name)
+	}
+
+	// the cover is a signed url served by the local storage
+	cover, err := h.server.Client().Get(project.Cover)
+	if err != nil {
+		t.Fatalf("Error fetching project cover: %v", err)
+	}
+	cover.Body.Close()
+	if cover.StatusCode != http.StatusOK {
+		t.Errorf("project cover returned unexpected status code: got %v want %v", cover.StatusCode, http.StatusOK)
+	}
+
+	h.do(http.MethodGet, "/v1/project/"+services.GenerateUUID().String(), h.admin.token, nil).
+		expect(t, http.StatusNotFound, "Project with the provided ID does not exist.")
+}
+
+func TestProjectServices(t *testing.T) {
+	h := newHarness(t)
+	path := fmt.Sprintf("/v1/project/%v/services", h.project.id)
+	body := services.ProjectServiceMap{Services: []string{h.serviceId}}
+
+	var all []services.ServicesDetails
+	res := h.do(http.MethodGet, "/v1/services", h.admin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &all)
+	if len(all) != 1 || all[0].Id != h.serviceId {
+		t.Errorf("GetAllServices returned unexpected services: %s", res.data)
+	}
+
+	h.do(http.MethodPost, path, h.admin.token, body).
+		expect(t, http.StatusCreated, fmt.Sprintf("Successfuly added services to project-id: %v", h.project.id))
+	h.do(http.MethodPost, path, h.admin.token, body).
+		expect(t, http.StatusBadRequest, "The selected services are already included in this project.")
+
+	var metadata services.MetaDataByProject
+	res = h.do(http.MethodGet, fmt.Sprintf("/v1/client/projects/%v/metadata", h.project.id), h.user.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &metadata)
+	if metadata.Name != h.project.name {
+		t.Errorf("GetMetadataByProjectId returned unexpected project: got %v want %v", metadata.Name, h.project.name)
+	}
+	h.do(http.MethodGet, fmt.Sprintf("/v1/client/projects/%v/metadata", h.otherProject.id), h.user.token, nil).
+		expect(t, http.StatusNotFound, "Insufficient privileges to perform requested action.")
+	h.do(http.MethodGet, fmt.Sprintf("/v1/client/projects/%v/metadata", h.otherProject.id), h.admin.token, nil).
+		expect(t, http.StatusOK, "")
+
+	h.do(http.MethodDelete, path, h.admin.token, body).
+		expect(t, http.StatusOK, fmt.Sprintf("Successfuly removed service-id: %v from project-id: %v", h.serviceId, h.project.id))
+}
+
+func TestProjectUsers(t *testing.T) {
+	h := newHarness(t)
+	path := fmt.Sprintf("/v1/project/%v/user", h.otherProject.id)
+
+	h.do(http.MethodPost, path, h.admin.token, services.ProjectUserMap{UserId: h.user.id}).
+		expect(t, http.StatusCreated, fmt.Sprintf("Successfuly added user-id: %v to project-id: %v", h.user.id, h.otherProject.id))
+	h.do(http.MethodPost, path, h.admin.token, services.ProjectUserMap{UserId: h.user.id}).
+		expect(t, http.StatusBadRequest, "It looks like this user is already associated with the project.")
+	h.do(http.MethodPost, path, h.admin.token, services.ProjectUserMap{UserId: "unknown"}).
+		expect(t, http.StatusBadRequest, "User doesn't exist.")
+
+	// users only see the projects they are a member of
+	var all []services.Project
+	res := h.do(http.MethodGet, "/v1/client/projects", h.user.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &all)
+	if len(all) != 2 {
+		t.Errorf("GetProjectsByUserId returned unexpected number of projects: got %v want 2", len(all))
+	}
+
+	res = h.do(http.MethodGet, "/v1/client/projects", h.outsider.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &all)
+	if len(all) != 0 {
+		t.Errorf("GetProjectsByUserId returned projects for a user without any: %s", res.data)
+	}
+
+	h.do(http.MethodDelete, path, h.admin.token, services.ProjectUserMap{UserId: h.user.id}).
+		expect(t, http.StatusOK, fmt.Sprintf("Successfuly removed user-id: %v from project-id: %v", h.user.id, h.otherProject.id))
+}
+
+func TestDeleteProject(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(http.MethodDelete, "/v1/project/"+h.otherProject.id, h.admin.token, nil).
+		expect(t, http.StatusOK, fmt.Sprintf("Successfully deleted project with id: %v", h.otherProject.id))
+	h.do(http.MethodDelete, "/v1/project/"+h.otherProject.id, h.admin.token, nil).
+		expect(t, http.StatusNotFound, "project not found")
+}
+
+func TestCategories(t *testing.T) {
+	h := newHarness(t)
+	path := fmt.Sprintf("/v1/project/%v/category", h.project.id)
+
+	var created services.CategoryId
+	res := h.do(http.MethodPost, path, h.admin.token, services.Category{ParentId: h.project.id, CategoryName: "events"})
+	res.expect(t, http.StatusCreated, "Successfully created new category.")
+	res.decode(t, &created)
+
+	h.do(http.MethodPost, path, h.admin.token, services.Category{ParentId: h.project.id}).
+		expect(t, http.StatusBadRequest, "Invalid category details")
+
+	h.do(http.MethodGet, path, h.admin.token, nil).expect(t, http.StatusOK, "")
+
+	h.do(http.MethodPatch, path+"/"+created.CategoryId, h.admin.token, services.Category{CategoryName: "news"}).
+		expect(t, http.StatusCreated, "Successfully updated the category.")
+	h.do(http.MethodDelete, path+"/"+created.CategoryId, h.admin.token, nil).
+		expect(t, http.StatusOK, "Successfully deleted the category.")
+}
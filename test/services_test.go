@@ -0,0 +1,235 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func TestServicesAuthorization(t *testing.T) {
+	h := newHarness(t)
+
+	tests := []struct {
+		name            string
+		token           string
+		projectId       string
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{"member", h.user.token, h.project.id, http.StatusOK, ""},
+		{"admin", h.admin.token, h.otherProject.id, http.StatusOK, ""},
+		{"non member", h.outsider.token, h.project.id, http.StatusNotFound, "Insufficient privileges to perform requested action."},
+		{"unknown project", h.admin.token, services.GenerateUUID().String(), http.StatusNotFound, "Project with given id not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := h.do(http.MethodGet, "/v1/services/news/"+tt.projectId, tt.token, nil)
+			res.expect(t, tt.expectedStatus, tt.expectedMessage)
+		})
+	}
+}
+
+func TestNews(t *testing.T) {
+	h := newHarness(t)
+	path := "/v1/services/news/" + h.project.id
+	fields := map[string]string{"title": "launch", "text": "we launched", "link": "https://adgytec.in"}
+	image := map[string][]byte{"image": pngImage(t)}
+
+	for i := 0; i < 3; i++ {
+		h.doForm(http.MethodPost, path, h.user.token, fields, image).
+			expect(t, http.StatusCreated, "Successfully created news item.")
+	}
+	h.doForm(http.MethodPost, path, h.user.token, fields, nil).
+		expect(t, http.StatusBadRequest, "Missing required file: image")
+
+	var news []services.News
+	res := h.do(http.MethodGet, path, h.user.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &news)
+	if len(news) != 3 {
+		t.Fatalf("GetNews returned unexpected number of news: got %v want 3", len(news))
+	}
+
+	res = h.do(http.MethodGet, "/v1/services/news", h.project.clientToken, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &news)
+	if len(news) != 3 {
+		t.Errorf("GetAllNewsClient returned unexpected number of news: got %v want 3", len(news))
+	}
+
+	first := path + "/" + news[0].Id
+	h.do(http.MethodPut, first, h.user.token, services.NewsPut{Title: "relaunch", Link: "https://adgytec.in", Text: "we relaunched"}).
+		expect(t, http.StatusOK, fmt.Sprintf("Successfully updated news with id: %v", news[0].Id))
+	h.do(http.MethodPut, first, h.user.token, services.NewsPut{}).
+		expect(t, http.StatusBadRequest, "request body is empty")
+
+	h.do(http.MethodDelete, first, h.user.token, nil).
+		expect(t, http.StatusOK, "Successfully delete news item")
+	h.do(http.MethodDelete, first, h.user.token, nil).
+		expect(t, http.StatusNotFound, "News item not found")
+
+	remaining := services.NewsDelete{NewsId: []string{news[1].Id, news[2].Id}}
+	h.do(http.MethodDelete, path, h.user.token, remaining).
+		expect(t, http.StatusOK, "Successfully deleted news")
+	h.do(http.MethodDelete, path, h.user.token, remaining).
+		expect(t, http.StatusNotFound, "News not found")
+}
+
+func TestBlogs(t *testing.T) {
+	h := newHarness(t)
+	blogId := services.GenerateUUID().String()
+	path := fmt.Sprintf("/v1/services/blogs/%v/%v", h.project.id, blogId)
+	// every project has a default category sharing its id
+	fields := map[string]string{"title": "first blog", "summary": "summary", "content": "<p>content</p>", "author": "adgytec", "category": h.project.id}
+
+	h.doForm(http.MethodPost, path, h.user.token, fields, map[string][]byte{"cover": pngImage(t)}).
+		expect(t, http.StatusCreated, "Successfully added new blog")
+	h.doForm(http.MethodPost, fmt.Sprintf("/v1/services/blogs/%v/%v", h.project.id, services.GenerateUUID()), h.user.token, fields, nil).
+		expect(t, http.StatusCreated, "Successfully added new blog")
+
+	var list struct {
+		Blogs []services.BlogSummary `json:"blogs"`
+	}
+	res := h.do(http.MethodGet, "/v1/services/blogs/"+h.project.id, h.user.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &list)
+	if len(list.Blogs) != 2 {
+		t.Errorf("GetAllBlogsByProjectId returned unexpected number of blogs: got %v want 2", len(list.Blogs))
+	}
+
+	h.do(http.MethodGet, fmt.Sprintf("/v1/services/blogs/%v/category/%v", h.project.id, h.project.id), h.user.token, nil).
+		expect(t, http.StatusOK, "")
+	h.do(http.MethodGet, "/v1/services/blogs", h.project.clientToken, nil).expect(t, http.StatusOK, "")
+	h.do(http.MethodGet, "/v1/services/blogs/category/"+h.project.id, h.project.clientToken, nil).expect(t, http.StatusOK, "")
+
+	h.do(http.MethodPatch, path, h.user.token, services.BlogMetadata{Title: "renamed blog", Summary: "new summary", Category: h.project.id}).
+		expect(t, http.StatusOK, "successfully updated blog data")
+	h.doForm(http.MethodPatch, path+"/cover", h.user.token, nil, map[string][]byte{"cover": pngImage(t)}).
+		expect(t, http.StatusOK, "successfully updated blog cover image")
+	h.do(http.MethodPatch, path+"/content", h.user.token, map[string]string{"content": "<p>updated</p>"}).
+		expect(t, http.StatusOK, "Successfully updated blog content")
+
+	var blog services.Blog
+	res = h.do(http.MethodGet, "/v1/services/blog/"+blogId, h.project.clientToken, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &blog)
+	if blog.Title != "renamed blog" || blog.Content != "<p>updated</p>" {
+		t.Errorf("GetBlogById returned unexpected blog: %s", res.data)
+	}
+
+	media := fmt.Sprintf("services/blogs/%v/%v/media.png", h.project.id, blogId)
+	h.doForm(http.MethodPost, path+"/media", h.user.token,
+		map[string]string{"metadata": fmt.Sprintf(`[{"path": %q}]`, media)},
+		map[string][]byte{"media_0": pngImage(t)},
+	).expect(t, http.StatusCreated, "uploaded media files")
+	h.do(http.MethodDelete, path+"/media", h.user.token, services.BlogMedia{Paths: []string{media}}).
+		expect(t, http.StatusCreated, "successfully deleted the media files")
+
+	h.do(http.MethodDelete, path, h.user.token, nil).
+		expect(t, http.StatusOK, "successfully deleted the blog item")
+	h.do(http.MethodGet, path, h.user.token, nil).
+		expect(t, http.StatusNotFound, "Blog with the provided ID does not exist.")
+}
+
+func TestGallery(t *testing.T) {
+	h := newHarness(t)
+	path := fmt.Sprintf("/v1/services/gallery/%v/albums", h.project.id)
+
+	h.doForm(http.MethodPost, path, h.user.token, map[string]string{"name": "summit"}, map[string][]byte{"cover": pngImage(t)}).
+		expect(t, http.StatusCreated, "Successfully created the album")
+
+	var albums struct {
+		Albums []services.Album `json:"albums"`
+	}
+	res := h.do(http.MethodGet, path, h.user.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &albums)
+	if len(albums.Albums) != 1 {
+		t.Fatalf("GetAlbumsByProjectId returned unexpected number of albums: got %v want 1", len(albums.Albums))
+	}
+	albumId := albums.Albums[0].Id
+	h.do(http.MethodGet, "/v1/services/gallery/albums", h.project.clientToken, nil).expect(t, http.StatusOK, "")
+
+	h.do(http.MethodPatch, path+"/"+albumId+"/metadata", h.user.token, map[string]string{"name": "summit 2024"}).
+		expect(t, http.StatusOK, "successfully updated album data")
+	h.doForm(http.MethodPatch, path+"/"+albumId+"/cover", h.user.token, nil, map[string][]byte{"cover": pngImage(t)}).
+		expect(t, http.StatusOK, "successfully updated album cover image")
+
+	album := fmt.Sprintf("/v1/services/gallery/%v/album/%v", h.project.id, albumId)
+	var photo struct {
+		Id string `json:"id"`
+	}
+	res = h.doForm(http.MethodPost, album, h.user.token, nil, map[string][]byte{"photo": pngImage(t)})
+	res.expect(t, http.StatusCreated, "Successfully added photo to the album")
+	res.decode(t, &photo)
+
+	var photos struct {
+		Photos []services.Photos `json:"photos"`
+	}
+	res = h.do(http.MethodGet, "/v1/services/gallery/album/"+albumId, h.project.clientToken, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &photos)
+	if len(photos.Photos) != 1 || photos.Photos[0].Id != photo.Id {
+		t.Errorf("GetPhotosByAlbumId returned unexpected photos: %s", res.data)
+	}
+	h.do(http.MethodGet, album, h.user.token, nil).expect(t, http.StatusOK, "")
+	h.do(http.MethodGet, "/v1/services/gallery/album/"+albumId+"/name", h.project.clientToken, nil).expect(t, http.StatusOK, "")
+
+	h.do(http.MethodDelete, album, h.user.token, services.PhotoDelete{Id: []string{photo.Id}}).
+		expect(t, http.StatusOK, "Successfully deleted photos")
+	h.do(http.MethodDelete, path+"/"+albumId, h.user.token, nil).
+		expect(t, http.StatusOK, "successfully deleted the album")
+}
+
+func TestDocuments(t *testing.T) {
+	h := newHarness(t)
+	path := fmt.Sprintf("/v1/services/documents/%v/cover", h.project.id)
+
+	h.do(http.MethodPost, path, h.user.token, map[string]string{"name": "reports"}).
+		expect(t, http.StatusOK, "successfully created document cover")
+
+	var covers []services.DocumentCover
+	res := h.do(http.MethodGet, path, h.user.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &covers)
+	if len(covers) != 1 {
+		t.Fatalf("GetDocumentCoverByProjectId returned unexpected number of covers: got %v want 1", len(covers))
+	}
+
+	h.do(http.MethodPatch, path+"/"+covers[0].Id, h.user.token, map[string]string{"name": "annual reports"}).
+		expect(t, http.StatusOK, "successfully updated document cover")
+
+	res = h.do(http.MethodGet, "/v1/services/documents/cover", h.project.clientToken, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &covers)
+	if len(covers) != 1 || covers[0].Name != "annual reports" {
+		t.Errorf("GetDocumentCoverByProjectIdClient returned unexpected covers: %s", res.data)
+	}
+
+	h.do(http.MethodDelete, path+"/"+covers[0].Id, h.user.token, nil).
+		expect(t, http.StatusOK, "successfully deleted the document cover")
+}
+
+func TestContactUs(t *testing.T) {
+	h := newHarness(t)
+	path := "/v1/services/contact-us/" + h.project.id
+
+	h.do(http.MethodPost, "/v1/services/contact-us", h.project.clientToken, map[string]string{"name": "visitor", "message": "hello"}).
+		expect(t, http.StatusCreated, "Successfully submitted user data")
+
+	var data struct {
+		Responses []services.ContactUs `json:"responses"`
+	}
+	res := h.do(http.MethodGet, path, h.user.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &data)
+	if len(data.Responses) != 1 {
+		t.Fatalf("GetContactUs returned unexpected number of responses: got %v want 1", len(data.Responses))
+	}
+
+	h.do(http.MethodDelete, path+"/"+data.Responses[0].Id, h.user.token, nil).
+		expect(t, http.StatusOK, "successfully deleted the record")
+}
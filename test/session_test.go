@@ -0,0 +1,97 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+const revoked = "The session has been revoked, sign in again."
+
+// nextSecond waits for the next second, tokens issued in the second of a
+// revocation are still accepted so the harness tokens have to be older
+func nextSecond() {
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+}
+
+func (h *harness) signIn(email string) string {
+	h.t.Helper()
+
+	var data struct {
+		Token string `json:"token"`
+	}
+	res := h.do(http.MethodPost, "/v1/auth/token", "", services.Credentials{Email: email, Password: testPassword})
+	res.expect(h.t, http.StatusOK, "")
+	res.decode(h.t, &data)
+
+	return data.Token
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	h := newHarness(t)
+	path := "/v1/user/" + h.user.id + "/sessions"
+
+	// cached as valid before the revocation
+	h.do(http.MethodGet, "/v1/client/projects", h.user.token, nil).
+		expect(t, http.StatusOK, "")
+	nextSecond()
+
+	h.do(http.MethodDelete, path, h.outsider.token, nil).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+	h.do(http.MethodDelete, path, h.admin.token, nil).
+		expect(t, http.StatusOK, "The user has been signed out of every session.")
+
+	h.do(http.MethodGet, "/v1/client/projects", h.user.token, nil).
+		expect(t, http.StatusUnauthorized, revoked)
+
+	// signing in again right away works
+	token := h.signIn(h.user.email)
+	h.do(http.MethodGet, "/v1/client/projects", token, nil).
+		expect(t, http.StatusOK, "")
+
+	// other users keep their sessions
+	h.do(http.MethodGet, "/v1/client/projects", h.outsider.token, nil).
+		expect(t, http.StatusOK, "")
+}
+
+func TestRoleChangeRevokesSessions(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(http.MethodGet, "/v1/users", h.admin.token, nil).
+		expect(t, http.StatusOK, "")
+	nextSecond()
+
+	// a name change keeps the sessions
+	h.do(http.MethodPatch, "/v1/user/"+h.admin.id, h.superAdmin.token, services.User{Name: "Renamed Admin"}).
+		expect(t, http.StatusOK, "Successfully updated user details")
+	h.do(http.MethodGet, "/v1/users", h.admin.token, nil).
+		expect(t, http.StatusOK, "")
+
+	// the demoted admin's token still claims the admin role
+	h.do(http.MethodPatch, "/v1/user/"+h.admin.id, h.superAdmin.token, services.User{Role: "user"}).
+		expect(t, http.StatusOK, "Successfully updated user details")
+	h.do(http.MethodGet, "/v1/users", h.admin.token, nil).
+		expect(t, http.StatusUnauthorized, revoked)
+
+	h.do(http.MethodGet, "/v1/users", h.signIn(h.admin.email), nil).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+}
+
+func TestDeletedUserTokenRejected(t *testing.T) {
+	h := newHarness(t)
+
+	// cached as valid before the deletion
+	h.do(http.MethodGet, "/v1/users", h.admin.token, nil).
+		expect(t, http.StatusOK, "")
+
+	h.do(http.MethodDelete, "/v1/user/"+h.admin.id, h.superAdmin.token, services.UserDeletion{InheritorId: h.superAdmin.id}).
+		expect(t, http.StatusOK, "The user has been successfully deleted.")
+
+	// the token of the deleted admin hasn't expired yet
+	h.do(http.MethodGet, "/v1/users", h.admin.token, nil).
+		expect(t, http.StatusUnauthorized, "The account no longer exists.")
+	h.do(http.MethodGet, "/v1/client/projects", h.admin.token, nil).
+		expect(t, http.StatusUnauthorized, "The account no longer exists.")
+}
@@ -0,0 +1,141 @@
+package test
+
+import (
+	"context"
+	"encoding/csv"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func (h *harness) importUsers(path, token, file string) *response {
+	h.t.Helper()
+
+	return h.doForm(http.MethodPost, path, token, nil, map[string][]byte{"file": []byte(file)})
+}
+
+func TestImportUsers(t *testing.T) {
+	h := newHarness(t)
+
+	// email validation looks up the mx records of the domain
+	if _, err := net.LookupMX("gmail.com"); err != nil {
+		t.Skipf("mx lookup unavailable, skipping: %v", err)
+	}
+
+	file := "Email,Name,Role,Projects\n" +
+		"first.import@gmail.com,First Import,user," + h.project.id + ":author\n" +
+		"FIRST.IMPORT@gmail.com,Second Import,user,\n" +
+		"third.import@gmail.com,Third Import,admin,\n" +
+		"super.admin@adgytec.in,Super Admin,user,\n" +
+		"fourth.import@gmail.com,Fourth Import,user," + h.project.id + ":manager\n" +
+		"fifth.import@gmail.com,Fifth Import,user,00000000-0000-0000-0000-000000000000:viewer\n"
+
+	h.importUsers("/v1/users/import", h.user.token, file).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+	h.importUsers("/v1/users/import?dryRun=maybe", h.admin.token, file).
+		expect(t, http.StatusBadRequest, "Invalid dryRun, expected true or false.")
+	h.importUsers("/v1/users/import", h.admin.token, "email,role\nfirst.import@gmail.com,user\n").
+		expect(t, http.StatusBadRequest, "The file is missing the name column.")
+	h.importUsers("/v1/users/import", h.admin.token, "email,name,role\n").
+		expect(t, http.StatusBadRequest, "The file has no users.")
+
+	var report services.ImportReport
+	res := h.importUsers("/v1/users/import?dryRun=true", h.admin.token, file)
+	res.expect(t, http.StatusOK, "1 of 6 users can be imported.")
+	res.decode(t, &report)
+
+	expected := []string{
+		services.ImportRowValid,
+		services.ImportRowFailed, // duplicate of the first row
+		services.ImportRowFailed, // admins can only create users
+		services.ImportRowFailed, // existing account
+		services.ImportRowFailed, // invalid project role
+		services.ImportRowFailed, // unknown project
+	}
+	for i, row := range report.Rows {
+		if row.Status != expected[i] || row.Line != i+2 {
+			t.Errorf("unexpected row %d: got %+v want status %v", i, row, expected[i])
+		}
+	}
+
+	// nothing is created in a dry run
+	var count int
+	err := h.pool.QueryRow(context.Background(), `SELECT count(*) FROM users WHERE email = $1`, "first.import@gmail.com").Scan(&count)
+	if err != nil || count != 0 {
+		t.Fatalf("dry run created users: %v %v", count, err)
+	}
+
+	res = h.importUsers("/v1/users/import", h.admin.token, file)
+	res.expect(t, http.StatusOK, "Imported 1 of 6 users.")
+	res.decode(t, &report)
+
+	created := report.Rows[0]
+	if created.Status != services.ImportRowCreated || created.UserId == "" {
+		t.Fatalf("first row wasn't created: %+v", created)
+	}
+
+	if err := h.svc.WaitForBackgroundTasks(context.Background()); err != nil {
+		t.Fatalf("Error waiting for invites: %v", err)
+	}
+	if len(h.mailer.sentTo("first.import@gmail.com")) != 1 {
+		t.Errorf("expected an invite for the imported user")
+	}
+
+	var role string
+	err = h.pool.QueryRow(context.Background(),
+		`SELECT role FROM user_to_project WHERE user_id = $1 AND project_id = $2`, created.UserId, h.project.id,
+	).Scan(&role)
+	if err != nil || role != "author" {
+		t.Fatalf("imported user isn't an author of the project: %v %v", role, err)
+	}
+
+	// importing again only reports the existing accounts
+	h.importUsers("/v1/users/import", h.admin.token, file).
+		expect(t, http.StatusOK, "Imported 0 of 6 users.")
+}
+
+func TestExportUsers(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(http.MethodGet, "/v1/users/export", h.user.token, nil).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+
+	// names are user controlled, a spreadsheet must not run them
+	formula := `=HYPERLINK("https://attacker.example","open")`
+	_, err := h.pool.Exec(context.Background(), `UPDATE users SET name = $1 WHERE user_id = $2`, formula, h.outsider.id)
+	if err != nil {
+		t.Fatalf("Error updating user: %v", err)
+	}
+
+	res := h.do(http.MethodGet, "/v1/users/export", h.admin.token, nil)
+	if res.status != http.StatusOK {
+		t.Fatalf("unexpected status code: got %v want %v", res.status, http.StatusOK)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(string(res.raw))).ReadAll()
+	if err != nil {
+		t.Fatalf("export isn't a valid csv file: %v", err)
+	}
+
+	if len(records) != 5 || strings.Join(records[0], ",") != "user_id,email,name,role,status,invite_status,created_at,projects" {
+		t.Fatalf("unexpected export: %v", records)
+	}
+
+	for _, record := range records[1:] {
+		projects := ""
+		if record[0] == h.user.id {
+			projects = h.project.id + ":editor"
+		}
+
+		if record[7] != projects {
+			t.Errorf("unexpected projects of %v: got %q want %q", record[1], record[7], projects)
+		}
+
+		if record[0] == h.outsider.id && record[2] != "'"+formula {
+			t.Errorf("formula name wasn't escaped: %q", record[2])
+		}
+	}
+}
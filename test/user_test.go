@@ -1,70 +1,359 @@
 package test
 
 import (
-	"bytes"
-	"encoding/json"
-	"io"
+	"context"
+	"net"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"testing"
-	"time"
 
+	"github.com/rohan031/adgytec-api/identity"
 	"github.com/rohan031/adgytec-api/v1/services"
+	"github.com/rohan031/adgytec-api/v1/validation"
 )
 
 func TestPostUser(t *testing.T) {
+	h := newHarness(t)
+
+	// email validation looks up the mx records of the domain
+	if _, err := net.LookupMX("gmail.com"); err != nil {
+		t.Skipf("mx lookup unavailable, skipping: %v", err)
+	}
+
 	tests := []test{
 		{
 			name:            "successfull user creation",
 			expectedStatus:  http.StatusCreated,
-			expectedMessage: "Successfully created user account.",
-			reqBody:         services.User{Name: "aryan dhoundiyal", Email: "aryanluvmomdad@gmail.com", Role: "user"},
+			expectedMessage: "Successfully invited user.",
+			reqBody:         services.User{Name: "aryan dhoundiyal", Email: "aryan.dhoundiyal@gmail.com", Role: "user"},
 		}, {
 			name:            "duplicate user creation",
 			expectedStatus:  http.StatusConflict,
 			expectedMessage: "The email address provided is already associated with an existing user account.",
-			reqBody:         services.User{Name: "test user", Email: "vermarohan031@gmail.com", Role: "user"},
+			reqBody:         services.User{Name: "test user", Email: "aryan.dhoundiyal@gmail.com", Role: "user"},
+		}, {
+			name:            "invalid email",
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "The request body contains invalid input values.",
+			reqBody:         services.User{Name: "test user", Email: "not-an-email", Role: "user"},
 		},
 	}
 
-	url := baseUrl + "/v1/user"
-	client := http.Client{
-		Timeout: 30 * time.Second,
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := h.do(http.MethodPost, "/v1/user", h.superAdmin.token, tt.reqBody)
+			res.expect(t, tt.expectedStatus, tt.expectedMessage)
+		})
+	}
+
+	sent := h.mailer.sentTo("aryan.dhoundiyal@gmail.com")
+	if len(sent) != 1 {
+		t.Errorf("PostUser sent unexpected number of emails: got %v want 1", len(sent))
+	} else if !strings.Contains(sent[0].HTML, "/accept-invite?token=") || strings.Contains(sent[0].HTML, "Password") {
+		t.Errorf("PostUser sent an email without an invite link or with credentials: %s", sent[0].HTML)
+	}
+
+	t.Run("admin creating super admin", func(t *testing.T) {
+		res := h.do(http.MethodPost, "/v1/user", h.admin.token, services.User{Name: "test user", Email: "someone@gmail.com", Role: "super_admin"})
+		res.expect(t, http.StatusForbidden, "Insufficient privileges to create a user account with the specified role.")
+	})
+
+	t.Run("user role", func(t *testing.T) {
+		res := h.do(http.MethodPost, "/v1/user", h.user.token, services.User{Name: "test user", Email: "someone@gmail.com", Role: "user"})
+		res.expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+	})
+}
+
+func TestGetUsers(t *testing.T) {
+	h := newHarness(t)
+
+	type usersPage struct {
+		Users    []services.User       `json:"users"`
+		PageInfo services.UserPageInfo `json:"pageInfo"`
+	}
+
+	var page usersPage
+	res := h.do(http.MethodGet, "/v1/users", h.admin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &page)
+	if len(page.Users) != 4 || page.PageInfo.NextPage {
+		t.Errorf("GetAllUsers returned unexpected number of users: got %v want 4", len(page.Users))
+	}
+
+	res = h.do(http.MethodGet, "/v1/users?role=user", h.admin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &page)
+	for _, u := range page.Users {
+		if u.Role != "user" {
+			t.Errorf("GetAllUsers returned user with role %v", u.Role)
+		}
+	}
+
+	// pages follow each other without gaps or repeats
+	var names []string
+	path := "/v1/users?sort=-name&limit=3"
+	for {
+		res = h.do(http.MethodGet, path, h.admin.token, nil)
+		res.expect(t, http.StatusOK, "")
+		res.decode(t, &page)
+		for _, u := range page.Users {
+			names = append(names, u.Name)
+		}
+
+		if !page.PageInfo.NextPage {
+			break
+		}
+		path = "/v1/users?sort=-name&limit=3&cursor=" + url.QueryEscape(*page.PageInfo.Cursor)
+	}
+	want := []string{"Super Admin", "Project User", "Project Admin", "Outside User"}
+	if !slices.Equal(names, want) {
+		t.Errorf("GetAllUsers returned unexpected pages: got %v want %v", names, want)
+	}
+
+	res = h.do(http.MethodGet, "/v1/users?search=ADMIN%40", h.admin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &page)
+	if len(page.Users) != 2 {
+		t.Errorf("GetAllUsers search returned unexpected number of users: got %v want 2", len(page.Users))
+	}
+
+	res = h.do(http.MethodGet, "/v1/users?search=_", h.admin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &page)
+	if len(page.Users) != 0 {
+		t.Errorf("GetAllUsers search didn't match _ literally: %s", res.data)
+	}
+
+	res = h.do(http.MethodGet, "/v1/users?projectId="+h.project.id, h.admin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &page)
+	if len(page.Users) != 1 || page.Users[0].UserId != h.user.id {
+		t.Errorf("GetAllUsers returned unexpected project members: %s", res.data)
+	}
+
+	h.do(http.MethodGet, "/v1/users?sort=role", h.admin.token, nil).
+		expect(t, http.StatusBadRequest, "Invalid sort, expected created, name or email, prefixed with - for descending order.")
+	h.do(http.MethodGet, "/v1/users?role=owner", h.admin.token, nil).
+		expect(t, http.StatusBadRequest, "Invalid role filter.")
+	h.do(http.MethodGet, "/v1/users?cursor=abc", h.admin.token, nil).
+		expect(t, http.StatusBadRequest, "Invalid cursor.")
+
+	var user services.User
+	res = h.do(http.MethodGet, "/v1/user/"+h.user.id, h.admin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &user)
+	if user.Email != h.user.email {
+		t.Errorf("GetUserById returned unexpected user: got %v want %v", user.Email, h.user.email)
+	}
+
+	h.do(http.MethodGet, "/v1/user/unknown", h.admin.token, nil).
+		expect(t, http.StatusNotFound, "User with the provided ID does not exist.")
+	h.do(http.MethodGet, "/v1/users", h.user.token, nil).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+
+	// the cursor keeps working when the row it was taken from is deleted
+	res = h.do(http.MethodGet, "/v1/users?sort=name&limit=2", h.admin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &page)
+	if len(page.Users) != 2 || page.Users[1].UserId != h.admin.id {
+		t.Fatalf("GetAllUsers returned unexpected first page: %s", res.data)
+	}
+	cursor := url.QueryEscape(*page.PageInfo.Cursor)
+
+	// a cursor only continues the sort it was made for
+	h.do(http.MethodGet, "/v1/users?sort=email&cursor="+cursor, h.admin.token, nil).
+		expect(t, http.StatusBadRequest, "Invalid cursor.")
+
+	_, err := h.pool.Exec(context.Background(), `DELETE FROM users WHERE user_id = $1`, h.admin.id)
+	if err != nil {
+		t.Fatalf("Error deleting user: %v", err)
+	}
+	res = h.do(http.MethodGet, "/v1/users?sort=name&limit=2&cursor="+cursor, h.superAdmin.token, nil)
+	res.expect(t, http.StatusOK, "")
+	res.decode(t, &page)
+	if len(page.Users) != 2 || page.Users[0].UserId != h.user.id || page.Users[1].UserId != h.superAdmin.id {
+		t.Errorf("GetAllUsers returned unexpected page after the cursor row was deleted: %s", res.data)
+	}
+}
+
+func TestPatchUser(t *testing.T) {
+	h := newHarness(t)
+
+	tests := []struct {
+		test
+		token  string
+		userId string
+	}{
+		{
+			test:   test{name: "user updating own name", expectedStatus: http.StatusOK, expectedMessage: "Successfully updated user details", reqBody: services.User{Name: "renamed user"}},
+			token:  h.user.token,
+			userId: h.user.id,
+		}, {
+			test:   test{name: "admin updating user", expectedStatus: http.StatusOK, expectedMessage: "Successfully updated user details", reqBody: services.User{Name: "renamed again"}},
+			token:  h.admin.token,
+			userId: h.user.id,
+		}, {
+			test:   test{name: "admin granting super admin", expectedStatus: http.StatusForbidden, expectedMessage: "Insufficient privileges to perform requested action.", reqBody: services.User{Role: "super_admin"}},
+			token:  h.admin.token,
+			userId: h.user.id,
+		}, {
+			test:   test{name: "user updating someone else", expectedStatus: http.StatusForbidden, expectedMessage: "Insufficient privileges to perform requested action.", reqBody: services.User{Name: "renamed user"}},
+			token:  h.user.token,
+			userId: h.outsider.id,
+		}, {
+			test:   test{name: "unknown user", expectedStatus: http.StatusNotFound, expectedMessage: "No user found.", reqBody: services.User{Name: "renamed user"}},
+			token:  h.superAdmin.token,
+			userId: "unknown",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			var reqBody io.Reader
-			bodyBytes, _ := json.Marshal(tt.reqBody)
-			reqBody = bytes.NewBuffer(bodyBytes)
+			res := h.do(http.MethodPatch, "/v1/user/"+tt.userId, tt.token, tt.reqBody)
+			res.expect(t, tt.expectedStatus, tt.expectedMessage)
+		})
+	}
 
-			req, err := http.NewRequest(http.MethodPost, url, reqBody)
-			if err != nil {
-				t.Fatalf("Error creating post request to user endpoint: %v", err)
-			}
-			req.Header.Add("Authorization", "Bearer "+idToken)
-			req.Header.Add("Content-Type", "application/json")
+	u, err := h.identity.GetUser(context.Background(), h.user.id)
+	if err != nil {
+		t.Fatalf("Error getting updated user: %v", err)
+	}
+	if u.Name != "renamed again" {
+		t.Errorf("PatchUser didn't update the identity provider: got %q", u.Name)
+	}
+}
 
-			res, err := client.Do(req)
-			if err != nil {
-				t.Fatalf("client: error making HTTP request: %v", err)
-			}
-			defer res.Body.Close()
+func TestDeleteUser(t *testing.T) {
+	h := newHarness(t)
 
-			if res.StatusCode != tt.expectedStatus {
-				t.Errorf("PostUser returned unexpected status code: got %v want %v", res.StatusCode, tt.expectedStatus)
-			}
+	tests := []struct {
+		name            string
+		token           string
+		userId          string
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{"user role", h.user.token, h.outsider.id, http.StatusForbidden, "Insufficient privileges to perform requested action."},
+		{"admin deleting admin", h.admin.token, h.admin.id, http.StatusForbidden, "Insufficient privileges to perform requested action."},
+		{"without inheritor", h.admin.token, h.outsider.id, http.StatusBadRequest, "Choose a user to inherit the content of the deleted user."},
+		{"admin deleting user", h.admin.token, h.outsider.id, http.StatusOK, "The user has been successfully deleted."},
+		{"unknown user", h.superAdmin.token, h.outsider.id, http.StatusNotFound, "No user found for deletion."},
+	}
 
-			body, err := io.ReadAll(res.Body)
-			if err != nil {
-				t.Fatalf("client: error reading response body: %v", err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := services.UserDeletion{InheritorId: h.admin.id}
+			if tt.name == "without inheritor" {
+				body.InheritorId = ""
 			}
 
-			var payload services.JSONResponse
-			json.Unmarshal(body, &payload)
-			if payload.Message != tt.expectedMessage {
-				t.Errorf("PostUser returned unexpected body: got %v want %v", payload.Message, tt.expectedMessage)
-			}
+			res := h.do(http.MethodDelete, "/v1/user/"+tt.userId, tt.token, body)
+			res.expect(t, tt.expectedStatus, tt.expectedMessage)
 		})
 	}
 
+	h.do(http.MethodGet, "/v1/user/"+h.outsider.id, h.admin.token, nil).
+		expect(t, http.StatusNotFound, "User with the provided ID does not exist.")
+}
+
+func TestDeleteUserTransfersContent(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+
+	var albumId string
+	err := h.pool.QueryRow(ctx, `
+		INSERT INTO album (project_id, user_id, name, cover)
+		VALUES ($1, $2, 'events', 'cover.png')
+		RETURNING album_id
+	`, h.project.id, h.user.id).Scan(&albumId)
+	if err != nil {
+		t.Fatalf("Error adding album: %v", err)
+	}
+
+	h.do(http.MethodDelete, "/v1/user/"+h.user.id, h.admin.token, services.UserDeletion{InheritorId: h.user.id}).
+		expect(t, http.StatusBadRequest, "The deleted user can't inherit their own content.")
+	h.do(http.MethodDelete, "/v1/user/"+h.user.id, h.admin.token, services.UserDeletion{InheritorId: "unknown"}).
+		expect(t, http.StatusBadRequest, "The user inheriting the content doesn't exist.")
+
+	// nothing was deleted by the failed attempts
+	if _, err := h.identity.GetUser(ctx, h.user.id); err != nil {
+		t.Fatalf("account deleted by a failed deletion: %v", err)
+	}
+
+	h.do(http.MethodDelete, "/v1/user/"+h.user.id, h.admin.token, services.UserDeletion{InheritorId: h.outsider.id}).
+		expect(t, http.StatusOK, "The user has been successfully deleted.")
+
+	var owner string
+	err = h.pool.QueryRow(ctx, "SELECT user_id FROM album WHERE album_id = $1", albumId).Scan(&owner)
+	if err != nil {
+		t.Fatalf("Error reading album: %v", err)
+	}
+	if owner != h.outsider.id {
+		t.Errorf("album wasn't transferred: got owner %v want %v", owner, h.outsider.id)
+	}
+}
+
+func TestDeleteUserKeepsProjectOwner(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+	path := "/v1/user/" + h.outsider.id
+	body := services.UserDeletion{InheritorId: h.admin.id}
+
+	h.addMember(h.outsider.id, h.project.id, validation.ProjectOwner)
+	h.do(http.MethodDelete, path, h.admin.token, body).
+		expect(t, http.StatusConflict, "The project needs an owner, make another member owner first.")
+
+	// nothing was deleted by the refused deletion
+	if _, err := h.identity.GetUser(ctx, h.outsider.id); err != nil {
+		t.Fatalf("account deleted by a refused deletion: %v", err)
+	}
+
+	h.do(http.MethodPatch, "/v1/project/"+h.project.id+"/user", h.admin.token, services.ProjectUserMap{UserId: h.user.id, Role: validation.ProjectOwner}).
+		expect(t, http.StatusOK, "")
+	h.do(http.MethodDelete, path, h.admin.token, body).
+		expect(t, http.StatusOK, "The user has been successfully deleted.")
+
+	// an account without a users row
+	u, err := h.identity.CreateUser(ctx, identity.UserToCreate{Email: "orphan@adgytec.in", Name: "Orphan", Password: testPassword})
+	if err != nil {
+		t.Fatalf("Error creating identity user: %v", err)
+	}
+	h.do(http.MethodDelete, "/v1/user/"+u.UID, h.admin.token, body).
+		expect(t, http.StatusNotFound, "No user found for deletion.")
+}
+
+func TestSuspendUser(t *testing.T) {
+	h := newHarness(t)
+	path := "/v1/user/" + h.user.id
+
+	h.do(http.MethodPost, path+"/suspend", h.user.token, nil).
+		expect(t, http.StatusForbidden, "Insufficient privileges to perform requested action.")
+	h.do(http.MethodPost, path+"/reactivate", h.admin.token, nil).
+		expect(t, http.StatusBadRequest, "The user isn't suspended.")
+
+	h.do(http.MethodPost, path+"/suspend", h.admin.token, nil).
+		expect(t, http.StatusOK, "The user has been suspended.")
+	h.do(http.MethodPost, path+"/suspend", h.admin.token, nil).
+		expect(t, http.StatusBadRequest, "The user is already suspended.")
+
+	// the issued token stops working and no new one is issued
+	h.do(http.MethodGet, "/v1/client/projects", h.user.token, nil).
+		expect(t, http.StatusForbidden, "The account has been suspended.")
+	h.do(http.MethodPost, "/v1/auth/token", "", services.Credentials{Email: h.user.email, Password: testPassword}).
+		expect(t, http.StatusForbidden, "The account has been suspended.")
+
+	var user services.User
+	h.do(http.MethodGet, path, h.admin.token, nil).decode(t, &user)
+	if user.Status != "suspended" || user.SuspendedAt == nil {
+		t.Errorf("suspended user has unexpected status: %v %v", user.Status, user.SuspendedAt)
+	}
+
+	h.do(http.MethodPost, path+"/reactivate", h.admin.token, nil).
+		expect(t, http.StatusOK, "The user has been reactivated.")
+	h.do(http.MethodGet, "/v1/client/projects", h.user.token, nil).
+		expect(t, http.StatusOK, "")
+	h.do(http.MethodPost, "/v1/auth/token", "", services.Credentials{Email: h.user.email, Password: testPassword}).
+		expect(t, http.StatusOK, "")
 }
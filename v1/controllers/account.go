@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/rohan031/adgytec-api/helper"
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+// the same response whether the email belongs to an account or not
+const accountEmailMessage = "If an account exists for the email, we've sent a link to it."
+
+func (h *Handler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	data, err := helper.DecodeJSON[services.AccountEmail](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	h.services.RequestPasswordReset(r.Context(), &data)
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = accountEmailMessage
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	data, err := helper.DecodeJSON[services.PasswordReset](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	err = h.services.ConfirmPasswordReset(r.Context(), &data)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = "Password reset, you can now sign in with your new password."
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) RequestEmailVerification(w http.ResponseWriter, r *http.Request) {
+	data, err := helper.DecodeJSON[services.AccountEmail](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	h.services.RequestEmailVerification(r.Context(), &data)
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = accountEmailMessage
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) ConfirmEmailVerification(w http.ResponseWriter, r *http.Request) {
+	data, err := helper.DecodeJSON[services.EmailVerification](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	err = h.services.ConfirmEmailVerification(r.Context(), &data)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = "Email verified."
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/rohan031/adgytec-api/helper"
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+// sign in for the local identity provider, with firebase the dashboard gets
+// its id token from firebase directly
+func (h *Handler) PostToken(w http.ResponseWriter, r *http.Request) {
+	data, err := helper.DecodeJSON[services.Credentials](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	token, err := h.services.SignIn(r.Context(), &data)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Data = struct {
+		Token string `json:"token"`
+	}{Token: token}
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
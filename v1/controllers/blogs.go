@@ -12,7 +12,7 @@ import (
 	"github.com/rohan031/adgytec-api/v1/services"
 )
 
-func GetUUID(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetUUID(w http.ResponseWriter, r *http.Request) {
 	id := services.GenerateUUID()
 
 	var payload services.JSONResponse
@@ -26,9 +26,9 @@ func GetUUID(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func PostMedia(w http.ResponseWriter, r *http.Request) {
-	// projectId := chi.URLParam(r, "projectId")
-	// blogId := chi.URLParam(r, "blogId")
+func (h *Handler) PostMedia(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+	blogId := chi.URLParam(r, "blogId")
 	maxSize := 25 << 20 // 25 mb
 
 	err := helper.ParseMultipartForm(w, r, maxSize)
@@ -46,7 +46,7 @@ func PostMedia(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var bm services.BlogMedia
-	err, success := bm.UploadMedia(r)
+	err, success := h.services.UploadMedia(r.Context(), &bm, r, projectId, blogId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -65,14 +65,17 @@ func PostMedia(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func DeleteMedia(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+	blogId := chi.URLParam(r, "blogId")
+
 	mediaDetails, err := helper.DecodeJSON[services.BlogMedia](w, r, mb)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
 	}
 
-	err = mediaDetails.DeleteMedia()
+	err = h.services.DeleteMedia(r.Context(), &mediaDetails, projectId, blogId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -84,7 +87,7 @@ func DeleteMedia(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusCreated, payload)
 }
 
-func PostBlog(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostBlog(w http.ResponseWriter, r *http.Request) {
 	maxSize := 15 << 20 // 15mb
 	err := helper.ParseMultipartForm(w, r, maxSize)
 	if err != nil {
@@ -131,12 +134,12 @@ func PostBlog(w http.ResponseWriter, r *http.Request) {
 		// 	Message: message,
 		// })
 		// return
-		err = blogItem.CreateBlogWithoutCover(projectId, userId)
+		err = h.services.CreateBlogWithoutCover(r.Context(), &blogItem, projectId, userId)
 	} else {
-		err = blogItem.CreateBlog(r, projectId, userId)
+		err = h.services.CreateBlog(r.Context(), &blogItem, r, projectId, userId)
 	}
 
-	// err = blogItem.CreateBlog(r, projectId, userId)
+	// err = h.services.CreateBlog(r.Context(), &blogItem, r, projectId, userId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -150,7 +153,7 @@ func PostBlog(w http.ResponseWriter, r *http.Request) {
 }
 
 // only title, author, created_at, summary, cover image
-func GetAllBlogsByProjectId(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAllBlogsByProjectId(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 	cursor := r.URL.Query().Get("cursor")
 	limString := r.URL.Query().Get("limit")
@@ -165,7 +168,7 @@ func GetAllBlogsByProjectId(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var blogs services.Blog
-	all, pageInfo, err := blogs.GetBlogsByProjectId(projectId, cursor, limit)
+	all, pageInfo, err := h.services.GetBlogsByProjectId(r.Context(), &blogs, projectId, cursor, limit)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -185,7 +188,7 @@ func GetAllBlogsByProjectId(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func GetAllBlogsByCategoryId(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAllBlogsByCategoryId(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 	categoryId := chi.URLParam(r, "categoryId")
 	cursor := r.URL.Query().Get("cursor")
@@ -202,7 +205,7 @@ func GetAllBlogsByCategoryId(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var blogs services.Blog
-	all, pageInfo, err := blogs.GetBlogsByCategoryId(projectId, categoryId, cursor, limit)
+	all, pageInfo, err := h.services.GetBlogsByCategoryId(r.Context(), &blogs, projectId, categoryId, cursor, limit)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -222,7 +225,7 @@ func GetAllBlogsByCategoryId(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func GetAllBlogsByProjectIdClient(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAllBlogsByProjectIdClient(w http.ResponseWriter, r *http.Request) {
 	projectId := r.Context().Value(custom.ProjectId).(string)
 	cursor := r.URL.Query().Get("cursor")
 
@@ -238,7 +241,7 @@ func GetAllBlogsByProjectIdClient(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var blogs services.Blog
-	all, pageInfo, err := blogs.GetBlogsByProjectId(projectId, cursor, limit)
+	all, pageInfo, err := h.services.GetBlogsByProjectId(r.Context(), &blogs, projectId, cursor, limit)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -257,7 +260,7 @@ func GetAllBlogsByProjectIdClient(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func GetAllBlogsByCategoryIdClient(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAllBlogsByCategoryIdClient(w http.ResponseWriter, r *http.Request) {
 	projectId := r.Context().Value(custom.ProjectId).(string)
 	categoryId := chi.URLParam(r, "categoryId")
 	cursor := r.URL.Query().Get("cursor")
@@ -273,7 +276,7 @@ func GetAllBlogsByCategoryIdClient(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var blogs services.Blog
-	all, pageInfo, err := blogs.GetBlogsByCategoryId(projectId, categoryId, cursor, limit)
+	all, pageInfo, err := h.services.GetBlogsByCategoryId(r.Context(), &blogs, projectId, categoryId, cursor, limit)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -292,13 +295,14 @@ func GetAllBlogsByCategoryIdClient(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func GetBlogById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetBlogById(w http.ResponseWriter, r *http.Request) {
 	blogId := chi.URLParam(r, "blogId")
+	projectId := projectIdFromRequest(r)
 
 	var blogData services.Blog
 	blogData.Id = blogId
 
-	blog, err := blogData.GetBlogById()
+	blog, err := h.services.GetBlogById(r.Context(), &blogData, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -312,8 +316,9 @@ func GetBlogById(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func PatchBlogMetadataById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PatchBlogMetadataById(w http.ResponseWriter, r *http.Request) {
 	blogId := chi.URLParam(r, "blogId")
+	projectId := chi.URLParam(r, "projectId")
 
 	blogDetails, err := helper.DecodeJSON[services.BlogMetadata](w, r, mb)
 	if err != nil {
@@ -322,7 +327,7 @@ func PatchBlogMetadataById(w http.ResponseWriter, r *http.Request) {
 	}
 
 	blogDetails.Id = blogId
-	err = blogDetails.PatchBlogMetadataById()
+	err = h.services.PatchBlogMetadataById(r.Context(), &blogDetails, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -335,14 +340,14 @@ func PatchBlogMetadataById(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func DeleteBlogById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteBlogById(w http.ResponseWriter, r *http.Request) {
 	blogId := chi.URLParam(r, "blogId")
 	projectId := chi.URLParam(r, "projectId")
 
 	var blog services.Blog
 	blog.Id = blogId
 
-	err := blog.DeleteBlogById(projectId)
+	err := h.services.DeleteBlogById(r.Context(), &blog, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -356,7 +361,7 @@ func DeleteBlogById(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func PatchBlogCover(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PatchBlogCover(w http.ResponseWriter, r *http.Request) {
 	maxSize := 10 << 20 // 10mb
 	err := helper.ParseMultipartForm(w, r, maxSize)
 	if err != nil {
@@ -380,7 +385,7 @@ func PatchBlogCover(w http.ResponseWriter, r *http.Request) {
 	var blog services.Blog
 
 	blog.Id = blogId
-	err = blog.PatchBlogCover(r, projectId)
+	err = h.services.PatchBlogCover(r.Context(), &blog, r, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -394,8 +399,9 @@ func PatchBlogCover(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func PatchBlogContent(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PatchBlogContent(w http.ResponseWriter, r *http.Request) {
 	blogId := chi.URLParam(r, "blogId")
+	projectId := chi.URLParam(r, "projectId")
 
 	blogContent, err := helper.DecodeJSON[services.Blog](w, r, mb*10)
 	if err != nil {
@@ -404,7 +410,7 @@ func PatchBlogContent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	blogContent.Id = blogId
-	err = blogContent.PatchBlogContent()
+	err = h.services.PatchBlogContent(r.Context(), &blogContent, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -8,7 +8,7 @@ import (
 	"github.com/rohan031/adgytec-api/v1/services"
 )
 
-func PostCategoryByProjectId(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostCategoryByProjectId(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 
 	category, err := helper.DecodeJSON[services.Category](w, r, mb)
@@ -17,7 +17,7 @@ func PostCategoryByProjectId(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	item, err := category.PostCategoryByProjectId(projectId)
+	item, err := h.services.PostCategoryByProjectId(r.Context(), &category, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -31,8 +31,9 @@ func PostCategoryByProjectId(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusCreated, payload)
 }
 
-func PatchCategoryById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PatchCategoryById(w http.ResponseWriter, r *http.Request) {
 	categoryId := chi.URLParam(r, "categoryId")
+	projectId := chi.URLParam(r, "projectId")
 
 	category, err := helper.DecodeJSON[services.Category](w, r, mb)
 	if err != nil {
@@ -40,7 +41,7 @@ func PatchCategoryById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = category.PatchCategoryById(categoryId)
+	err = h.services.PatchCategoryById(r.Context(), &category, categoryId, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -53,12 +54,12 @@ func PatchCategoryById(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusCreated, payload)
 }
 
-func GetCategoryByProjectId(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetCategoryByProjectId(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 
 	var category services.Category
 
-	categories, err := category.GetCategoryByProjectId(projectId)
+	categories, err := h.services.GetCategoryByProjectId(r.Context(), &category, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -71,11 +72,12 @@ func GetCategoryByProjectId(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func DeleteCategoryById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteCategoryById(w http.ResponseWriter, r *http.Request) {
 	categoryId := chi.URLParam(r, "categoryId")
+	projectId := chi.URLParam(r, "projectId")
 	var category services.Category
 
-	err := category.DeleteCategoryById(categoryId)
+	err := h.services.DeleteCategoryById(r.Context(), &category, categoryId, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rohan031/adgytec-api/helper"
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func (h *Handler) PostClientToken(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+
+	tokenDetails, err := helper.DecodeJSON[services.ClientTokenInput](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	token, err := h.services.CreateClientToken(r.Context(), &tokenDetails, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = "Successfully created client token, it won't be shown again."
+	payload.Data = token
+
+	helper.EncodeJSON(w, http.StatusCreated, payload)
+}
+
+func (h *Handler) GetClientTokens(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+
+	var token services.ClientToken
+	all, err := h.services.GetClientTokensByProjectId(r.Context(), &token, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Data = all
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) PatchClientToken(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+	tokenId := chi.URLParam(r, "tokenId")
+
+	tokenDetails, err := helper.DecodeJSON[services.ClientTokenInput](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	token, err := h.services.PatchClientTokenById(r.Context(), &tokenDetails, tokenId, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = "Successfully updated client token."
+	payload.Data = token
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) RotateClientToken(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+	tokenId := chi.URLParam(r, "tokenId")
+
+	var token services.ClientToken
+	rotated, err := h.services.RotateClientTokenById(r.Context(), &token, tokenId, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = "Successfully rotated client token, the previous token no longer works."
+	payload.Data = rotated
+
+	helper.EncodeJSON(w, http.StatusCreated, payload)
+}
+
+func (h *Handler) RevokeClientToken(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+	tokenId := chi.URLParam(r, "tokenId")
+
+	var token services.ClientToken
+	err := h.services.RevokeClientTokenById(r.Context(), &token, tokenId, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = "Successfully revoked client token."
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
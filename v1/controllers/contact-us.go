@@ -9,7 +9,7 @@ import (
 	"strconv"
 )
 
-func PostContactUs(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostContactUs(w http.ResponseWriter, r *http.Request) {
 	projectId := r.Context().Value(custom.ProjectId).(string)
 
 	data, err := helper.DecodeJSON[map[string]interface{}](w, r, mb)
@@ -20,7 +20,7 @@ func PostContactUs(w http.ResponseWriter, r *http.Request) {
 
 	var contactUs services.ContactUs
 
-	err = contactUs.PostContactUs(projectId, data)
+	err = h.services.PostContactUs(r.Context(), &contactUs, projectId, data)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -33,7 +33,7 @@ func PostContactUs(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusCreated, payload)
 }
 
-func GetContactUs(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetContactUs(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 	cursor := r.URL.Query().Get("cursor")
 	limString := r.URL.Query().Get("limit")
@@ -47,7 +47,7 @@ func GetContactUs(w http.ResponseWriter, r *http.Request) {
 		cursor = getNow()
 	}
 	var contactUs services.ContactUs
-	all, pageInfo, err := contactUs.GetContactUs(projectId, cursor, limit)
+	all, pageInfo, err := h.services.GetContactUs(r.Context(), &contactUs, projectId, cursor, limit)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -66,13 +66,14 @@ func GetContactUs(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func DeleteContactUsItem(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteContactUsItem(w http.ResponseWriter, r *http.Request) {
 	contactId := chi.URLParam(r, "contactId")
+	projectId := chi.URLParam(r, "projectId")
 
 	var contactUs services.ContactUs
 	contactUs.Id = contactId
 
-	err := contactUs.DeleteContactUsById()
+	err := h.services.DeleteContactUsById(r.Context(), &contactUs, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
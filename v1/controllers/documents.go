@@ -8,7 +8,7 @@ import (
 	"net/http"
 )
 
-func GetDocumentCoverByProjectId(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetDocumentCoverByProjectId(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 	cursor := r.URL.Query().Get("cursor")
 
@@ -17,7 +17,7 @@ func GetDocumentCoverByProjectId(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var documentCover services.DocumentCover
-	all, err := documentCover.GetDocumentCoverByProjectId(projectId, cursor)
+	all, err := h.services.GetDocumentCoverByProjectId(r.Context(), &documentCover, projectId, cursor)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -30,7 +30,7 @@ func GetDocumentCoverByProjectId(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func GetDocumentCoverByProjectIdClient(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetDocumentCoverByProjectIdClient(w http.ResponseWriter, r *http.Request) {
 	projectId := r.Context().Value(custom.ProjectId).(string)
 	cursor := r.URL.Query().Get("cursor")
 
@@ -39,7 +39,7 @@ func GetDocumentCoverByProjectIdClient(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var documentCover services.DocumentCover
-	all, err := documentCover.GetDocumentCoverByProjectId(projectId, cursor)
+	all, err := h.services.GetDocumentCoverByProjectId(r.Context(), &documentCover, projectId, cursor)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -52,7 +52,7 @@ func GetDocumentCoverByProjectIdClient(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func PostDocumentCover(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostDocumentCover(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 	userId := r.Context().Value(custom.UserID).(string)
 
@@ -62,7 +62,7 @@ func PostDocumentCover(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = coverDetails.PostDocumentCoverByProjectId(projectId, userId)
+	err = h.services.PostDocumentCoverByProjectId(r.Context(), &coverDetails, projectId, userId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -75,8 +75,9 @@ func PostDocumentCover(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func PatchDocumentCoverById(w http.ResponseWriter, r *http.Request) {
-	coverId := chi.URLParam(r, "cover")
+func (h *Handler) PatchDocumentCoverById(w http.ResponseWriter, r *http.Request) {
+	coverId := chi.URLParam(r, "coverId")
+	projectId := chi.URLParam(r, "projectId")
 
 	coverDetails, err := helper.DecodeJSON[services.DocumentCover](w, r, mb)
 	if err != nil {
@@ -85,7 +86,7 @@ func PatchDocumentCoverById(w http.ResponseWriter, r *http.Request) {
 	}
 
 	coverDetails.Id = coverId
-	err = coverDetails.PatchDocumentCoverById()
+	err = h.services.PatchDocumentCoverById(r.Context(), &coverDetails, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -98,14 +99,14 @@ func PatchDocumentCoverById(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func DeleteDocumentCoverById(w http.ResponseWriter, r *http.Request) {
-	coverId := chi.URLParam(r, "cover")
+func (h *Handler) DeleteDocumentCoverById(w http.ResponseWriter, r *http.Request) {
+	coverId := chi.URLParam(r, "coverId")
 	projectId := chi.URLParam(r, "projectId")
 
 	var documentCover services.DocumentCover
 	documentCover.Id = coverId
 
-	err := documentCover.DeleteDocumentCoverById(projectId)
+	err := h.services.DeleteDocumentCoverById(r.Context(), &documentCover, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -11,7 +11,7 @@ import (
 	"github.com/rohan031/adgytec-api/v1/services"
 )
 
-func GetAlbumsByProjectId(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAlbumsByProjectId(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 	cursor := r.URL.Query().Get("cursor")
 	limString := r.URL.Query().Get("limit")
@@ -26,7 +26,7 @@ func GetAlbumsByProjectId(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var albums services.Album
-	all, pageInfo, err := albums.GetAlbumsByProjectId(projectId, cursor, limit)
+	all, pageInfo, err := h.services.GetAlbumsByProjectId(r.Context(), &albums, projectId, cursor, limit)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -45,7 +45,7 @@ func GetAlbumsByProjectId(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func GetAlbumsByProjectIdClient(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAlbumsByProjectIdClient(w http.ResponseWriter, r *http.Request) {
 	projectId := r.Context().Value(custom.ProjectId).(string)
 	cursor := r.URL.Query().Get("cursor")
 	limString := r.URL.Query().Get("limit")
@@ -60,7 +60,7 @@ func GetAlbumsByProjectIdClient(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var albums services.Album
-	all, pageInfo, err := albums.GetAlbumsByProjectId(projectId, cursor, limit)
+	all, pageInfo, err := h.services.GetAlbumsByProjectId(r.Context(), &albums, projectId, cursor, limit)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -79,7 +79,7 @@ func GetAlbumsByProjectIdClient(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func PostAlbum(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostAlbum(w http.ResponseWriter, r *http.Request) {
 	maxSize := 10 << 20 // 10mb
 	err := helper.ParseMultipartForm(w, r, maxSize)
 	if err != nil {
@@ -117,7 +117,7 @@ func PostAlbum(w http.ResponseWriter, r *http.Request) {
 	var albumItem services.Album
 	albumItem.Name = name
 
-	err = albumItem.CreateAlbum(r, projectId, userId)
+	err = h.services.CreateAlbum(r.Context(), &albumItem, r, projectId, userId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -130,8 +130,9 @@ func PostAlbum(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusCreated, payload)
 }
 
-func PatchAlbumMetadataById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PatchAlbumMetadataById(w http.ResponseWriter, r *http.Request) {
 	albumId := chi.URLParam(r, "albumId")
+	projectId := chi.URLParam(r, "projectId")
 
 	albumDetails, err := helper.DecodeJSON[services.Album](w, r, mb)
 	if err != nil {
@@ -140,7 +141,7 @@ func PatchAlbumMetadataById(w http.ResponseWriter, r *http.Request) {
 	}
 
 	albumDetails.Id = albumId
-	err = albumDetails.PatchAlbumMetadataById()
+	err = h.services.PatchAlbumMetadataById(r.Context(), &albumDetails, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -153,7 +154,7 @@ func PatchAlbumMetadataById(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func PatchAlbumCoverById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PatchAlbumCoverById(w http.ResponseWriter, r *http.Request) {
 	maxSize := 10 << 20 // 10mb
 	err := helper.ParseMultipartForm(w, r, maxSize)
 	if err != nil {
@@ -177,7 +178,7 @@ func PatchAlbumCoverById(w http.ResponseWriter, r *http.Request) {
 	var album services.Album
 
 	album.Id = albumId
-	err = album.PatchAlbumCoverById(r, projectId)
+	err = h.services.PatchAlbumCoverById(r.Context(), &album, r, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -191,14 +192,14 @@ func PatchAlbumCoverById(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func DeleteAlbumById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteAlbumById(w http.ResponseWriter, r *http.Request) {
 	albumId := chi.URLParam(r, "albumId")
 	projectId := chi.URLParam(r, "projectId")
 
 	var album services.Album
 	album.Id = albumId
 
-	err := album.DeleteAlbumById(projectId)
+	err := h.services.DeleteAlbumById(r.Context(), &album, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -212,8 +213,9 @@ func DeleteAlbumById(w http.ResponseWriter, r *http.Request) {
 }
 
 // photos
-func GetPhotosByAlbumId(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetPhotosByAlbumId(w http.ResponseWriter, r *http.Request) {
 	albumId := chi.URLParam(r, "albumId")
+	projectId := projectIdFromRequest(r)
 	cursor := r.URL.Query().Get("cursor")
 	limString := r.URL.Query().Get("limit")
 
@@ -227,7 +229,7 @@ func GetPhotosByAlbumId(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var photos services.Photos
-	all, pageInfo, err := photos.GetPhotosByAlbumId(albumId, cursor, limit)
+	all, pageInfo, err := h.services.GetPhotosByAlbumId(r.Context(), &photos, albumId, projectId, cursor, limit)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -246,13 +248,14 @@ func GetPhotosByAlbumId(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func GetAlbumNameById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAlbumNameById(w http.ResponseWriter, r *http.Request) {
 	albumId := chi.URLParam(r, "albumId")
+	projectId := projectIdFromRequest(r)
 
 	var album services.Album
 	album.Id = albumId
 
-	name, err := album.GetAlbumNameById()
+	name, err := h.services.GetAlbumNameById(r.Context(), &album, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -264,7 +267,7 @@ func GetAlbumNameById(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func PostPhoto(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostPhoto(w http.ResponseWriter, r *http.Request) {
 	maxSize := 10 << 20 // 10mb
 	err := helper.ParseMultipartForm(w, r, maxSize)
 	if err != nil {
@@ -287,7 +290,7 @@ func PostPhoto(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var photoItem services.Photos
-	id, err := photoItem.PostPhotoByAlbumId(r, projectId, albumId, userId)
+	id, err := h.services.PostPhotoByAlbumId(r.Context(), &photoItem, r, projectId, albumId, userId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -305,7 +308,10 @@ func PostPhoto(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusCreated, payload)
 }
 
-func DeletePhotosById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeletePhotosById(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+	albumId := chi.URLParam(r, "albumId")
+
 	photoId, err := helper.DecodeJSON[services.PhotoDelete](w, r, mb)
 	if err != nil {
 		helper.HandleError(w, err)
@@ -313,7 +319,7 @@ func DeletePhotosById(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var photo services.Photos
-	err = photo.DeletePhotoById(photoId.Id)
+	err = h.services.DeletePhotoById(r.Context(), &photo, photoId.Id, albumId, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -1,13 +1,24 @@
 package controllers
 
 import (
-	"context"
+	"net/http"
 	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rohan031/adgytec-api/v1/custom"
+	"github.com/rohan031/adgytec-api/v1/services"
 )
 
 const mb = 1 << 20
 
-var ctx = context.Background()
+// Handler serves the v1 endpoints on top of the services
+type Handler struct {
+	services *services.Service
+}
+
+func New(s *services.Service) *Handler {
+	return &Handler{services: s}
+}
 
 func getNow() string {
 	now := time.Now()
@@ -16,3 +27,14 @@ func getNow() string {
 
 	return istTime.Format(time.RFC3339)
 }
+
+// projectIdFromRequest returns the project a request is scoped to, the url
+// parameter on dashboard routes and the client token's project on public ones
+func projectIdFromRequest(r *http.Request) string {
+	if projectId := chi.URLParam(r, "projectId"); projectId != "" {
+		return projectId
+	}
+
+	projectId, _ := r.Context().Value(custom.ProjectId).(string)
+	return projectId
+}
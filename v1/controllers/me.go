@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/rohan031/adgytec-api/helper"
+	"github.com/rohan031/adgytec-api/v1/custom"
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+// profile, memberships and capabilities of the signed in user
+func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
+	myId := r.Context().Value(custom.UserID).(string)
+
+	me, err := h.services.GetMe(r.Context(), myId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Data = me
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+// users update their own display name
+func (h *Handler) PatchMe(w http.ResponseWriter, r *http.Request) {
+	myId := r.Context().Value(custom.UserID).(string)
+
+	data, err := helper.DecodeJSON[services.MeUpdate](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	err = h.services.UpdateMe(r.Context(), myId, &data)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	me, err := h.services.GetMe(r.Context(), myId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = "Successfully updated your profile."
+	payload.Data = me
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/rohan031/adgytec-api/v1/services"
 )
 
-func PostNews(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostNews(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 	maxSize := 10 << 20
 	err := helper.ParseMultipartForm(w, r, maxSize)
@@ -50,7 +50,7 @@ func PostNews(w http.ResponseWriter, r *http.Request) {
 		Link:  link,
 	}
 
-	err = newsDetails.CreateNewsItem(r, projectId)
+	err = h.services.CreateNewsItem(r.Context(), newsDetails, r, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -63,11 +63,11 @@ func PostNews(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusCreated, payload)
 }
 
-func GetAllNewsClient(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAllNewsClient(w http.ResponseWriter, r *http.Request) {
 	projectId := r.Context().Value(custom.ProjectId).(string)
 
 	var news services.News
-	all, err := news.GetAllNewsByProjectId(projectId, 4)
+	all, err := h.services.GetAllNewsByProjectId(r.Context(), &news, projectId, 4)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -80,11 +80,11 @@ func GetAllNewsClient(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func GetNews(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetNews(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 
 	var news services.News
-	all, err := news.GetAllNewsByProjectId(projectId, 100)
+	all, err := h.services.GetAllNewsByProjectId(r.Context(), &news, projectId, 100)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -97,13 +97,14 @@ func GetNews(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func DeleteNews(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteNews(w http.ResponseWriter, r *http.Request) {
 	newsId := chi.URLParam(r, "newsId")
+	projectId := chi.URLParam(r, "projectId")
 
 	var news services.News
 	news.Id = newsId
 
-	err := news.DeleteNews()
+	err := h.services.DeleteNews(r.Context(), &news, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -116,7 +117,7 @@ func DeleteNews(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func DeleteNewsMultiple(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteNewsMultiple(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 	newsId, err := helper.DecodeJSON[services.NewsDelete](w, r, mb)
 	if err != nil {
@@ -124,7 +125,7 @@ func DeleteNewsMultiple(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = newsId.DeleteNewsMultiple(projectId)
+	err = h.services.DeleteNewsMultiple(r.Context(), &newsId, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -137,8 +138,9 @@ func DeleteNewsMultiple(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func PutNews(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PutNews(w http.ResponseWriter, r *http.Request) {
 	newsId := chi.URLParam(r, "newsId")
+	projectId := chi.URLParam(r, "projectId")
 
 	newsDetails, err := helper.DecodeJSON[services.NewsPut](w, r, mb)
 	if err != nil {
@@ -156,7 +158,7 @@ func PutNews(w http.ResponseWriter, r *http.Request) {
 	}
 
 	newsDetails.Id = newsId
-	err = newsDetails.NewsUpdate()
+	err = h.services.NewsUpdate(r.Context(), &newsDetails, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
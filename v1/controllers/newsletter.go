@@ -8,7 +8,7 @@ import (
 	"github.com/rohan031/adgytec-api/v1/services"
 )
 
-func GetNewslettersEmail(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetNewslettersEmail(w http.ResponseWriter, r *http.Request) {
 	var payload services.JSONResponse
 
 	payload.Error = false
@@ -17,7 +17,7 @@ func GetNewslettersEmail(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func PostNewsletterEmail(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostNewsletterEmail(w http.ResponseWriter, r *http.Request) {
 	payload, err := helper.DecodeJSON[services.Newsletter](w, r, mb)
 
 	if err != nil {
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rohan031/adgytec-api/helper"
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func (h *Handler) GetProjectOrigins(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+
+	var origin services.ProjectOrigin
+	all, err := h.services.GetOriginsByProjectId(r.Context(), &origin, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Data = all
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) PostProjectOrigin(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+
+	origin, err := helper.DecodeJSON[services.ProjectOrigin](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	err = h.services.AddOriginToProject(r.Context(), &origin, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = fmt.Sprintf("Successfully allowed origin: %s", origin.Origin)
+
+	helper.EncodeJSON(w, http.StatusCreated, payload)
+}
+
+func (h *Handler) DeleteProjectOrigin(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+
+	origin := services.ProjectOrigin{OriginId: chi.URLParam(r, "originId")}
+	err := h.services.DeleteOriginFromProject(r.Context(), &origin, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = fmt.Sprintf("Successfully removed origin: %s", origin.Origin)
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
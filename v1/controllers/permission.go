@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rohan031/adgytec-api/helper"
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+func (h *Handler) GetServicePermissions(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+	userId := chi.URLParam(r, "userId")
+
+	var permission services.ServicePermission
+	all, err := h.services.GetServicePermissionsByUserId(r.Context(), &permission, userId, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Data = all
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) PutServicePermission(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+	userId := chi.URLParam(r, "userId")
+
+	permission, err := helper.DecodeJSON[services.ServicePermission](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+	permission.Service = chi.URLParam(r, "service")
+
+	updated, err := h.services.PutServicePermission(r.Context(), &permission, userId, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = fmt.Sprintf("Successfully updated %v permissions of user-id: %v", updated.Service, userId)
+	payload.Data = updated
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) DeleteServicePermission(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+	userId := chi.URLParam(r, "userId")
+
+	permission := services.ServicePermission{Service: chi.URLParam(r, "service")}
+	err := h.services.DeleteServicePermission(r.Context(), &permission, userId, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = fmt.Sprintf("Successfully removed %v permissions of user-id: %v", permission.Service, userId)
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) LiftServicePermissions(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+	userId := chi.URLParam(r, "userId")
+
+	err := h.services.LiftServicePermissions(r.Context(), userId, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = fmt.Sprintf("Successfully restored the project role access of user-id: %v", userId)
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
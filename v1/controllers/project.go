@@ -11,7 +11,7 @@ import (
 	"github.com/rohan031/adgytec-api/v1/services"
 )
 
-func PostProject(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostProject(w http.ResponseWriter, r *http.Request) {
 	maxSize := 10 << 20
 	err := helper.ParseMultipartForm(w, r, maxSize)
 	if err != nil {
@@ -45,7 +45,7 @@ func PostProject(w http.ResponseWriter, r *http.Request) {
 	projectDetails := &services.Project{
 		ProjectName: projectName,
 	}
-	err = projectDetails.CreateProject(r)
+	err = h.services.CreateProject(r.Context(), projectDetails, r)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -54,11 +54,19 @@ func PostProject(w http.ResponseWriter, r *http.Request) {
 	var payload services.JSONResponse
 	payload.Error = false
 	payload.Message = fmt.Sprintf("Successfully created new project: %s", projectDetails.ProjectName)
+	// the client token can't be read back later
+	payload.Data = struct {
+		ProjectId   string `json:"projectId"`
+		ClientToken string `json:"clientToken"`
+	}{
+		ProjectId:   projectDetails.Id,
+		ClientToken: projectDetails.ClientToken,
+	}
 
 	helper.EncodeJSON(w, http.StatusCreated, payload)
 }
 
-func PostProjectAndServices(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostProjectAndServices(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 
 	s, err := helper.DecodeJSON[services.ProjectServiceMap](w, r, mb)
@@ -67,7 +75,7 @@ func PostProjectAndServices(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.CreateProjectServiceMap(projectId)
+	err = h.services.CreateProjectServiceMap(r.Context(), &s, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -80,7 +88,7 @@ func PostProjectAndServices(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusCreated, payload)
 }
 
-func PostProjectAndUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostProjectAndUser(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 
 	user, err := helper.DecodeJSON[services.ProjectUserMap](w, r, mb)
@@ -89,7 +97,7 @@ func PostProjectAndUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = user.CreateUserProjectMap(projectId)
+	err = h.services.CreateUserProjectMap(r.Context(), &user, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -102,10 +110,10 @@ func PostProjectAndUser(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusCreated, payload)
 }
 
-func GetAllProjects(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAllProjects(w http.ResponseWriter, r *http.Request) {
 	var projects services.Project
 
-	all, err := projects.GetAllProjects()
+	all, err := h.services.GetAllProjects(r.Context(), &projects)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -119,12 +127,12 @@ func GetAllProjects(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func GetProjectById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetProjectById(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 	var project services.Project
 	project.Id = projectId
 
-	p, err := project.GetProjectById()
+	p, err := h.services.GetProjectById(r.Context(), &project)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -138,12 +146,12 @@ func GetProjectById(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func DeleteProjectById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteProjectById(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 	var project services.Project
 	project.Id = projectId
 
-	err := project.DeleteProjectById()
+	err := h.services.DeleteProjectById(r.Context(), &project)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -156,7 +164,29 @@ func DeleteProjectById(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func DeleteProjectAndUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PatchProjectAndUser(w http.ResponseWriter, r *http.Request) {
+	projectId := chi.URLParam(r, "projectId")
+
+	user, err := helper.DecodeJSON[services.ProjectUserMap](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	err = h.services.PatchUserProjectMap(r.Context(), &user, projectId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = fmt.Sprintf("Successfully changed role of user-id: %v to %v", user.UserId, user.Role)
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) DeleteProjectAndUser(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 
 	user, err := helper.DecodeJSON[services.ProjectUserMap](w, r, mb)
@@ -165,7 +195,7 @@ func DeleteProjectAndUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = user.DeleteUserProjectMap(projectId)
+	err = h.services.DeleteUserProjectMap(r.Context(), &user, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -179,7 +209,7 @@ func DeleteProjectAndUser(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func DeleteProjectAndService(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteProjectAndService(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 
 	service, err := helper.DecodeJSON[services.ProjectServiceMap](w, r, mb)
@@ -188,7 +218,7 @@ func DeleteProjectAndService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = service.DeleteProjectServiceMap(projectId)
+	err = h.services.DeleteProjectServiceMap(r.Context(), &service, projectId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -201,10 +231,10 @@ func DeleteProjectAndService(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func GetAllServices(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAllServices(w http.ResponseWriter, r *http.Request) {
 	var p services.Project
 
-	all, err := p.GetAllServices()
+	all, err := h.services.GetAllServices(r.Context(), &p)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -217,7 +247,7 @@ func GetAllServices(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func GetProjectsByUserId(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetProjectsByUserId(w http.ResponseWriter, r *http.Request) {
 	userId := r.Context().Value(custom.UserID).(string)
 	userRole := r.Context().Value(custom.UserRole).(string)
 	var project services.Project
@@ -225,9 +255,9 @@ func GetProjectsByUserId(w http.ResponseWriter, r *http.Request) {
 	var err error
 
 	if userRole != "user" {
-		all, err = project.GetAllProjects()
+		all, err = h.services.GetAllProjects(r.Context(), &project)
 	} else {
-		all, err = project.GetProjectsByUserId(userId)
+		all, err = h.services.GetProjectsByUserId(r.Context(), &project, userId)
 	}
 
 	if err != nil {
@@ -242,12 +272,12 @@ func GetProjectsByUserId(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func GetMetadataByProjectId(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetMetadataByProjectId(w http.ResponseWriter, r *http.Request) {
 	projectId := chi.URLParam(r, "projectId")
 	var project services.Project
 	project.Id = projectId
 
-	data, err := project.GetMetadataByProjectId()
+	data, err := h.services.GetMetadataByProjectId(r.Context(), &project)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
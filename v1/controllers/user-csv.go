@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rohan031/adgytec-api/helper"
+	"github.com/rohan031/adgytec-api/v1/custom"
+	"github.com/rohan031/adgytec-api/v1/services"
+)
+
+// creates the users of a csv file, with ?dryRun=true the file is only
+// validated
+func (h *Handler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	myId := r.Context().Value(custom.UserID).(string)
+	myRole := r.Context().Value(custom.UserRole).(string)
+
+	dryRun := false
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			message := "Invalid dryRun, expected true or false."
+			helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message})
+			return
+		}
+	}
+
+	maxSize := 1 << 20 // 1mb
+	err := helper.ParseMultipartForm(w, r, maxSize)
+	if err != nil {
+		return
+	}
+
+	requiredFileFields := "file"
+	if _, ok := r.MultipartForm.File[requiredFileFields]; !ok {
+		message := fmt.Sprintf("Missing required file: %s", requiredFileFields)
+		helper.HandleError(w, &custom.MalformedRequest{
+			Status:  http.StatusBadRequest,
+			Message: message,
+		})
+		return
+	}
+
+	file, _, err := r.FormFile(requiredFileFields)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error opening uploaded file", "error", err)
+		helper.HandleError(w, err)
+		return
+	}
+	defer file.Close()
+
+	report, err := h.services.ImportUsers(r.Context(), file, myRole, myId, dryRun)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = fmt.Sprintf("Imported %d of %d users.", report.Created, report.Total)
+	if dryRun {
+		payload.Message = fmt.Sprintf("%d of %d users can be imported.", report.Valid, report.Total)
+	}
+	payload.Data = report
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+// every user with their project assignments as a csv file
+func (h *Handler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.services.ExportUsers(r.Context())
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	filename := fmt.Sprintf("users-%s.csv", time.Now().UTC().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	err = services.WriteUsersCSV(w, users)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error writing users csv", "error", err)
+	}
+}
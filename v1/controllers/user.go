@@ -1,21 +1,21 @@
 package controllers
 
 import (
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
-
-	"firebase.google.com/go/v4/auth"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/rohan031/adgytec-api/firebase"
 	"github.com/rohan031/adgytec-api/helper"
+	"github.com/rohan031/adgytec-api/identity"
 	"github.com/rohan031/adgytec-api/v1/custom"
 	"github.com/rohan031/adgytec-api/v1/services"
 	"github.com/rohan031/adgytec-api/v1/validation"
 )
 
 // creating new user
-func PostUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostUser(w http.ResponseWriter, r *http.Request) {
 	myRole := r.Context().Value(custom.UserRole).(string)
 
 	// decoding request body
@@ -41,42 +41,31 @@ func PostUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// creating user and generating password
-	password, err := data.CreateUser()
+	// creating user with an invite to set their password
+	myId := r.Context().Value(custom.UserID).(string)
+	invite, err := h.services.InviteUser(r.Context(), &data, myId)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
 	}
 
-	var userDetails services.UserCreationDetails
-	userDetails.Email = data.Email
-	userDetails.Name = data.Name
-	userDetails.Password = password
-
-	// path to email template
-	templatePath := "./assets/template.html"
-	to := []string{
-		data.Email, // user to send email
-	}
-
-	subject := "Adgytec account creation"
-	// sending user credentials via email
-	err = services.SendEmail(userDetails, templatePath, to, subject)
+	err = h.services.SendInvite(r.Context(), invite)
 	var payload services.JSONResponse
 
 	payload.Error = false
-	payload.Message = "Successfully created user account."
+	payload.Message = "Successfully invited user."
+	payload.Data = data
 
 	status := http.StatusCreated
 	if err != nil {
-		payload.Message = "User account created. But can't send user credentials"
+		payload.Message = "User account created. But can't send the invite, try resending it."
 		status = http.StatusPartialContent
 	}
 	helper.EncodeJSON(w, status, payload)
 }
 
 // update user details
-func PatchUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PatchUser(w http.ResponseWriter, r *http.Request) {
 	myId := r.Context().Value(custom.UserID).(string)
 	myRole := r.Context().Value(custom.UserRole).(string)
 	userId := chi.URLParam(r, "id")
@@ -86,21 +75,21 @@ func PatchUser(w http.ResponseWriter, r *http.Request) {
 	payload.Error = false
 	payload.Message = "Successfully updated user details"
 
-	// fetching user from firebase
-	u, err := firebase.FirebaseClient.GetUser(ctx, userId)
+	// fetching user from identity provider
+	u, err := h.services.Identity.GetUser(r.Context(), userId)
 	if err != nil {
-		if auth.IsUserNotFound(err) {
+		if errors.Is(err, identity.ErrUserNotFound) {
 			message := "No user found."
 			err := &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
 			helper.HandleError(w, err)
 			return
 		}
 
-		log.Printf("Error getting user from firebase:%v\n", err)
+		slog.ErrorContext(r.Context(), "Error getting user from identity provider", "error", err)
 		helper.HandleError(w, err)
 		return
 	}
-	userRole := u.CustomClaims["role"]
+	userRole := u.Claims["role"]
 
 	// decoding req body
 	data, err := helper.DecodeJSON[services.User](w, r, mb)
@@ -120,7 +109,7 @@ func PatchUser(w http.ResponseWriter, r *http.Request) {
 
 	// filling any missing data for update user function
 	if data.Name == "" {
-		data.Name = u.DisplayName
+		data.Name = u.Name
 	}
 	if data.Role == "" && userRole != nil {
 		data.Role = userRole.(string)
@@ -128,7 +117,7 @@ func PatchUser(w http.ResponseWriter, r *http.Request) {
 
 	// super admins can perform any action
 	if myRole == "super_admin" {
-		err = data.UpdateUser()
+		err = h.services.UpdateUser(r.Context(), &data)
 		if err != nil {
 			helper.HandleError(w, err)
 			return
@@ -150,7 +139,7 @@ func PatchUser(w http.ResponseWriter, r *http.Request) {
 	// in middleware we checked if they are trying to update their account
 	// myid == userid because for role admin
 	if myRole == "user" || myId == userId {
-		err := data.UpdateUserName()
+		err := h.services.UpdateUserName(r.Context(), &data)
 		if err != nil {
 			helper.HandleError(w, err)
 			return
@@ -169,7 +158,7 @@ func PatchUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = data.UpdateUser()
+	err = h.services.UpdateUser(r.Context(), &data)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -177,14 +166,20 @@ func PatchUser(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func DeleteUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	userToDeleteId := chi.URLParam(r, "id")
 
 	userData := services.User{
 		UserId: userToDeleteId,
 	}
 
-	err := userData.DeleteUser()
+	deletion, err := helper.DecodeJSON[services.UserDeletion](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	err = h.services.DeleteUser(r.Context(), &userData, &deletion)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -197,14 +192,14 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func GetUserById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetUserById(w http.ResponseWriter, r *http.Request) {
 	userId := chi.URLParam(r, "id")
 
 	userData := services.User{
 		UserId: userId,
 	}
 
-	user, err := userData.GetUserById()
+	user, err := h.services.GetUserById(r.Context(), &userData)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -218,19 +213,205 @@ func GetUserById(w http.ResponseWriter, r *http.Request) {
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
 
-func GetAllUsers(w http.ResponseWriter, r *http.Request) {
-	requiredRole := r.URL.Query().Get("role")
-	var users services.User
+func (h *Handler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit > 50 || limit < 1 {
+		limit = 20 // default limit
+	}
+
+	filter := services.UserFilter{
+		Search:    query.Get("search"),
+		Role:      query.Get("role"),
+		ProjectId: query.Get("projectId"),
+		Sort:      query.Get("sort"),
+		Cursor:    query.Get("cursor"),
+		Limit:     limit,
+	}
+
+	all, pageInfo, err := h.services.GetUsersPage(r.Context(), &filter)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Data = struct {
+		Users    *[]services.User       `json:"users"`
+		PageInfo *services.UserPageInfo `json:"pageInfo"`
+	}{
+		Users:    all,
+		PageInfo: pageInfo,
+	}
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+// managedUser fetches the user an invite or suspension request is about and
+// checks the caller may manage users of that role
+func (h *Handler) managedUser(w http.ResponseWriter, r *http.Request) (*services.User, bool) {
+	myRole := r.Context().Value(custom.UserRole).(string)
+
+	userData := services.User{
+		UserId: chi.URLParam(r, "id"),
+	}
+
+	user, err := h.services.GetUserById(r.Context(), &userData)
+	if err != nil {
+		helper.HandleError(w, err)
+		return nil, false
+	}
+
+	if user.UserId == r.Context().Value(custom.UserID).(string) || !validation.AuthorizeRole(myRole, user.Role) {
+		message := "Insufficient privileges to perform requested action."
+		helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusForbidden, Message: message})
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (h *Handler) ResendInvite(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.managedUser(w, r)
+	if !ok {
+		return
+	}
+
+	myId := r.Context().Value(custom.UserID).(string)
+	invite, err := h.services.ResendInvite(r.Context(), user, myId)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	err = h.services.SendInvite(r.Context(), invite)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = "Successfully sent a new invite, earlier invites no longer work."
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.managedUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.services.RevokeInvite(r.Context(), user)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = "Successfully revoked the invite."
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+// public route, the token in the body authenticates the request
+func (h *Handler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	accept, err := helper.DecodeJSON[services.InviteAccept](w, r, mb)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	err = h.services.AcceptInvite(r.Context(), &accept)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = "Invite accepted, you can now sign in with your password."
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) GetUserConsistency(w http.ResponseWriter, r *http.Request) {
+	problems, err := h.services.CheckUserConsistency(r.Context())
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Data = problems
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) RepairUserConsistency(w http.ResponseWriter, r *http.Request) {
+	problems, err := h.services.ReconcileUsers(r.Context())
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Data = problems
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.managedUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.services.SuspendUser(r.Context(), user)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
 
-	var all *[]services.User
-	var err error
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = "The user has been suspended."
 
-	if requiredRole != "" && requiredRole == validation.User {
-		all, err = users.GetAllUsersByRole(requiredRole)
-	} else {
-		all, err = users.GetAllUsers()
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.managedUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.services.ReactivateUser(r.Context(), user)
+	if err != nil {
+		helper.HandleError(w, err)
+		return
+	}
+
+	var payload services.JSONResponse
+	payload.Error = false
+	payload.Message = "The user has been reactivated."
+
+	helper.EncodeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.managedUser(w, r)
+	if !ok {
+		return
 	}
 
+	err := h.services.RevokeUserSessions(r.Context(), user)
 	if err != nil {
 		helper.HandleError(w, err)
 		return
@@ -238,7 +419,7 @@ func GetAllUsers(w http.ResponseWriter, r *http.Request) {
 
 	var payload services.JSONResponse
 	payload.Error = false
-	payload.Data = all
+	payload.Message = "The user has been signed out of every session."
 
 	helper.EncodeJSON(w, http.StatusOK, payload)
 }
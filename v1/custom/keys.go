@@ -5,3 +5,4 @@ type ContextKey string
 const UserID ContextKey = "uid"
 const UserRole ContextKey = "role"
 const ProjectId ContextKey = "projectId"
+const ProjectRole ContextKey = "projectRole"
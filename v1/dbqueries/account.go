@@ -0,0 +1,56 @@
+package dbqueries
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// password reset and verification emails, limited per email address
+
+// serializes the requests of an email until the transaction ends, the count
+// of RecordAccountEmailRequest would let concurrent requests through otherwise
+const LockAccountEmailRequests = `
+	SELECT pg_advisory_xact_lock(hashtext(@email))
+`
+
+func LockAccountEmailRequestsArgs(email string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"email": email,
+	}
+}
+
+// record a requested email when fewer than @limit were requested since @since,
+// no row is returned when the limit is reached. Run after
+// LockAccountEmailRequests in the same transaction
+const RecordAccountEmailRequest = `
+	INSERT INTO account_email_request (email, action, created_at)
+	SELECT @email, @action, @now
+	WHERE (
+		SELECT count(*) FROM account_email_request
+		WHERE email = @email AND action = @action AND created_at > @since
+	) < @limit
+	RETURNING email
+`
+
+func RecordAccountEmailRequestArgs(email, action string, now, since time.Time, limit int) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"email":  email,
+		"action": action,
+		"now":    now,
+		"since":  since,
+		"limit":  limit,
+	}
+}
+
+// requests older than the window are only kept for counting
+const DeleteAccountEmailRequests = `
+	DELETE FROM account_email_request
+	WHERE created_at < @since
+`
+
+func DeleteAccountEmailRequestsArgs(since time.Time) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"since": since,
+	}
+}
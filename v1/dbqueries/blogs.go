@@ -2,11 +2,14 @@ package dbqueries
 
 import "github.com/jackc/pgx/v5"
 
+// the category has to belong to the same project, otherwise nothing is inserted
 const CreateBlogItem = `
 	INSERT INTO blogs 
 	(blog_id, user_id, project_id, title, cover_image, short_text, content, author, category_id)
-	VALUES 
-	(@blogId, @userId, @projectId, @title, @cover, @summary, @content, @author, @categoryId)
+	SELECT @blogId, @userId, project_id, @title, @cover, @summary, @content, @author, category_id
+	FROM category
+	WHERE category_id = @categoryId
+	AND project_id = @projectId
 `
 
 func CreateBlogItemArgs(
@@ -84,38 +87,47 @@ const GetBlogById = `
 	FROM blogs b
 	INNER JOIN category c
 	ON c.category_id = b.category_id
-	WHERE blog_id = @blogId;
+	WHERE b.blog_id = @blogId
+	AND b.project_id = @projectId;
 `
 
-func GetBlogsByIdArgs(blogId string) pgx.NamedArgs {
+func GetBlogsByIdArgs(blogId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"blogId": blogId,
+		"blogId":    blogId,
+		"projectId": projectId,
 	}
 }
 
 const PatchBlogMetadataById = `
-	UPDATE blogs 
-	SET title=@title, short_text=@summary, category_id=@categoryId
-	WHERE blog_id=@blogId
+	UPDATE blogs b
+	SET title=@title, short_text=@summary, category_id=c.category_id
+	FROM category c
+	WHERE b.blog_id=@blogId
+	AND b.project_id=@projectId
+	AND c.category_id=@categoryId
+	AND c.project_id=@projectId
 `
 
-func PatchBlogMetadataByIdArgs(title, summary, blogId, categoryId string) pgx.NamedArgs {
+func PatchBlogMetadataByIdArgs(title, summary, blogId, categoryId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
 		"title":      title,
 		"summary":    summary,
 		"blogId":     blogId,
 		"categoryId": categoryId,
+		"projectId":  projectId,
 	}
 }
 
 const DeleteBlogById = `
 	DELETE FROM blogs
 	WHERE blog_id=@blogId
+	AND project_id=@projectId
 `
 
-func DeleteBlogByIdArgs(blogId string) pgx.NamedArgs {
+func DeleteBlogByIdArgs(blogId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"blogId": blogId,
+		"blogId":    blogId,
+		"projectId": projectId,
 	}
 }
 
@@ -124,19 +136,22 @@ const PatchBlogCover = `
 		SELECT cover_image as image
 		FROM blogs 
 		WHERE blog_id = @blogId
+		AND project_id = @projectId
 	)
 	UPDATE blogs
 	SET cover_image  = @cover
 	WHERE blog_id = @blogId
+	AND project_id = @projectId
 	RETURNING (
 		SELECT image FROM cover
 	)
 `
 
-func PatchBlogCoverArgs(blogId, cover string) pgx.NamedArgs {
+func PatchBlogCoverArgs(blogId, cover, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"blogId": blogId,
-		"cover":  cover,
+		"blogId":    blogId,
+		"cover":     cover,
+		"projectId": projectId,
 	}
 }
 
@@ -144,11 +159,13 @@ const PatchBlogContent = `
 	UPDATE blogs
 	SET content = @content
 	WHERE blog_id = @blogId
+	AND project_id = @projectId
 `
 
-func PatchBlogContentArgs(blogId, content string) pgx.NamedArgs {
+func PatchBlogContentArgs(blogId, content, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"blogId":  blogId,
-		"content": content,
+		"blogId":    blogId,
+		"content":   content,
+		"projectId": projectId,
 	}
 }
@@ -2,9 +2,13 @@ package dbqueries
 
 import "github.com/jackc/pgx/v5"
 
+// the parent has to belong to the same project, otherwise nothing is inserted
 const PostCategoryByProjectId = `
 	INSERT INTO category (parent_id, project_id, category_name)
-	VALUES (@parentId, @projectId, @categoryName)
+	SELECT category_id, project_id, @categoryName
+	FROM category
+	WHERE category_id = @parentId
+	AND project_id = @projectId
 	RETURNING category_id
 `
 
@@ -20,12 +24,14 @@ const PatchCategoryById = `
 	UPDATE category
 	SET category_name = @categoryName
 	WHERE category_id = @categoryId
+	AND project_id = @projectId
 `
 
-func PatchCategoryByIdArgs(categoryName, categoryId string) pgx.NamedArgs {
+func PatchCategoryByIdArgs(categoryName, categoryId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
 		"categoryName": categoryName,
 		"categoryId":   categoryId,
+		"projectId":    projectId,
 	}
 }
 
@@ -92,10 +98,12 @@ func GetCategoryByProjectIdArgs(projectId string) pgx.NamedArgs {
 const DeleteCategoryById = `
 	DELETE FROM category 
 	WHERE category_id = @categoryId
+	AND project_id = @projectId
 `
 
-func DeleteCategoryByIdArgs(categoryId string) pgx.NamedArgs {
+func DeleteCategoryByIdArgs(categoryId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
 		"categoryId": categoryId,
+		"projectId":  projectId,
 	}
 }
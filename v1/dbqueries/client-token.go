@@ -0,0 +1,113 @@
+package dbqueries
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// client tokens are looked up by the sha256 hash of the token, the token
+// itself is never stored
+
+const GetClientTokenByHash = `
+	SELECT c.project_id, c.scopes, c.expires_at, c.revoked_at,
+		ARRAY(
+			SELECT o.origin FROM project_origin o WHERE o.project_id = c.project_id
+		)::varchar[] AS origins
+	FROM client_token c
+	WHERE c.token_hash = @tokenHash
+`
+
+func GetClientTokenByHashArgs(tokenHash string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"tokenHash": tokenHash,
+	}
+}
+
+const CreateClientToken = `
+	INSERT INTO client_token (project_id, token_hash, label, scopes, expires_at)
+	VALUES (@projectId, @tokenHash, @label, @scopes, @expiresAt)
+	RETURNING token_id, label, scopes, created_at, expires_at, revoked_at
+`
+
+func CreateClientTokenArgs(projectId, tokenHash, label string, scopes []string, expiresAt *time.Time) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"projectId": projectId,
+		"tokenHash": tokenHash,
+		"label":     label,
+		"scopes":    scopes,
+		"expiresAt": expiresAt,
+	}
+}
+
+const GetClientTokensByProjectId = `
+	SELECT token_id, label, scopes, created_at, expires_at, revoked_at
+	FROM client_token
+	WHERE project_id = @projectId
+	ORDER BY created_at DESC
+`
+
+func GetClientTokensByProjectIdArgs(projectId string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"projectId": projectId,
+	}
+}
+
+// revoked tokens can't be changed
+const PatchClientTokenById = `
+	UPDATE client_token
+	SET label = @label, expires_at = @expiresAt
+	WHERE token_id = @tokenId
+	AND project_id = @projectId
+	AND revoked_at IS NULL
+	RETURNING token_id, label, scopes, created_at, expires_at, revoked_at
+`
+
+func PatchClientTokenByIdArgs(tokenId, projectId, label string, expiresAt *time.Time) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"tokenId":   tokenId,
+		"projectId": projectId,
+		"label":     label,
+		"expiresAt": expiresAt,
+	}
+}
+
+const RevokeClientTokenById = `
+	UPDATE client_token
+	SET revoked_at = now()
+	WHERE token_id = @tokenId
+	AND project_id = @projectId
+	AND revoked_at IS NULL
+`
+
+func RevokeClientTokenByIdArgs(tokenId, projectId string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"tokenId":   tokenId,
+		"projectId": projectId,
+	}
+}
+
+// revokes the token and issues a replacement with the same label, scopes and
+// expiry in one statement
+const RotateClientTokenById = `
+	WITH revoked AS (
+		UPDATE client_token
+		SET revoked_at = now()
+		WHERE token_id = @tokenId
+		AND project_id = @projectId
+		AND revoked_at IS NULL
+		RETURNING project_id, label, scopes, expires_at
+	)
+	INSERT INTO client_token (project_id, token_hash, label, scopes, expires_at)
+	SELECT project_id, @tokenHash, label, scopes, expires_at
+	FROM revoked
+	RETURNING token_id, label, scopes, created_at, expires_at, revoked_at
+`
+
+func RotateClientTokenByIdArgs(tokenId, projectId, tokenHash string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"tokenId":   tokenId,
+		"projectId": projectId,
+		"tokenHash": tokenHash,
+	}
+}
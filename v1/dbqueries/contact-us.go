@@ -38,10 +38,12 @@ const DeleteContactUsById = `
 	DELETE FROM contact_us
 	WHERE
 	id = @contactId
+	AND project_id = @projectId
 `
 
-func DeleteContactUsByIdArgs(contactId string) pgx.NamedArgs {
+func DeleteContactUsByIdArgs(contactId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
 		"contactId": contactId,
+		"projectId": projectId,
 	}
 }
@@ -20,7 +20,7 @@ const GetDocumentCoverByProjectId = `
 	SELECT cover_id, name, created_at
 	FROM document_cover
 	WHERE 
-	projectId = @projectId
+	project_id = @projectId
 	AND 
 	created_at < @createdAt
 	ORDER BY created_at DESC
@@ -38,11 +38,13 @@ const DeleteDocumentCoverBytId = `
 	Delete FROM document_cover
 	where
 	cover_id = @coverId
+	AND project_id = @projectId
 `
 
-func DeleteDocumentCoverByIdArgs(coverId string) pgx.NamedArgs {
+func DeleteDocumentCoverByIdArgs(coverId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"coverId": coverId,
+		"coverId":   coverId,
+		"projectId": projectId,
 	}
 }
 
@@ -50,11 +52,13 @@ const PatchDocumentCoverById = `
 	UPDATE document_cover
 	SET name = @name
 	Where cover_id = @coverId
+	AND project_id = @projectId
 `
 
-func PatchDocumentCoverByIdArgs(coverId, name string) pgx.NamedArgs {
+func PatchDocumentCoverByIdArgs(coverId, name, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"coverId": coverId,
-		"name":    name,
+		"coverId":   coverId,
+		"name":      name,
+		"projectId": projectId,
 	}
 }
@@ -43,11 +43,13 @@ const DeleteAlbumById = `
 	DELETE FROM album
 	WHERE
 	album_id = @albumId
+	AND project_id = @projectId
 `
 
-func DeleteAlbumByIdArgs(albumId string) pgx.NamedArgs {
+func DeleteAlbumByIdArgs(albumId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"albumId": albumId,
+		"albumId":   albumId,
+		"projectId": projectId,
 	}
 }
 
@@ -56,12 +58,14 @@ const PatchAlbumMetadataById = `
 	SET name = @name
 	WHERE
 	album_id = @albumId
+	AND project_id = @projectId
 `
 
-func PatchAlbumMetadataByIdArgs(albumId, name string) pgx.NamedArgs {
+func PatchAlbumMetadataByIdArgs(albumId, name, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"albumId": albumId,
-		"name":    name,
+		"albumId":   albumId,
+		"name":      name,
+		"projectId": projectId,
 	}
 }
 
@@ -70,35 +74,41 @@ const PatchAlbumCoverById = `
 		SELECT cover as image
 		FROM album 
 		WHERE album_id = @albumId
+		AND project_id = @projectId
 	)
 	UPDATE album
 	SET cover  = @cover
 	WHERE album_id = @albumId
+	AND project_id = @projectId
 	RETURNING (
 		SELECT image FROM cover
 	)
 `
 
-func PatchAlbumCoverByIdArgs(albumId, cover string) pgx.NamedArgs {
+func PatchAlbumCoverByIdArgs(albumId, cover, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"albumId": albumId,
-		"cover":   cover,
+		"albumId":   albumId,
+		"cover":     cover,
+		"projectId": projectId,
 	}
 }
 
-// photos
+// photos, they have no project of their own and are scoped through their album
 const PostPhotoByAlbumId = `
 	INSERT INTO photos (photo_id, album_id, path, user_id)
-	VALUES
-	(@photoId, @albumId, @path, @userId)
+	SELECT @photoId, album_id, @path, @userId
+	FROM album
+	WHERE album_id = @albumId
+	AND project_id = @projectId
 `
 
-func PostPhotoByAlbumIdArgs(photoId, albumId, path, userId string) pgx.NamedArgs {
+func PostPhotoByAlbumIdArgs(photoId, albumId, path, userId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"photoId": photoId,
-		"albumId": albumId,
-		"path":    path,
-		"userId":  userId,
+		"photoId":   photoId,
+		"albumId":   albumId,
+		"path":      path,
+		"userId":    userId,
+		"projectId": projectId,
 	}
 }
 
@@ -106,42 +116,54 @@ const GetAlbumNameById = `
 	SELECT name
 	FROM album
 	WHERE album_id = @albumId
+	AND project_id = @projectId
 `
 
-func GetAlbumNameByIdArgs(albumId string) pgx.NamedArgs {
+func GetAlbumNameByIdArgs(albumId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"albumId": albumId,
+		"albumId":   albumId,
+		"projectId": projectId,
 	}
 }
 
 const GetPhotosByAlbumId = `
-	SELECT photo_id, path, created_at
-	FROM photos
+	SELECT p.photo_id, p.path, p.created_at
+	FROM photos p
+	INNER JOIN album a
+	ON a.album_id = p.album_id
 	WHERE
-	album_id = @albumId
-	AND created_at < @createdAt
-	ORDER BY created_at DESC
+	p.album_id = @albumId
+	AND a.project_id = @projectId
+	AND p.created_at < @createdAt
+	ORDER BY p.created_at DESC
 	LIMIT @limit
 `
 
-func GetPhotosByAlbumIdArgs(albumId, createdAt string, limit int) pgx.NamedArgs {
+func GetPhotosByAlbumIdArgs(albumId, projectId, createdAt string, limit int) pgx.NamedArgs {
 	return pgx.NamedArgs{
 		"albumId":   albumId,
+		"projectId": projectId,
 		"createdAt": createdAt,
 		"limit":     limit,
 	}
 }
 
 const DeletePhotosById = `
-	DELETE FROM photos
+	DELETE FROM photos p
+	USING album a
 	WHERE 
-	photo_id = ANY(@photoIds)
-	RETURNING path
+	p.album_id = a.album_id
+	AND p.album_id = @albumId
+	AND a.project_id = @projectId
+	AND p.photo_id = ANY(@photoIds)
+	RETURNING p.path
 `
 
-func DeletePhotosByIdArgs(photoIds []string) pgx.NamedArgs {
+func DeletePhotosByIdArgs(photoIds []string, albumId, projectId string) pgx.NamedArgs {
 
 	return pgx.NamedArgs{
-		"photoIds": photoIds,
+		"photoIds":  photoIds,
+		"albumId":   albumId,
+		"projectId": projectId,
 	}
 }
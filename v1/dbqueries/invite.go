@@ -0,0 +1,122 @@
+package dbqueries
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// invites are looked up by the sha256 hash of the token, the token itself is
+// only sent to the invitee
+
+// add an invited user along with the first invite
+const CreateInvitedUser = `
+	WITH inserted_user AS (
+		INSERT INTO users (user_id, name, email, role, invite_status)
+		VALUES (@userId, @name, @email, @role, 'pending')
+		RETURNING user_id
+	)
+	INSERT INTO user_invite (user_id, token_hash, invited_by, expires_at)
+	SELECT user_id, @tokenHash, NULLIF(@invitedBy, ''), @expiresAt
+	FROM inserted_user
+`
+
+func CreateInvitedUserArgs(userId, email, name, role, tokenHash, invitedBy string, expiresAt time.Time) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId":    userId,
+		"email":     email,
+		"name":      name,
+		"role":      role,
+		"tokenHash": tokenHash,
+		"invitedBy": invitedBy,
+		"expiresAt": expiresAt,
+	}
+}
+
+// replace the open invites of a user that hasn't accepted yet with a new one
+const ReissueInvite = `
+	WITH revoked AS (
+		UPDATE user_invite
+		SET revoked_at = now()
+		WHERE user_id = @userId AND accepted_at IS NULL AND revoked_at IS NULL
+	), pending_user AS (
+		UPDATE users
+		SET invite_status = 'pending'
+		WHERE user_id = @userId AND invite_status <> 'accepted'
+		RETURNING user_id
+	)
+	INSERT INTO user_invite (user_id, token_hash, invited_by, expires_at)
+	SELECT user_id, @tokenHash, NULLIF(@invitedBy, ''), @expiresAt
+	FROM pending_user
+`
+
+func ReissueInviteArgs(userId, tokenHash, invitedBy string, expiresAt time.Time) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId":    userId,
+		"tokenHash": tokenHash,
+		"invitedBy": invitedBy,
+		"expiresAt": expiresAt,
+	}
+}
+
+const RevokeInvite = `
+	WITH revoked AS (
+		UPDATE user_invite
+		SET revoked_at = now()
+		WHERE user_id = @userId AND accepted_at IS NULL AND revoked_at IS NULL
+	)
+	UPDATE users
+	SET invite_status = 'revoked'
+	WHERE user_id = @userId AND invite_status = 'pending'
+`
+
+func RevokeInviteArgs(userId string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId": userId,
+	}
+}
+
+const GetInviteByHash = `
+	SELECT invite_id, user_id, expires_at, accepted_at, revoked_at
+	FROM user_invite
+	WHERE token_hash = @tokenHash
+`
+
+func GetInviteByHashArgs(tokenHash string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"tokenHash": tokenHash,
+	}
+}
+
+// claiming is what makes an invite single use, only one request can set
+// accepted_at
+const ClaimInvite = `
+	WITH claimed AS (
+		UPDATE user_invite
+		SET accepted_at = now()
+		WHERE invite_id = @inviteId AND accepted_at IS NULL AND revoked_at IS NULL
+		RETURNING user_id
+	)
+	UPDATE users
+	SET invite_status = 'accepted'
+	WHERE user_id IN (SELECT user_id FROM claimed)
+`
+
+// undo a claim when the password couldn't be set
+const ReleaseInvite = `
+	WITH released AS (
+		UPDATE user_invite
+		SET accepted_at = NULL
+		WHERE invite_id = @inviteId
+		RETURNING user_id
+	)
+	UPDATE users
+	SET invite_status = 'pending'
+	WHERE user_id IN (SELECT user_id FROM released)
+`
+
+func InviteIdArgs(inviteId string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"inviteId": inviteId,
+	}
+}
@@ -0,0 +1,36 @@
+package dbqueries
+
+import "github.com/jackc/pgx/v5"
+
+// every project of a user with its enabled services and the service
+// permissions of the user in it
+const GetMembershipsByUserId = `
+	SELECT
+		p.project_id,
+		p.project_name,
+		up.role,
+		up.permissions_restricted AS restricted,
+		coalesce((
+			SELECT json_agg(json_build_object('serviceId', s.service_id, 'serviceName', s.service_name, 'icon', s.icon) ORDER BY s.service_name)
+			FROM project_to_service ps
+			INNER JOIN services s
+			ON s.service_id = ps.service_id
+			WHERE ps.project_id = p.project_id
+		), '[]'::json) AS services,
+		coalesce((
+			SELECT json_object_agg(usp.service, usp.permissions)
+			FROM user_service_permission usp
+			WHERE usp.user_id = up.user_id AND usp.project_id = up.project_id
+		), '{}'::json) AS permissions
+	FROM user_to_project up
+	INNER JOIN project p
+	ON p.project_id = up.project_id
+	WHERE up.user_id = @userId
+	ORDER BY p.project_name
+`
+
+func GetMembershipsByUserIdArgs(userId string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId": userId,
+	}
+}
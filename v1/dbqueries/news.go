@@ -38,25 +38,27 @@ func GetAllNewsByProjectIdArgs(projectId string, limit int) pgx.NamedArgs {
 // get image by news id
 const GetNewsImageById = `
 	SELECT image FROM news 
-	WHERE news_id=@newsId
+	WHERE news_id=@newsId AND project_id=@projectId
 `
 
-func GetNewsImageByIdArgs(newsId string) pgx.NamedArgs {
+func GetNewsImageByIdArgs(newsId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"newsId": newsId,
+		"newsId":    newsId,
+		"projectId": projectId,
 	}
 }
 
 // delete news
 const DeleteNewsById = `
 	DELETE FROM news 
-	WHERE news_id=@newsId 
+	WHERE news_id=@newsId AND project_id=@projectId
 	RETURNING image
 `
 
-func DeleteNewsByIdArgs(newsId string) pgx.NamedArgs {
+func DeleteNewsByIdArgs(newsId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"newsId": newsId,
+		"newsId":    newsId,
+		"projectId": projectId,
 	}
 }
 
@@ -77,12 +79,14 @@ const DeleteMultipleNewsById = `
 	DELETE FROM news
 	WHERE 
 	news_id = ANY(@newsIds)
+	AND project_id = @projectId
 	RETURNING image
 `
 
-func DeleteMultipleNewsByIdArgs(newsId []string) pgx.NamedArgs {
+func DeleteMultipleNewsByIdArgs(newsId []string, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"newsIds": newsId,
+		"newsIds":   newsId,
+		"projectId": projectId,
 	}
 }
 
@@ -90,15 +94,16 @@ func DeleteMultipleNewsByIdArgs(newsId []string) pgx.NamedArgs {
 const UpdateNewsById = `
 	UPDATE news 
 	SET title=@title, link=@link, text=@text
-	WHERE news_id=@newsId
+	WHERE news_id=@newsId AND project_id=@projectId
 `
 
-func UpdateNewsByIdArgs(newsId, title, link, text string) pgx.NamedArgs {
+func UpdateNewsByIdArgs(newsId, title, link, text, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"title":  title,
-		"link":   link,
-		"text":   text,
-		"newsId": newsId,
+		"title":     title,
+		"link":      link,
+		"text":      text,
+		"newsId":    newsId,
+		"projectId": projectId,
 	}
 }
 
@@ -0,0 +1,58 @@
+package dbqueries
+
+import "github.com/jackc/pgx/v5"
+
+// origins are stored normalized, see validation.NormalizeOrigin
+
+const GetOriginsByProjectId = `
+	SELECT origin_id, origin, created_at
+	FROM project_origin
+	WHERE project_id = @projectId
+	ORDER BY created_at
+`
+
+func GetOriginsByProjectIdArgs(projectId string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"projectId": projectId,
+	}
+}
+
+const AddOriginToProject = `
+	INSERT INTO project_origin (project_id, origin)
+	VALUES (@projectId, @origin)
+`
+
+func AddOriginToProjectArgs(projectId, origin string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"projectId": projectId,
+		"origin":    origin,
+	}
+}
+
+const DeleteOriginFromProject = `
+	DELETE FROM project_origin
+	WHERE project_id = @projectId AND origin_id = @originId
+	RETURNING origin
+`
+
+func DeleteOriginFromProjectArgs(projectId, originId string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"projectId": projectId,
+		"originId":  originId,
+	}
+}
+
+// used for CORS, a preflight doesn't carry the client token so any project
+// having the origin is enough, ClientTokenAuthentication checks the origin
+// against the token's project
+const OriginExists = `
+	SELECT EXISTS (
+		SELECT 1 FROM project_origin WHERE origin = @origin
+	)
+`
+
+func OriginExistsArgs(origin string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"origin": origin,
+	}
+}
@@ -0,0 +1,94 @@
+package dbqueries
+
+import "github.com/jackc/pgx/v5"
+
+const GetServicePermissionsByUserId = `
+	SELECT service, permissions, updated_at
+	FROM user_service_permission
+	WHERE user_id = @userId AND project_id = @projectId
+	ORDER BY service
+`
+
+func GetServicePermissionsByUserIdArgs(userId, projectId string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId":    userId,
+		"projectId": projectId,
+	}
+}
+
+// a grant restricts the member to their grants, see CheckServicePermission
+const UpsertServicePermission = `
+	WITH restricted AS (
+		UPDATE user_to_project
+		SET permissions_restricted = true
+		WHERE user_id = @userId AND project_id = @projectId
+	)
+	INSERT INTO user_service_permission (user_id, project_id, service, permissions)
+	VALUES (@userId, @projectId, @service, @permissions)
+	ON CONFLICT (user_id, project_id, service)
+	DO UPDATE SET permissions = EXCLUDED.permissions, updated_at = now()
+	RETURNING service, permissions, updated_at
+`
+
+func UpsertServicePermissionArgs(userId, projectId, service string, permissions []string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId":      userId,
+		"projectId":   projectId,
+		"service":     service,
+		"permissions": permissions,
+	}
+}
+
+const DeleteServicePermission = `
+	DELETE FROM user_service_permission
+	WHERE user_id = @userId AND project_id = @projectId AND service = @service
+`
+
+func DeleteServicePermissionArgs(userId, projectId, service string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId":    userId,
+		"projectId": projectId,
+		"service":   service,
+	}
+}
+
+// removing the grants of a restricted member leaves them restricted, the
+// restriction is lifted with LiftServicePermissions only
+const LiftServicePermissions = `
+	WITH deleted AS (
+		DELETE FROM user_service_permission
+		WHERE user_id = @userId AND project_id = @projectId
+	)
+	UPDATE user_to_project
+	SET permissions_restricted = false
+	WHERE user_id = @userId AND project_id = @projectId AND permissions_restricted
+`
+
+func LiftServicePermissionsArgs(userId, projectId string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId":    userId,
+		"projectId": projectId,
+	}
+}
+
+// restricted is set by the first grant to the member in the project, allowed
+// only matters then. Always returns a row, members without one are rejected
+// by ServicesRoleAuthorization before
+const CheckServicePermission = `
+	SELECT
+		coalesce(bool_or(up.permissions_restricted), false) AS restricted,
+		coalesce(bool_or(usp.service = @service AND @permission = ANY(usp.permissions)), false) AS allowed
+	FROM user_to_project up
+	LEFT JOIN user_service_permission usp
+	ON usp.user_id = up.user_id AND usp.project_id = up.project_id
+	WHERE up.user_id = @userId AND up.project_id = @projectId
+`
+
+func CheckServicePermissionArgs(userId, projectId, service, permission string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId":     userId,
+		"projectId":  projectId,
+		"service":    service,
+		"permission": permission,
+	}
+}
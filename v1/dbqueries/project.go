@@ -17,17 +17,18 @@ const CreateProject = `
 		INSERT INTO category (category_id, project_id, category_name)
 		VALUES (@projectId, @projectId, 'default')
 	)
-	INSERT INTO client_token (token, project_id)
-	SELECT @clientToken, project_id
+	INSERT INTO client_token (token_hash, project_id, scopes)
+	SELECT @tokenHash, project_id, @scopes
 	FROM inserted_row;
 `
 
-func CreateProjectArgs(projectName, coverImage, projectId, clientToken string) pgx.NamedArgs {
+func CreateProjectArgs(projectName, coverImage, projectId, tokenHash string, scopes []string) pgx.NamedArgs {
 	return pgx.NamedArgs{
 		"projectName": projectName,
 		"coverImage":  coverImage,
 		"projectId":   projectId,
-		"clientToken": clientToken,
+		"tokenHash":   tokenHash,
+		"scopes":      scopes,
 	}
 }
 
@@ -48,36 +49,60 @@ func AddServicesToProject(projectId string, services []string) string {
 
 // add a user to project
 const AddUserToProject = `
-	INSERT INTO user_to_project (user_id, project_id)
-	VALUES (@userId, @projectId)
+	INSERT INTO user_to_project (user_id, project_id, role)
+	VALUES (@userId, @projectId, @role)
 `
 
-func AddUserToProjectArgs(userId, projectId string) pgx.NamedArgs {
+func AddUserToProjectArgs(userId, projectId, role string) pgx.NamedArgs {
 	return pgx.NamedArgs{
 		"userId":    userId,
 		"projectId": projectId,
+		"role":      role,
 	}
 }
 
-// get project id  by token
-const GetProjectIdByClientToken = `
-	SELECT project_id FROM client_token
-	WHERE token=@clientToken
+// change the role of a project member
+// owners of the project, locked so concurrent changes can't remove the last
+// one together
+const LockProjectOwners = `
+	SELECT user_id FROM user_to_project
+	WHERE project_id = @projectId AND role = 'owner'
+	FOR UPDATE
 `
 
-func GetProjectIdByClientTokenArgs(clientToken string) pgx.NamedArgs {
+func LockProjectOwnersArgs(projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
-		"clientToken": clientToken,
+		"projectId": projectId,
+	}
+}
+
+// projects the user owns, takes GetUserByIDArgs
+const GetOwnedProjectIds = `
+	SELECT project_id::varchar FROM user_to_project
+	WHERE user_id = @userId AND role = 'owner'
+`
+
+const UpdateUserProjectRole = `
+	UPDATE user_to_project
+	SET role = @role
+	WHERE user_id = @userId AND project_id = @projectId
+`
+
+func UpdateUserProjectRoleArgs(userId, projectId, role string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId":    userId,
+		"projectId": projectId,
+		"role":      role,
 	}
 }
 
-// auth to check if the user has rights to perform action in that project
-const GetProjectIdByUserIdAndProjectId = `
-	SELECT project_id FROM user_to_project 
+// auth to check the rights of the user in that project
+const GetProjectRoleByUserIdAndProjectId = `
+	SELECT project_id, role FROM user_to_project
 	WHERE user_id=@userId AND project_id=@projectId
 `
 
-func GetProjectIdByUserIdAndProjectIdArgs(userId, projectId string) pgx.NamedArgs {
+func GetProjectRoleByUserIdAndProjectIdArgs(userId, projectId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
 		"userId":    userId,
 		"projectId": projectId,
@@ -113,11 +138,10 @@ const GetProjectDetailsById = `
 		p.created_at,
 		p.cover_image,
 		coalesce(ud.user_data, '[]'::json) AS user_data,
-		coalesce(s.service_data, '[]'::json) as service_data,
-		c.token
+		coalesce(s.service_data, '[]'::json) as service_data
 	FROM project p
 	INNER JOIN (
-		SELECT json_agg(json_build_object('userId', up.user_id, 'name', u.name, 'email', u.email)) AS user_data
+		SELECT json_agg(json_build_object('userId', up.user_id, 'name', u.name, 'email', u.email, 'role', up.role)) AS user_data
 		FROM user_to_project up
 		INNER JOIN users u 
 		ON up.user_id = u.user_id
@@ -130,8 +154,6 @@ const GetProjectDetailsById = `
 		ON sp.service_id = s.service_id
 		WHERE sp.project_id=@projectId
 	) s ON 1=1
-	INNER JOIN client_token c 
-	ON p.project_id = c.project_id
 	WHERE p.project_id = @projectId;
 `
 
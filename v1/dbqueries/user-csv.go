@@ -0,0 +1,48 @@
+package dbqueries
+
+import "github.com/jackc/pgx/v5"
+
+// emails out of the given ones that already have an account, emails are
+// compared lowercased
+const GetExistingEmails = `
+	SELECT lower(email) FROM users
+	WHERE lower(email) = ANY(@emails)
+`
+
+func GetExistingEmailsArgs(emails []string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"emails": emails,
+	}
+}
+
+// ids out of the given ones that belong to a project, the ids have to be
+// valid uuids
+const GetExistingProjects = `
+	SELECT project_id::text FROM project
+	WHERE project_id = ANY(@projectIds::uuid[])
+`
+
+func GetExistingProjectsArgs(projectIds []string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"projectIds": projectIds,
+	}
+}
+
+// every user with their project assignments as "project-id:role" separated
+// by ";", the format the import reads
+const ExportUsers = `
+	SELECT
+		u.user_id,
+		u.email,
+		u.name,
+		u.role,
+		u.status,
+		u.invite_status,
+		u.created_at,
+		coalesce(string_agg(up.project_id::text || ':' || up.role, ';' ORDER BY up.project_id), '') AS projects
+	FROM users u
+	LEFT JOIN user_to_project up
+	ON up.user_id = u.user_id
+	GROUP BY u.user_id
+	ORDER BY u.created_at, u.user_id
+`
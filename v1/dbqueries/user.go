@@ -1,6 +1,11 @@
 package dbqueries
 
-import "github.com/jackc/pgx/v5"
+import (
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
 
 // create a single user
 const CreateUser = `
@@ -17,20 +22,9 @@ func CreateUserArgs(uid, email, name, role string) pgx.NamedArgs {
 	}
 }
 
-// get all users
-const GetUsers = `Select * FROM users`
-
-// get all users by role
-const GetUsersByRole = `
-	Select * FROM users 
-	WHERE role = @role
-`
-
-func GetUsersByRoleArgs(role string) pgx.NamedArgs {
-	return pgx.NamedArgs{
-		"role": role,
-	}
-}
+// every user, only for the consistency check with the identity provider,
+// listings go through GetUsersPage
+const GetUsersForReconcile = `SELECT * FROM users`
 
 // get a single user by email
 const GetUserByEmail = `
@@ -44,6 +38,11 @@ func GetUserByEmailArgs(email string) pgx.NamedArgs {
 	}
 }
 
+// check if a users row exists, takes GetUserByIDArgs
+const UserExists = `
+	SELECT EXISTS (SELECT 1 FROM users WHERE user_id=@userId)
+`
+
 //get a single user by userid
 const GetUserByID = `
 	SELECT * FROM users 
@@ -62,14 +61,6 @@ const DeleteUser = `
 	WHERE user_id=@userId
 `
 
-// const DeleteUser = `WITH deleted_user AS(
-// 	DELETE FROM users
-// 	WHERE user_id=@userId
-// 	RETURNING user_id
-// )
-// DELETE FROM user_to_project
-// WHERE user_id IN (SELECT user_id FROM deleted_user)`
-
 func DeleteUserArgs(userId string) pgx.NamedArgs {
 	return pgx.NamedArgs{
 		"userId": userId,
@@ -90,17 +81,159 @@ func UpdateUserNameArgs(name, userId string) pgx.NamedArgs {
 	}
 }
 
-// update user name and role
+// update user name and role, a new role revokes the sessions so tokens with
+// the old role claim stop working
 const UpdateUser = `
 	UPDATE users 
-	SET name=@name, role=@role 
+	SET name=@name, role=@role,
+		sessions_revoked_at = CASE WHEN role <> @role THEN @now ELSE sessions_revoked_at END
 	WHERE user_id=@userId
 `
 
-func UpdateUserArgs(name, role, userId string) pgx.NamedArgs {
+func UpdateUserArgs(name, role, userId string, now time.Time) pgx.NamedArgs {
 	return pgx.NamedArgs{
 		"name":   name,
 		"role":   role,
 		"userId": userId,
+		"now":    now,
+	}
+}
+
+// users page, the keyset of every sort ends with the cursor column so rows
+// with the same name or email keep a stable order
+var userSortKeys = map[string]struct {
+	column string
+	desc   bool
+}{
+	"created":  {"", false},
+	"-created": {"", true},
+	"name":     {"u.name", false},
+	"-name":    {"u.name", true},
+	"email":    {"u.email", false},
+	"-email":   {"u.email", true},
+}
+
+func ValidUserSort(sort string) bool {
+	_, ok := userSortKeys[sort]
+	return ok
+}
+
+// GetUsersPage returns the page query for sort, which has to be valid. The
+// keyset is the sort value and the cursor column of the last row of the
+// previous page, cursor 0 for the first page. The values are compared as
+// given so the rows after them are found even if that row is gone
+func GetUsersPage(sort string) string {
+	key := userSortKeys[sort]
+
+	op, order := ">", "ASC"
+	if key.desc {
+		op, order = "<", "DESC"
+	}
+
+	keyset := fmt.Sprintf("u.cursor %s @cursor", op)
+	orderBy := fmt.Sprintf("u.cursor %s", order)
+	if key.column != "" {
+		keyset = fmt.Sprintf("(%s, u.cursor) %s (@cursorValue, @cursor)", key.column, op)
+		orderBy = fmt.Sprintf("%s %s, %s", key.column, order, orderBy)
+	}
+
+	return fmt.Sprintf(`
+	SELECT u.* FROM users u
+	WHERE (@search = '' OR u.name ILIKE @search OR u.email ILIKE @search)
+		AND (@role = '' OR u.role = @role)
+		AND (@projectId = '' OR EXISTS (
+			SELECT 1 FROM user_to_project up
+			WHERE up.user_id = u.user_id AND up.project_id::varchar = @projectId
+		))
+		AND (@cursor = 0 OR %s)
+	ORDER BY %s
+	LIMIT @limit
+`, keyset, orderBy)
+}
+
+func GetUsersPageArgs(search, role, projectId, cursorValue string, cursor, limit int) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"search":      search,
+		"role":        role,
+		"projectId":   projectId,
+		"cursorValue": cursorValue,
+		"cursor":      cursor,
+		"limit":       limit,
+	}
+}
+
+// suspend an active user, the row and the content are kept
+const SuspendUser = `
+	UPDATE users
+	SET status = 'suspended', suspended_at = @now
+	WHERE user_id = @userId AND status = 'active'
+`
+
+func SuspendUserArgs(userId string, now time.Time) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId": userId,
+		"now":    now,
+	}
+}
+
+// what the token middleware checks besides the token, takes GetUserByIDArgs
+const GetUserSessionState = `
+	SELECT status = 'suspended', sessions_revoked_at
+	FROM users
+	WHERE user_id = @userId
+`
+
+// reject every token issued so far
+const RevokeUserSessions = `
+	UPDATE users
+	SET sessions_revoked_at = @now
+	WHERE user_id = @userId
+`
+
+func RevokeUserSessionsArgs(userId string, now time.Time) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId": userId,
+		"now":    now,
+	}
+}
+
+// reactivate a suspended user, takes GetUserByIDArgs
+const ReactivateUser = `
+	UPDATE users
+	SET status = 'active', suspended_at = NULL
+	WHERE user_id = @userId AND status = 'suspended'
+`
+
+// lock the user inheriting content so it can't be deleted in the meantime
+const LockContentInheritor = `
+	SELECT user_id FROM users
+	WHERE user_id = @inheritorId
+	FOR SHARE
+`
+
+func LockContentInheritorArgs(inheritorId string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"inheritorId": inheritorId,
+	}
+}
+
+// hand the authored content of a user over to another one
+const TransferUserContent = `
+	WITH blogs_moved AS (
+		UPDATE blogs SET user_id = @inheritorId WHERE user_id = @userId
+	), albums_moved AS (
+		UPDATE album SET user_id = @inheritorId WHERE user_id = @userId
+	), photos_moved AS (
+		UPDATE photos SET user_id = @inheritorId WHERE user_id = @userId
+	), covers_moved AS (
+		UPDATE document_cover SET user_id = @inheritorId WHERE user_id = @userId
+	)
+	UPDATE documents SET user_id = @inheritorId WHERE user_id = @userId
+`
+
+func TransferUserContentArgs(userId, inheritorId string) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"userId":      userId,
+		"inheritorId": inheritorId,
 	}
 }
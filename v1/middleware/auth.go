@@ -3,90 +3,135 @@ package middleware
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
-
-	"firebase.google.com/go/v4/auth"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/rohan031/adgytec-api/database"
-	"github.com/rohan031/adgytec-api/firebase"
 	"github.com/rohan031/adgytec-api/helper"
+	"github.com/rohan031/adgytec-api/identity"
+	"github.com/rohan031/adgytec-api/session"
 	"github.com/rohan031/adgytec-api/v1/custom"
 	"github.com/rohan031/adgytec-api/v1/dbqueries"
+	"github.com/rohan031/adgytec-api/v1/services"
+	"github.com/rohan031/adgytec-api/v1/validation"
 )
 
 type ClientToken struct {
+	ProjectId string     `db:"project_id"`
+	Scopes    []string   `db:"scopes"`
+	ExpiresAt *time.Time `db:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	Origins   []string   `db:"origins"`
+}
+
+type ProjectMember struct {
 	ProjectId string `db:"project_id"`
+	Role      string `db:"role"`
 }
 
 type ProjectName struct {
 	ProjectName string `db:"project_name"`
 }
 
-func ClientTokenAuthentication(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// check for authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			message := "The request lacks an authorization header."
-			err := &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message}
-			helper.HandleError(w, err)
-			return
-		}
+// ClientTokenAuthentication authenticates public endpoints with a client token
+// of the project, the token has to be granted scope
+func (m *Middleware) ClientTokenAuthentication(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// check for authorization header
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				message := "The request lacks an authorization header."
+				err := &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message}
+				helper.HandleError(w, err)
+				return
+			}
 
-		// check for valid header
-		authArray := strings.Split(authHeader, " ")
-		if len(authArray) != 2 {
-			message := "The authentication header provided is invalid."
-			err := &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message}
-			helper.HandleError(w, err)
-			return
-		}
+			// check for valid header
+			authArray := strings.Split(authHeader, " ")
+			if len(authArray) != 2 {
+				message := "The authentication header provided is invalid."
+				err := &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message}
+				helper.HandleError(w, err)
+				return
+			}
 
-		// check for bearer scheme
-		if bearer := authArray[0]; bearer != "Bearer" {
-			message := "The authentication scheme provided is invalid."
-			err := &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message}
-			helper.HandleError(w, err)
-			return
-		}
+			// check for bearer scheme
+			if bearer := authArray[0]; bearer != "Bearer" {
+				message := "The authentication scheme provided is invalid."
+				err := &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message}
+				helper.HandleError(w, err)
+				return
+			}
 
-		clientToken := authArray[1]
-		args := dbqueries.GetProjectIdByClientTokenArgs(clientToken)
-		rows, err := database.DB.Query(ctx, dbqueries.GetProjectIdByClientToken, args)
-		if err != nil {
-			log.Printf("Error fetching project id from db: %v\n", err)
-			helper.HandleError(w, err)
-			return
-		}
-		defer rows.Close()
+			clientToken := authArray[1]
+			args := dbqueries.GetClientTokenByHashArgs(services.HashClientToken(clientToken))
+			rows, err := m.Store.Query(r.Context(), dbqueries.GetClientTokenByHash, args)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Error fetching client token from db", "error", err)
+				helper.HandleError(w, err)
+				return
+			}
+			defer rows.Close()
 
-		project, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[ClientToken])
-		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				message := "Project with the provided client token does not exist."
-				helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusNotFound, Message: message})
+			token, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[ClientToken])
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					message := "Project with the provided client token does not exist."
+					helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusNotFound, Message: message})
+					return
+				}
+				slog.ErrorContext(r.Context(), "Error reading rows", "error", err)
+				helper.HandleError(w, err)
 				return
 			}
-			log.Printf("Error reading rows: %v\n", err)
-			helper.HandleError(w, err)
-			return
-		}
 
-		ctx := r.Context()
-		ctx = context.WithValue(ctx, custom.ProjectId, project.ProjectId)
-		req := r.WithContext(ctx)
+			if token.RevokedAt != nil {
+				message := "The client token provided has been revoked."
+				helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message})
+				return
+			}
 
-		*r = *req
-		next.ServeHTTP(w, r)
-	})
+			// expiry is stored as utc without a zone
+			if token.ExpiresAt != nil && !token.ExpiresAt.After(time.Now()) {
+				message := "The client token provided has expired."
+				helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message})
+				return
+			}
+
+			if !slices.Contains(token.Scopes, scope) {
+				message := "The client token provided doesn't grant access to this resource."
+				helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusForbidden, Message: message})
+				return
+			}
+
+			// requests without an origin don't come from a browser, e.g. server
+			// side rendering, the token alone is enough for them
+			if origin := r.Header.Get("Origin"); origin != "" {
+				normalized, ok := validation.NormalizeOrigin(origin)
+				if !ok || !slices.Contains(token.Origins, normalized) {
+					message := "Requests from this origin are not allowed for the project."
+					helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusForbidden, Message: message})
+					return
+				}
+			}
+
+			ctx := r.Context()
+			ctx = context.WithValue(ctx, custom.ProjectId, token.ProjectId)
+			req := r.WithContext(ctx)
+
+			*r = *req
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-func TokenAuthentication(next http.Handler) http.Handler {
+func (m *Middleware) TokenAuthentication(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// check for authorization header
 		authHeader := r.Header.Get("Authorization")
@@ -116,24 +161,24 @@ func TokenAuthentication(next http.Handler) http.Handler {
 
 		// verify id token provided
 		idToken := authArray[1]
-		token, err := firebase.FirebaseClient.VerifyIDToken(ctx, idToken)
+		token, err := m.Identity.VerifyToken(r.Context(), idToken)
 		if err != nil {
 
-			if auth.IsIDTokenExpired(err) {
+			if errors.Is(err, identity.ErrTokenExpired) {
 				message := "The ID token provided has expired and is no longer valid for authentication."
 				err := &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message}
 				helper.HandleError(w, err)
 				return
 			}
 
-			if auth.IsIDTokenInvalid(err) {
+			if errors.Is(err, identity.ErrTokenInvalid) {
 				message := "The provided ID token is invalid and cannot be used for authentication."
 				err := &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message}
 				helper.HandleError(w, err)
 				return
 			}
 
-			log.Printf("error verifying ID token: %v\n", err)
+			slog.ErrorContext(r.Context(), "error verifying ID token", "error", err)
 			helper.HandleError(w, err)
 			return
 		}
@@ -145,6 +190,40 @@ func TokenAuthentication(next http.Handler) http.Handler {
 			return
 		}
 
+		// tokens stay valid until they expire, suspensions and revocations
+		// are checked here
+		state, err := m.sessionState(r.Context(), token.UID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error checking user session in db", "error", err)
+			helper.HandleError(w, err)
+			return
+		}
+
+		// the users row is gone once the user is deleted
+		if state.Deleted {
+			message := "The account no longer exists."
+			err := &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message}
+			helper.HandleError(w, err)
+			return
+		}
+
+		if state.Suspended {
+			message := "The account has been suspended."
+			err := &custom.MalformedRequest{Status: http.StatusForbidden, Message: message}
+			helper.HandleError(w, err)
+			return
+		}
+
+		// compared in whole seconds like firebase does, a token issued in the
+		// second of the revocation is accepted so signing in again right away
+		// works
+		if state.RevokedAt != nil && token.IssuedAt.Before(state.RevokedAt.Truncate(time.Second)) {
+			message := "The session has been revoked, sign in again."
+			err := &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message}
+			helper.HandleError(w, err)
+			return
+		}
+
 		// adding values to request context
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, custom.UserID, token.UID)
@@ -157,7 +236,27 @@ func TokenAuthentication(next http.Handler) http.Handler {
 	})
 }
 
-func UserRoleAuthorization(next http.Handler) http.Handler {
+// sessionState reads the state of the user's sessions through the cache,
+// accounts without a users row are deleted
+func (m *Middleware) sessionState(ctx context.Context, userId string) (session.State, error) {
+	if state, ok := m.Sessions.Get(userId); ok {
+		return state, nil
+	}
+
+	var state session.State
+	args := dbqueries.GetUserByIDArgs(userId)
+	err := m.Store.QueryRow(ctx, dbqueries.GetUserSessionState, args).Scan(&state.Suspended, &state.RevokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		state.Deleted = true
+	} else if err != nil {
+		return state, err
+	}
+
+	m.Sessions.Set(userId, state)
+	return state, nil
+}
+
+func (m *Middleware) UserRoleAuthorization(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// patch method middleware
 		if r.Method == http.MethodPatch {
@@ -193,22 +292,22 @@ func UserRoleAuthorization(next http.Handler) http.Handler {
 				// uid := r.Context().Value(custom.UserID).(string)
 				userRole := r.Context().Value(custom.UserRole).(string)
 
-				// fetch user from firebase
-				u, err := firebase.FirebaseClient.GetUser(ctx, idParam)
+				// fetch user from identity provider
+				u, err := m.Identity.GetUser(r.Context(), idParam)
 				if err != nil {
-					if auth.IsUserNotFound(err) {
+					if errors.Is(err, identity.ErrUserNotFound) {
 						message := "No user found for deletion."
 						err := &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
 						helper.HandleError(w, err)
 						return
 					}
 
-					log.Printf("Error getting user from firebase:%v\n", err)
+					slog.ErrorContext(r.Context(), "Error getting user from identity provider", "error", err)
 					helper.HandleError(w, err)
 					return
 				}
 
-				userToDeleteRole := u.CustomClaims["role"]
+				userToDeleteRole := u.Claims["role"]
 
 				if userRole != "super_admin" && (userToDeleteRole == "super_admin" || userRole == userToDeleteRole) {
 					message := "Insufficient privileges to perform requested action."
@@ -224,7 +323,7 @@ func UserRoleAuthorization(next http.Handler) http.Handler {
 	})
 }
 
-func AdminRoleAuthorization(next http.Handler) http.Handler {
+func (m *Middleware) AdminRoleAuthorization(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// admin and super admin having privilaged rights
 		userRole := r.Context().Value(custom.UserRole).(string)
@@ -239,8 +338,22 @@ func AdminRoleAuthorization(next http.Handler) http.Handler {
 	})
 }
 
+func (m *Middleware) SuperAdminRoleAuthorization(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userRole := r.Context().Value(custom.UserRole).(string)
+		if userRole != validation.SuperAdmin {
+			message := "Insufficient privileges to perform requested action."
+			err := &custom.MalformedRequest{Status: http.StatusForbidden, Message: message}
+			helper.HandleError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // services endpoint auth
-func ServicesRoleAuthorization(next http.Handler) http.Handler {
+func (m *Middleware) ServicesRoleAuthorization(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		userRole := r.Context().Value(custom.UserRole).(string)
 		userId := r.Context().Value(custom.UserID).(string)
@@ -248,9 +361,9 @@ func ServicesRoleAuthorization(next http.Handler) http.Handler {
 
 		// check if project exists
 		args := dbqueries.GetProjectByIdArgs(projectId)
-		rows, err := database.DB.Query(ctx, dbqueries.GetProjectNameById, args)
+		rows, err := m.Store.Query(r.Context(), dbqueries.GetProjectNameById, args)
 		if err != nil {
-			log.Printf("Error fetching project id from db: %v\n", err)
+			slog.ErrorContext(r.Context(), "Error fetching project id from db", "error", err)
 			helper.HandleError(w, err)
 			return
 		}
@@ -273,7 +386,7 @@ func ServicesRoleAuthorization(next http.Handler) http.Handler {
 				}
 			}
 
-			log.Printf("Error reading rows: %v\n", err)
+			slog.ErrorContext(r.Context(), "Error reading rows", "error", err)
 			helper.HandleError(w, err)
 			return
 		}
@@ -284,34 +397,133 @@ func ServicesRoleAuthorization(next http.Handler) http.Handler {
 			return
 		}
 
-		// check for user project
-		args = dbqueries.GetProjectIdByUserIdAndProjectIdArgs(userId, projectId)
-		rows, err = database.DB.Query(ctx, dbqueries.GetProjectIdByUserIdAndProjectId, args)
+		// check the role of the user in the project
+		member, err := m.projectMember(r.Context(), userId, projectId)
 		if err != nil {
-			log.Printf("Error fetching project id from db: %v\n", err)
 			helper.HandleError(w, err)
 			return
 		}
-		defer rows.Close()
 
-		_, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[ClientToken])
+		if member == nil {
+			message := "Insufficient privileges to perform requested action."
+			helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusNotFound, Message: message})
+			return
+		}
+
+		if !validation.ProjectRoleAllowsMethod(member.Role, r.Method) {
+			message := "Your role in the project doesn't allow this action."
+			helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusForbidden, Message: message})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), custom.ProjectRole, member.Role)
+		req := r.WithContext(ctx)
+
+		*r = *req
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ProjectOwnerAuthorization lets owners manage the members of their project
+// next to admins
+func (m *Middleware) ProjectOwnerAuthorization(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userRole := r.Context().Value(custom.UserRole).(string)
+		if userRole != "user" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userId := r.Context().Value(custom.UserID).(string)
+		member, err := m.projectMember(r.Context(), userId, chi.URLParam(r, "projectId"))
 		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				message := "Insufficient privileges to perform requested action."
-				helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusNotFound, Message: message})
-				return
-			}
-			log.Printf("Error reading rows: %v\n", err)
 			helper.HandleError(w, err)
 			return
 		}
 
-		// if project.ProjectId != projectId {
-		// 	message := "Insufficient privileges to perform requested action."
-		// 	helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message})
-		// 	return
-		// }
+		if member == nil || member.Role != validation.ProjectOwner {
+			message := "Insufficient privileges to perform requested action."
+			helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusForbidden, Message: message})
+			return
+		}
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// projectMember returns nil when the user isn't a member of the project
+func (m *Middleware) projectMember(ctx context.Context, userId, projectId string) (*ProjectMember, error) {
+	args := dbqueries.GetProjectRoleByUserIdAndProjectIdArgs(userId, projectId)
+	rows, err := m.Store.Query(ctx, dbqueries.GetProjectRoleByUserIdAndProjectId, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching project role from db", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	member, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[ProjectMember])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == "22P02" {
+			message := "Invalid project id."
+			return nil, &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+type ServicePermission struct {
+	Restricted bool `db:"restricted"`
+	Allowed    bool `db:"allowed"`
+}
+
+// ServicePermissionAuthorization limits members with service permissions to
+// the granted services, it runs after ServicesRoleAuthorization so the role
+// has to allow the request as well
+func (m *Middleware) ServicePermissionAuthorization(service string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userRole := r.Context().Value(custom.UserRole).(string)
+			if userRole != "user" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userId := r.Context().Value(custom.UserID).(string)
+			projectId := chi.URLParam(r, "projectId")
+			permission := validation.PermissionForMethod(r.Method)
+
+			args := dbqueries.CheckServicePermissionArgs(userId, projectId, service, permission)
+			rows, err := m.Store.Query(r.Context(), dbqueries.CheckServicePermission, args)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Error fetching service permission from db", "error", err)
+				helper.HandleError(w, err)
+				return
+			}
+			defer rows.Close()
+
+			check, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[ServicePermission])
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Error reading rows", "error", err)
+				helper.HandleError(w, err)
+				return
+			}
+
+			if check.Restricted && !check.Allowed {
+				message := "You don't have " + permission + " permission for " + service + "."
+				helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusForbidden, Message: message})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/rohan031/adgytec-api/v1/dbqueries"
+	"github.com/rohan031/adgytec-api/v1/validation"
+)
+
+// AllowOrigin decides the CORS origins, the dashboard origins from the config
+// and every origin registered for a project.
+// A preflight doesn't carry the client token, ClientTokenAuthentication checks
+// the origin against the token's project
+func (m *Middleware) AllowOrigin(r *http.Request, origin string) bool {
+	for _, pattern := range m.Config.AllowedOrigins {
+		if validation.MatchOrigin(pattern, origin) {
+			return true
+		}
+	}
+
+	origin, ok := validation.NormalizeOrigin(origin)
+	if !ok {
+		return false
+	}
+
+	var exists bool
+	args := dbqueries.OriginExistsArgs(origin)
+	err := m.Store.QueryRow(r.Context(), dbqueries.OriginExists, args).Scan(&exists)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error checking origin in db", "error", err)
+		return false
+	}
+
+	return exists
+}
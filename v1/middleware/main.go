@@ -1,5 +1,13 @@
 package middleware
 
-import "context"
+import "github.com/rohan031/adgytec-api/app"
 
-var ctx context.Context = context.Background()
+// Middleware holds the dependencies the v1 middlewares need to authenticate
+// and authorize requests
+type Middleware struct {
+	*app.App
+}
+
+func New(a *app.App) *Middleware {
+	return &Middleware{App: a}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/httprate"
+	"github.com/rohan031/adgytec-api/helper"
+	"github.com/rohan031/adgytec-api/v1/custom"
+)
+
+// AccountRateLimit limits the unauthenticated account endpoints per ip and
+// endpoint, on top of the global limit
+func (m *Middleware) AccountRateLimit(requests int) func(http.Handler) http.Handler {
+	return httprate.Limit(
+		requests,
+		time.Minute,
+		httprate.WithKeyFuncs(httprate.KeyByIP, httprate.KeyByEndpoint),
+		httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+			message := "Too many requests, try again in a minute."
+			helper.HandleError(w, &custom.MalformedRequest{Status: http.StatusTooManyRequests, Message: message})
+		}),
+	)
+}
@@ -3,24 +3,17 @@ package router
 import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
+	"github.com/rohan031/adgytec-api/config"
 	"github.com/rohan031/adgytec-api/v1/controllers"
 	"github.com/rohan031/adgytec-api/v1/middleware"
+	"github.com/rohan031/adgytec-api/v1/validation"
 )
 
-func Router() *chi.Mux {
+func Router(cfg *config.Config, h *controllers.Handler, mw *middleware.Middleware) *chi.Mux {
 	router := chi.NewRouter()
 
-	allowedOrigins := []string{
-		"https://*.adgytec.in",
-		"https://ecrimino.com",
-		"https://prise-rdc.com",
-	}
-	//if os.Getenv("ENV") == "dev" {
-	allowedOrigins = append(allowedOrigins, "http://*")
-	//}
-
 	router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   allowedOrigins,
+		AllowOriginFunc:  mw.AllowOrigin,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
@@ -28,128 +21,247 @@ func Router() *chi.Mux {
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 
-	router.Get("/newsletter", controllers.GetNewslettersEmail)  // protected route called from dashboard to show all the emails that are signup for newsletter along with their status subscribe and unsubscribe
-	router.Post("/newsletter", controllers.PostNewsletterEmail) // public route called from client frontend with their client token to add the email, if email already exists set status to subscribe
+	router.Get("/newsletter", h.GetNewslettersEmail)  // protected route called from dashboard to show all the emails that are signup for newsletter along with their status subscribe and unsubscribe
+	router.Post("/newsletter", h.PostNewsletterEmail) // public route called from client frontend with their client token to add the email, if email already exists set status to subscribe
 	// patch method for unsubscribing from email newsletter
 
+	// sign in, only served by the local identity provider
+	router.Post("/auth/token", h.PostToken)
+
+	// invitees choose their password, the invite token authenticates them
+	router.Post("/invite/accept", h.AcceptInvite)
+
+	// account recovery, the responses don't tell if an email has an account
+	router.Group(func(r chi.Router) {
+		r.Use(mw.AccountRateLimit(10))
+
+		r.Post("/auth/password-reset", h.RequestPasswordReset)
+		r.Post("/auth/password-reset/confirm", h.ConfirmPasswordReset)
+		r.Post("/auth/email-verification", h.RequestEmailVerification)
+		r.Post("/auth/email-verification/confirm", h.ConfirmEmailVerification)
+	})
+
 	// user module
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.TokenAuthentication)
-		r.Use(middleware.UserRoleAuthorization)
-
-		r.Post("/user", controllers.PostUser)
-		r.Patch("/user/{id}", controllers.PatchUser)
-		r.Delete("/user/{id}", controllers.DeleteUser)
-		r.Get("/user/{id}", controllers.GetUserById)
-		r.Get("/users", controllers.GetAllUsers)
+		r.Use(mw.TokenAuthentication)
+		r.Use(mw.UserRoleAuthorization)
+
+		r.Post("/user", h.PostUser)
+		r.Patch("/user/{id}", h.PatchUser)
+		r.Delete("/user/{id}", h.DeleteUser)
+		r.Get("/user/{id}", h.GetUserById)
+		r.Get("/users", h.GetAllUsers)
+
+		// onboarding many users at once, the export can be imported back
+		r.Post("/users/import", h.ImportUsers)
+		r.Get("/users/export", h.ExportUsers)
+
+		// invites of users that haven't signed in yet
+		r.Post("/user/{id}/invite", h.ResendInvite)
+		r.Delete("/user/{id}/invite", h.RevokeInvite)
+
+		// suspended users keep their content but can't sign in
+		r.Post("/user/{id}/suspend", h.SuspendUser)
+		r.Post("/user/{id}/reactivate", h.ReactivateUser)
+
+		// signs the user out everywhere
+		r.Delete("/user/{id}/sessions", h.RevokeUserSessions)
+	})
+
+	// the signed in user, any role
+	router.Group(func(r chi.Router) {
+		r.Use(mw.TokenAuthentication)
+
+		r.Get("/me", h.GetMe)
+		r.Patch("/me", h.PatchMe)
+	})
+
+	// drift between the identity provider and the users table
+	router.Group(func(r chi.Router) {
+		r.Use(mw.TokenAuthentication)
+		r.Use(mw.SuperAdminRoleAuthorization)
+
+		r.Get("/users/consistency", h.GetUserConsistency)
+		r.Post("/users/consistency/repair", h.RepairUserConsistency)
 	})
 
 	// project module admin only routes
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.TokenAuthentication)
-		r.Use(middleware.AdminRoleAuthorization)
-
-		r.Post("/project", controllers.PostProject)
-		r.Post("/project/{projectId}/services", controllers.PostProjectAndServices)
-		r.Post("/project/{projectId}/user", controllers.PostProjectAndUser)
-		r.Get("/projects", controllers.GetAllProjects)
-		r.Get("/project/{projectId}", controllers.GetProjectById)
-		r.Get("/services", controllers.GetAllServices)
-		r.Delete("/project/{projectId}", controllers.DeleteProjectById)
-		r.Delete("/project/{projectId}/user", controllers.DeleteProjectAndUser)
-		r.Delete("/project/{projectId}/services", controllers.DeleteProjectAndService)
+		r.Use(mw.TokenAuthentication)
+		r.Use(mw.AdminRoleAuthorization)
+
+		r.Post("/project", h.PostProject)
+		r.Post("/project/{projectId}/services", h.PostProjectAndServices)
+		r.Get("/projects", h.GetAllProjects)
+		r.Get("/project/{projectId}", h.GetProjectById)
+		r.Get("/services", h.GetAllServices)
+		r.Delete("/project/{projectId}", h.DeleteProjectById)
+		r.Delete("/project/{projectId}/services", h.DeleteProjectAndService)
 
 		// project category management
-		r.Post("/project/{projectId}/category", controllers.PostCategoryByProjectId)
-		r.Patch("/project/{projectId}/category/{categoryId}", controllers.PatchCategoryById)
-		r.Get("/project/{projectId}/category", controllers.GetCategoryByProjectId)
-		r.Delete("/project/{projectId}/category/{categoryId}", controllers.DeleteCategoryById)
+		r.Post("/project/{projectId}/category", h.PostCategoryByProjectId)
+		r.Patch("/project/{projectId}/category/{categoryId}", h.PatchCategoryById)
+		r.Get("/project/{projectId}/category", h.GetCategoryByProjectId)
+		r.Delete("/project/{projectId}/category/{categoryId}", h.DeleteCategoryById)
+
+		// project client tokens
+		r.Post("/project/{projectId}/tokens", h.PostClientToken)
+		r.Get("/project/{projectId}/tokens", h.GetClientTokens)
+		r.Patch("/project/{projectId}/tokens/{tokenId}", h.PatchClientToken)
+		r.Post("/project/{projectId}/tokens/{tokenId}/rotate", h.RotateClientToken)
+		r.Delete("/project/{projectId}/tokens/{tokenId}", h.RevokeClientToken)
+
+		// browser origins allowed to use the client tokens
+		r.Get("/project/{projectId}/origins", h.GetProjectOrigins)
+		r.Post("/project/{projectId}/origins", h.PostProjectOrigin)
+		r.Delete("/project/{projectId}/origins/{originId}", h.DeleteProjectOrigin)
 
 	})
 
+	// project members, managed by admins and the owners of the project
+	router.Group(func(r chi.Router) {
+		r.Use(mw.TokenAuthentication)
+		r.Use(mw.ProjectOwnerAuthorization)
+
+		r.Post("/project/{projectId}/user", h.PostProjectAndUser)
+		r.Patch("/project/{projectId}/user", h.PatchProjectAndUser)
+		r.Delete("/project/{projectId}/user", h.DeleteProjectAndUser)
+
+		// services a member is limited to
+		r.Get("/project/{projectId}/user/{userId}/permissions", h.GetServicePermissions)
+		r.Put("/project/{projectId}/user/{userId}/permissions/{service}", h.PutServicePermission)
+		r.Delete("/project/{projectId}/user/{userId}/permissions/{service}", h.DeleteServicePermission)
+		// removing grants keeps the member limited, this gives back the role access
+		r.Delete("/project/{projectId}/user/{userId}/permissions", h.LiftServicePermissions)
+	})
+
 	// project module users
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.TokenAuthentication)
+		r.Use(mw.TokenAuthentication)
+
+		r.Get("/client/projects", h.GetProjectsByUserId)
+
+		// members only, like the services of the project
+		r.Group(func(r chi.Router) {
+			r.Use(mw.ServicesRoleAuthorization)
 
-		r.Get("/client/projects", controllers.GetProjectsByUserId)
-		r.Get("/client/projects/{projectId}/metadata", controllers.GetMetadataByProjectId)
+			r.Get("/client/projects/{projectId}/metadata", h.GetMetadataByProjectId)
+		})
 	})
 
 	// services
-	// client token authentication for public endpoints
+	// client token authentication for public endpoints, each group needs its
+	// own scope on the token
+
+	// news
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.ClientTokenAuthentication)
-		// endpoints here
+		r.Use(mw.ClientTokenAuthentication(validation.ScopeReadNews))
 
-		r.Get("/services/news", controllers.GetAllNewsClient)
+		r.Get("/services/news", h.GetAllNewsClient)
+	})
 
-		// blogs
-		r.Get("/services/blogs", controllers.GetAllBlogsByProjectIdClient)
-		r.Get("/services/blogs/category/{categoryId}", controllers.GetAllBlogsByCategoryIdClient)
-		r.Get("/services/blog/{blogId}", controllers.GetBlogById)
+	// blogs
+	router.Group(func(r chi.Router) {
+		r.Use(mw.ClientTokenAuthentication(validation.ScopeReadBlogs))
 
-		// gallery
-		r.Get("/services/gallery/albums", controllers.GetAlbumsByProjectIdClient)
-		r.Get("/services/gallery/album/{albumId}", controllers.GetPhotosByAlbumId)
-		r.Get("/services/gallery/album/{albumId}/name", controllers.GetAlbumNameById)
+		r.Get("/services/blogs", h.GetAllBlogsByProjectIdClient)
+		r.Get("/services/blogs/category/{categoryId}", h.GetAllBlogsByCategoryIdClient)
+		r.Get("/services/blog/{blogId}", h.GetBlogById)
+	})
 
-		// documents
-		r.Get("/services/documents/cover", controllers.GetDocumentCoverByProjectIdClient)
+	// gallery
+	router.Group(func(r chi.Router) {
+		r.Use(mw.ClientTokenAuthentication(validation.ScopeReadGallery))
 
-		// contact us
-		r.Post("/services/contact-us", controllers.PostContactUs)
+		r.Get("/services/gallery/albums", h.GetAlbumsByProjectIdClient)
+		r.Get("/services/gallery/album/{albumId}", h.GetPhotosByAlbumId)
+		r.Get("/services/gallery/album/{albumId}/name", h.GetAlbumNameById)
+	})
+
+	// documents
+	router.Group(func(r chi.Router) {
+		r.Use(mw.ClientTokenAuthentication(validation.ScopeReadDocuments))
+
+		r.Get("/services/documents/cover", h.GetDocumentCoverByProjectIdClient)
+	})
+
+	// contact us
+	router.Group(func(r chi.Router) {
+		r.Use(mw.ClientTokenAuthentication(validation.ScopeWriteContactUs))
+
+		r.Post("/services/contact-us", h.PostContactUs)
 	})
 
 	// getting uuid
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.TokenAuthentication)
+		r.Use(mw.TokenAuthentication)
 
-		r.Get("/uuid", controllers.GetUUID)
+		r.Get("/uuid", h.GetUUID)
 	})
 
 	//dashboard endpoints for services
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.TokenAuthentication)
-		r.Use(middleware.ServicesRoleAuthorization)
+		r.Use(mw.TokenAuthentication)
+		r.Use(mw.ServicesRoleAuthorization)
 
 		// news
-		r.Post("/services/news/{projectId}", controllers.PostNews)
-		r.Get("/services/news/{projectId}", controllers.GetNews)
-		r.Put("/services/news/{projectId}/{newsId}", controllers.PutNews)
-		r.Delete("/services/news/{projectId}/{newsId}", controllers.DeleteNews)
-		r.Delete("/services/news/{projectId}", controllers.DeleteNewsMultiple)
+		r.Group(func(r chi.Router) {
+			r.Use(mw.ServicePermissionAuthorization(validation.ServiceNews))
+
+			r.Post("/services/news/{projectId}", h.PostNews)
+			r.Get("/services/news/{projectId}", h.GetNews)
+			r.Put("/services/news/{projectId}/{newsId}", h.PutNews)
+			r.Delete("/services/news/{projectId}/{newsId}", h.DeleteNews)
+			r.Delete("/services/news/{projectId}", h.DeleteNewsMultiple)
+		})
 
 		// blogs
-		r.Post("/services/blogs/{projectId}/{blogId}/media", controllers.PostMedia)
-		r.Delete("/services/blogs/{projectId}/{blogId}/media", controllers.DeleteMedia)
-		r.Post("/services/blogs/{projectId}/{blogId}", controllers.PostBlog)
-		r.Get("/services/blogs/{projectId}", controllers.GetAllBlogsByProjectId)
-		r.Get("/services/blogs/{projectId}/category/{categoryId}", controllers.GetAllBlogsByCategoryId)
-		r.Get("/services/blogs/{projectId}/{blogId}", controllers.GetBlogById)
-		r.Patch("/services/blogs/{projectId}/{blogId}", controllers.PatchBlogMetadataById)
-		r.Delete("/services/blogs/{projectId}/{blogId}", controllers.DeleteBlogById)
-		r.Patch("/services/blogs/{projectId}/{blogId}/cover", controllers.PatchBlogCover)
-		r.Patch("/services/blogs/{projectId}/{blogId}/content", controllers.PatchBlogContent)
+		r.Group(func(r chi.Router) {
+			r.Use(mw.ServicePermissionAuthorization(validation.ServiceBlogs))
+
+			r.Post("/services/blogs/{projectId}/{blogId}/media", h.PostMedia)
+			r.Delete("/services/blogs/{projectId}/{blogId}/media", h.DeleteMedia)
+			r.Post("/services/blogs/{projectId}/{blogId}", h.PostBlog)
+			r.Get("/services/blogs/{projectId}", h.GetAllBlogsByProjectId)
+			r.Get("/services/blogs/{projectId}/category/{categoryId}", h.GetAllBlogsByCategoryId)
+			r.Get("/services/blogs/{projectId}/{blogId}", h.GetBlogById)
+			r.Patch("/services/blogs/{projectId}/{blogId}", h.PatchBlogMetadataById)
+			r.Delete("/services/blogs/{projectId}/{blogId}", h.DeleteBlogById)
+			r.Patch("/services/blogs/{projectId}/{blogId}/cover", h.PatchBlogCover)
+			r.Patch("/services/blogs/{projectId}/{blogId}/content", h.PatchBlogContent)
+		})
 
 		// gallery
-		r.Get("/services/gallery/{projectId}/albums", controllers.GetAlbumsByProjectId)
-		r.Post("/services/gallery/{projectId}/albums", controllers.PostAlbum)
-		r.Patch("/services/gallery/{projectId}/albums/{albumId}/metadata", controllers.PatchAlbumMetadataById)
-		r.Patch("/services/gallery/{projectId}/albums/{albumId}/cover", controllers.PatchAlbumCoverById)
-		r.Delete("/services/gallery/{projectId}/albums/{albumId}", controllers.DeleteAlbumById)
-		r.Post("/services/gallery/{projectId}/album/{albumId}", controllers.PostPhoto)
-		r.Get("/services/gallery/{projectId}/album/{albumId}", controllers.GetPhotosByAlbumId)
-		r.Delete("/services/gallery/{projectId}/album/{albumId}", controllers.DeletePhotosById)
+		r.Group(func(r chi.Router) {
+			r.Use(mw.ServicePermissionAuthorization(validation.ServiceGallery))
+
+			r.Get("/services/gallery/{projectId}/albums", h.GetAlbumsByProjectId)
+			r.Post("/services/gallery/{projectId}/albums", h.PostAlbum)
+			r.Patch("/services/gallery/{projectId}/albums/{albumId}/metadata", h.PatchAlbumMetadataById)
+			r.Patch("/services/gallery/{projectId}/albums/{albumId}/cover", h.PatchAlbumCoverById)
+			r.Delete("/services/gallery/{projectId}/albums/{albumId}", h.DeleteAlbumById)
+			r.Post("/services/gallery/{projectId}/album/{albumId}", h.PostPhoto)
+			r.Get("/services/gallery/{projectId}/album/{albumId}", h.GetPhotosByAlbumId)
+			r.Delete("/services/gallery/{projectId}/album/{albumId}", h.DeletePhotosById)
+		})
 
 		// documents
-		r.Get("/services/documents/{projectId}/cover", controllers.GetDocumentCoverByProjectId)
-		r.Post("/services/documents/{projectId}/cover", controllers.PostDocumentCover)
-		r.Patch("/services/documents/{projectId}/cover/{coverId}", controllers.PatchDocumentCoverById)
-		r.Delete("/services/documents/{projectId}/cover/{coverId}", controllers.DeleteDocumentCoverById)
+		r.Group(func(r chi.Router) {
+			r.Use(mw.ServicePermissionAuthorization(validation.ServiceDocuments))
+
+			r.Get("/services/documents/{projectId}/cover", h.GetDocumentCoverByProjectId)
+			r.Post("/services/documents/{projectId}/cover", h.PostDocumentCover)
+			r.Patch("/services/documents/{projectId}/cover/{coverId}", h.PatchDocumentCoverById)
+			r.Delete("/services/documents/{projectId}/cover/{coverId}", h.DeleteDocumentCoverById)
+		})
 
 		// contact-us
-		r.Get("/services/contact-us/{projectId}", controllers.GetContactUs)
-		r.Delete("/services/contact-us/{projectId}/{contactId}", controllers.DeleteContactUsItem)
+		r.Group(func(r chi.Router) {
+			r.Use(mw.ServicePermissionAuthorization(validation.ServiceContactUs))
+
+			r.Get("/services/contact-us/{projectId}", h.GetContactUs)
+			r.Delete("/services/contact-us/{projectId}/{contactId}", h.DeleteContactUsItem)
+		})
 	})
 
 	return router
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rohan031/adgytec-api/identity"
+	"github.com/rohan031/adgytec-api/v1/custom"
+	"github.com/rohan031/adgytec-api/v1/dbqueries"
+)
+
+const (
+	passwordResetTemplate     = "./assets/password-reset.html"
+	verifyEmailTemplate       = "./assets/verify-email.html"
+	passwordResetAction       = "password_reset"
+	verifyEmailAction         = "verify_email"
+	accountEmailLimit         = 3
+	accountEmailLimitDuration = time.Hour
+)
+
+type AccountEmail struct {
+	Email string `json:"email"`
+}
+
+type PasswordReset struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type EmailVerification struct {
+	Token string `json:"token"`
+}
+
+// AccountLink is what the reset and verification emails are rendered from
+type AccountLink struct {
+	Name  string
+	Email string
+	Link  string
+}
+
+// RequestPasswordReset mails a reset link when the email belongs to a user.
+// The work happens after the response so neither the status nor the timing
+// tells if the account exists
+func (s *Service) RequestPasswordReset(ctx context.Context, e *AccountEmail) {
+	email := strings.TrimSpace(e.Email)
+	if email == "" {
+		return
+	}
+
+	s.runInBackground(ctx, func(ctx context.Context) {
+		s.sendAccountEmail(ctx, email, passwordResetAction)
+	})
+}
+
+// RequestEmailVerification mails a verification link, same as
+// RequestPasswordReset
+func (s *Service) RequestEmailVerification(ctx context.Context, e *AccountEmail) {
+	email := strings.TrimSpace(e.Email)
+	if email == "" {
+		return
+	}
+
+	s.runInBackground(ctx, func(ctx context.Context) {
+		s.sendAccountEmail(ctx, email, verifyEmailAction)
+	})
+}
+
+func (s *Service) sendAccountEmail(ctx context.Context, email, action string) {
+	rows, err := s.Store.Query(ctx, dbqueries.GetUserByEmail, dbqueries.GetUserByEmailArgs(email))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching user from db", "error", err)
+		return
+	}
+	defer rows.Close()
+
+	u, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[User])
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		}
+		return
+	}
+
+	// invitees set their password through the invite
+	if u.InviteStatus != "accepted" {
+		return
+	}
+
+	// timestamps are stored without a zone
+	now := time.Now().UTC()
+	since := now.Add(-accountEmailLimitDuration)
+
+	recorded, err := s.recordAccountEmailRequest(ctx, u.Email, action, now, since)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error recording account email request", "error", err)
+		return
+	}
+	if !recorded {
+		slog.WarnContext(ctx, "Account email limit reached", "action", action)
+		return
+	}
+
+	_, err = s.Store.Exec(ctx, dbqueries.DeleteAccountEmailRequests, dbqueries.DeleteAccountEmailRequestsArgs(since))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting old account email requests", "error", err)
+	}
+
+	authCtx, cancel := s.authContext(ctx)
+	defer cancel()
+
+	var link, template, subject string
+	switch action {
+	case passwordResetAction:
+		link, err = s.Identity.PasswordResetLink(authCtx, u.Email)
+		template, subject = passwordResetTemplate, "Reset your Adgytec password"
+	default:
+		link, err = s.Identity.EmailVerificationLink(authCtx, u.Email)
+		template, subject = verifyEmailTemplate, "Verify your Adgytec email"
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error generating account link", "action", action, "error", err)
+		return
+	}
+
+	details := &AccountLink{Name: u.Name, Email: u.Email, Link: link}
+	s.SendEmail(ctx, details, template, []string{u.Email}, subject)
+}
+
+// recordAccountEmailRequest records the request unless the limit of the email
+// is reached, concurrent requests for the email wait for each other
+func (s *Service) recordAccountEmailRequest(ctx context.Context, email, action string, now, since time.Time) (bool, error) {
+	tx, err := s.Store.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	_, err = tx.Exec(ctx, dbqueries.LockAccountEmailRequests, dbqueries.LockAccountEmailRequestsArgs(email))
+	if err != nil {
+		return false, err
+	}
+
+	args := dbqueries.RecordAccountEmailRequestArgs(email, action, now, since, accountEmailLimit)
+	tag, err := tx.Exec(ctx, dbqueries.RecordAccountEmailRequest, args)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, tx.Commit(ctx)
+}
+
+func (s *Service) actionCodes() (identity.ActionCodes, error) {
+	provider, ok := s.Identity.(identity.ActionCodes)
+	if !ok {
+		message := "The link is handled by the identity provider directly."
+		return nil, &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
+	return provider, nil
+}
+
+func actionCodeError(ctx context.Context, err error) error {
+	if errors.Is(err, identity.ErrActionCodeInvalid) {
+		message := "The link is invalid, has expired or has already been used."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	slog.ErrorContext(ctx, "Error using account action code", "error", err)
+	return err
+}
+
+// ConfirmPasswordReset sets the password with the code from a reset email,
+// firebase hosts this page itself
+func (s *Service) ConfirmPasswordReset(ctx context.Context, p *PasswordReset) error {
+	provider, err := s.actionCodes()
+	if err != nil {
+		return err
+	}
+
+	if len(p.Password) < 8 || len(p.Password) > 128 {
+		message := "Password must be between 8 and 128 characters."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	ctx, cancel := s.authContext(ctx)
+	defer cancel()
+
+	err = provider.ResetPassword(ctx, p.Token, p.Password)
+	if err != nil {
+		return actionCodeError(ctx, err)
+	}
+
+	return nil
+}
+
+func (s *Service) ConfirmEmailVerification(ctx context.Context, v *EmailVerification) error {
+	provider, err := s.actionCodes()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := s.authContext(ctx)
+	defer cancel()
+
+	err = provider.VerifyEmail(ctx, v.Token)
+	if err != nil {
+		return actionCodeError(ctx, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/rohan031/adgytec-api/identity"
+	"github.com/rohan031/adgytec-api/v1/custom"
+)
+
+type Credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// SignIn exchanges email and password for an id token, only providers issuing
+// their own tokens support it
+func (s *Service) SignIn(ctx context.Context, c *Credentials) (string, error) {
+	provider, ok := s.Identity.(identity.PasswordSignIn)
+	if !ok {
+		message := "Password sign in is not available with the configured identity provider."
+		return "", &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
+	ctx, cancel := s.authContext(ctx)
+	defer cancel()
+
+	token, err := provider.SignIn(ctx, c.Email, c.Password)
+	if err != nil {
+		if errors.Is(err, identity.ErrInvalidCredentials) {
+			message := "The email or password provided is incorrect."
+			return "", &custom.MalformedRequest{Status: http.StatusUnauthorized, Message: message}
+		}
+
+		if errors.Is(err, identity.ErrUserDisabled) {
+			message := "The account has been suspended."
+			return "", &custom.MalformedRequest{Status: http.StatusForbidden, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error signing in user", "error", err)
+		return "", err
+	}
+
+	return token, nil
+}
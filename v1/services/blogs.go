@@ -6,15 +6,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
+	"path"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/minio/minio-go/v7"
 	"github.com/rohan031/adgytec-api/v1/custom"
 	"github.com/rohan031/adgytec-api/v1/dbqueries"
 	"golang.org/x/net/html"
@@ -57,7 +57,48 @@ type BlogMetadata struct {
 	Category string
 }
 
-func (bm *BlogMedia) UploadMedia(r *http.Request) (error, bool) {
+// blogMediaPathsValid reports whether every path is inside the media folder of
+// the blog, so one project can't write over or delete another project's objects
+func (s *Service) blogMediaPathsValid(projectId, blogId string, paths []string) bool {
+	prefix := s.objectPath("services/blogs/%v/%v/", projectId, blogId)
+	for _, p := range paths {
+		if !strings.HasPrefix(p, prefix) || path.Clean(p) != p {
+			return false
+		}
+	}
+
+	return true
+}
+
+// blogContentPathsValid reports whether every image of the content points to
+// the media folder of the blog
+func (s *Service) blogContentPathsValid(ctx context.Context, projectId, blogId, content string) bool {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		slog.ErrorContext(ctx, "error parsing html", "error", err)
+		return false
+	}
+
+	var paths []string
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "img" {
+			for _, attr := range n.Attr {
+				if attr.Key == "data-path" && attr.Val != "" {
+					paths = append(paths, attr.Val)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(doc)
+
+	return s.blogMediaPathsValid(projectId, blogId, paths)
+}
+
+func (s *Service) UploadMedia(ctx context.Context, bm *BlogMedia, r *http.Request, projectId, blogId string) (error, bool) {
 	metadataJSON := r.FormValue("metadata")
 	var metadata []FileMetaData
 	err := json.Unmarshal([]byte(metadataJSON), &metadata)
@@ -65,118 +106,138 @@ func (bm *BlogMedia) UploadMedia(r *http.Request) (error, bool) {
 		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "Invalid file metadata."}, false
 	}
 
+	paths := make([]string, 0, len(metadata))
+	for _, meta := range metadata {
+		paths = append(paths, meta.Path)
+	}
+	if !s.blogMediaPathsValid(projectId, blogId, paths) {
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "Invalid media path."}, false
+	}
+
+	// uploads run concurrently but are awaited so the request (and a graceful
+	// shutdown draining it) covers every in-flight upload
+	var mu sync.Mutex
 	isSuccess := true
+	markFailed := func() {
+		mu.Lock()
+		isSuccess = false
+		mu.Unlock()
+	}
+
+	wg := new(sync.WaitGroup)
 	for i, meta := range metadata {
+		wg.Add(1)
 		go func(index int, metadata FileMetaData) {
+			defer wg.Done()
+
 			file, header, err := r.FormFile(fmt.Sprintf("media_%d", index))
 			if err != nil {
-				log.Printf("error reteriving file: %v\n", err)
-				isSuccess = false
+				slog.ErrorContext(ctx, "error reteriving file", "error", err)
+				markFailed()
 				return
 			}
 			defer file.Close()
 
-			fileToUpload, _, contentType, size, err := handleRequestImage(file, header)
+			fileToUpload, _, contentType, size, err := handleRequestImage(ctx, file, header)
 			if err != nil {
-				isSuccess = false
+				markFailed()
 				return
 			}
 
-			_, err = spaceStorage.PutObject(
-				ctx,
-				os.Getenv("SPACE_STORAGE_BUCKET_NAME"),
-				metadata.Path,
-				fileToUpload,
-				size,
-				minio.PutObjectOptions{
-					ContentType: contentType,
-				})
+			ctx, cancel := s.storageContext(ctx)
+			defer cancel()
+
+			err = s.Storage.Put(ctx, metadata.Path, fileToUpload, size, contentType)
 			if err != nil {
-				isSuccess = false
+				markFailed()
 				return
 			}
 
 		}(i, meta)
 	}
+	wg.Wait()
 
 	return nil, isSuccess
 }
 
-func (bm *BlogMedia) DeleteMedia() error {
+func (s *Service) DeleteMedia(ctx context.Context, bm *BlogMedia, projectId, blogId string) error {
 	if len(bm.Paths) == 0 {
 		return nil
 	}
 
-	objectChan := make(chan minio.ObjectInfo)
-	go func() {
-		defer close(objectChan)
-		for _, path := range bm.Paths {
-			objectChan <- minio.ObjectInfo{Key: path}
-		}
-	}()
-
-	e := spaceStorage.RemoveObjects(ctx,
-		os.Getenv("SPACE_STORAGE_BUCKET_NAME"),
-		objectChan,
-		minio.RemoveObjectsOptions{},
-	)
-
-	isErr := false
-	for err := range e {
-		log.Printf("Error deleting objects in space storage, %v\n", err)
-		isErr = true
+	if !s.blogMediaPathsValid(projectId, blogId, bm.Paths) {
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "Invalid media path."}
 	}
 
-	if isErr {
+	err := s.Storage.Delete(ctx, bm.Paths...)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting objects in space storage", "error", err)
 		return errors.New("error deleting image from space storage")
 	}
 
 	return nil
 }
 
-func addBlogToDatabase(b *Blog, projectId, userId string, wg *sync.WaitGroup, errChan chan error) {
+func (s *Service) addBlogToDatabase(ctx context.Context, b *Blog, projectId, userId string, wg *sync.WaitGroup, errChan chan error) {
 	defer wg.Done()
 
 	args := dbqueries.CreateBlogItemArgs(b.Id, userId, projectId, b.Title,
 		b.Cover, b.Summary, b.Content, b.Author, b.Category)
 
-	_, err := db.Exec(ctx, dbqueries.CreateBlogItem, args)
+	errChan <- s.insertBlog(ctx, args)
+}
+
+func (s *Service) insertBlog(ctx context.Context, args pgx.NamedArgs) error {
+	tag, err := s.Store.Exec(ctx, dbqueries.CreateBlogItem, args)
 	if err != nil {
-		log.Printf("Error adding blog item in database: %v\n", err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid blog or category id."
+			return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error adding blog item in database", "error", err)
+		return err
 	}
-	errChan <- err
+
+	// the category belongs to another project or doesn't exist
+	if tag.RowsAffected() == 0 {
+		message := "Category with the provided ID does not exist."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	return nil
 }
 
-func (b *Blog) CreateBlogWithoutCover(projectId, userId string) error {
+func (s *Service) CreateBlogWithoutCover(ctx context.Context, b *Blog, projectId, userId string) error {
+	if !s.blogContentPathsValid(ctx, projectId, b.Id, b.Content) {
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "Invalid media path."}
+	}
+
 	args := dbqueries.CreateBlogItemArgs(b.Id, userId, projectId, b.Title,
 		b.Cover, b.Summary, b.Content, b.Author, b.Category)
 
-	_, err := db.Exec(ctx, dbqueries.CreateBlogItem, args)
-	if err != nil {
-		log.Printf("Error adding blog item in database: %v\n", err)
-	}
-
-	return err
+	return s.insertBlog(ctx, args)
 }
 
-func (b *Blog) CreateBlog(r *http.Request, projectId, userId string) error {
+func (s *Service) CreateBlog(ctx context.Context, b *Blog, r *http.Request, projectId, userId string) error {
+	if !s.blogContentPathsValid(ctx, projectId, b.Id, b.Content) {
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "Invalid media path."}
+	}
+
 	file, header, err := r.FormFile("cover")
 	if err != nil {
-		log.Printf("Error retriving file: %v\n ", err)
+		slog.ErrorContext(ctx, "Error retriving file", "error", err)
 		return err
 	}
 	defer file.Close()
 
-	fileToUpload, format, contentType, size, err := handleRequestImage(file, header)
+	fileToUpload, format, contentType, size, err := handleRequestImage(ctx, file, header)
 	if err != nil {
 		return err
 	}
 
-	objectName := fmt.Sprintf("services/blogs/%v/%v/%v.%v", projectId, b.Id, generateRandomString(), format)
-
-	if val := os.Getenv("ENV"); val == "dev" {
-		objectName = "dev/" + objectName
-	}
+	objectName := s.objectPath("services/blogs/%v/%v/%v.%v", projectId, b.Id, generateRandomString(), format)
 	b.Cover = objectName
 
 	wg := new(sync.WaitGroup)
@@ -184,14 +245,15 @@ func (b *Blog) CreateBlog(r *http.Request, projectId, userId string) error {
 
 	wg.Add(2)
 
-	go uploadImageToCloudStorage(objectName, fileToUpload, size, contentType, wg, errChan)
-	go addBlogToDatabase(b, projectId, userId, wg, errChan)
+	go s.uploadImageToCloudStorage(ctx, objectName, fileToUpload, size, contentType, wg, errChan)
+	go s.addBlogToDatabase(ctx, b, projectId, userId, wg, errChan)
 
 	wg.Wait()
 	close(errChan)
 
 	for err := range errChan {
 		if err != nil {
+			s.runInBackground(ctx, func(ctx context.Context) { s.deleteFromCloudStorage(ctx, objectName) })
 			return err
 		}
 	}
@@ -199,19 +261,19 @@ func (b *Blog) CreateBlog(r *http.Request, projectId, userId string) error {
 	return nil
 }
 
-func (b *Blog) GetBlogsByProjectId(projectId, createdAt string, limit int) (*[]BlogSummary, *PageInfo, error) {
+func (s *Service) GetBlogsByProjectId(ctx context.Context, b *Blog, projectId, createdAt string, limit int) (*[]BlogSummary, *PageInfo, error) {
 	args := dbqueries.GetBlogsByProjectIdArgs(projectId, createdAt, limit+1)
-	rows, err := db.Query(ctx, dbqueries.GetBlogsByProjectId, args)
+	rows, err := s.Store.Query(ctx, dbqueries.GetBlogsByProjectId, args)
 
 	if err != nil {
-		log.Printf("Error fetching blogs from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching blogs from db", "error", err)
 		return nil, nil, err
 	}
 	defer rows.Close()
 
 	blogs, err := pgx.CollectRows(rows, pgx.RowToStructByName[BlogSummary])
 	if err != nil {
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, nil, err
 	}
 
@@ -235,7 +297,7 @@ func (b *Blog) GetBlogsByProjectId(projectId, createdAt string, limit int) (*[]B
 		if len(img) > 0 {
 			wg.Add(1)
 
-			go generatePresignedUrl(img, ind, week, wg, urlChan)
+			go s.generatePresignedUrl(ctx, img, ind, week, wg, urlChan)
 		}
 	}
 
@@ -250,19 +312,19 @@ func (b *Blog) GetBlogsByProjectId(projectId, createdAt string, limit int) (*[]B
 	return &blogs, &pageInfo, nil
 }
 
-func (b *Blog) GetBlogsByCategoryId(projectId, categoryId, createdAt string, limit int) (*[]BlogSummary, *PageInfo, error) {
+func (s *Service) GetBlogsByCategoryId(ctx context.Context, b *Blog, projectId, categoryId, createdAt string, limit int) (*[]BlogSummary, *PageInfo, error) {
 	args := dbqueries.GetBlogsByCategoryIdArgs(projectId, categoryId, createdAt, limit+1)
-	rows, err := db.Query(ctx, dbqueries.GetBlogsByCategoryId, args)
+	rows, err := s.Store.Query(ctx, dbqueries.GetBlogsByCategoryId, args)
 
 	if err != nil {
-		log.Printf("Error fetching blogs from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching blogs from db", "error", err)
 		return nil, nil, err
 	}
 	defer rows.Close()
 
 	blogs, err := pgx.CollectRows(rows, pgx.RowToStructByName[BlogSummary])
 	if err != nil {
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, nil, err
 	}
 
@@ -286,7 +348,7 @@ func (b *Blog) GetBlogsByCategoryId(projectId, categoryId, createdAt string, lim
 		if len(img) > 0 {
 			wg.Add(1)
 
-			go generatePresignedUrl(img, ind, week, wg, urlChan)
+			go s.generatePresignedUrl(ctx, img, ind, week, wg, urlChan)
 		}
 	}
 
@@ -301,11 +363,17 @@ func (b *Blog) GetBlogsByCategoryId(projectId, categoryId, createdAt string, lim
 	return &blogs, &pageInfo, nil
 }
 
-func (b *Blog) GetBlogById() (*Blog, error) {
-	args := dbqueries.GetBlogsByIdArgs(b.Id)
-	rows, err := db.Query(ctx, dbqueries.GetBlogById, args)
+func (s *Service) GetBlogById(ctx context.Context, b *Blog, projectId string) (*Blog, error) {
+	args := dbqueries.GetBlogsByIdArgs(b.Id, projectId)
+	rows, err := s.Store.Query(ctx, dbqueries.GetBlogById, args)
 	if err != nil {
-		log.Printf("Error fetching blog from db: %v\n", err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid blog id."
+			return nil, &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error fetching blog from db", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -316,21 +384,21 @@ func (b *Blog) GetBlogById() (*Blog, error) {
 			message := "Blog with the provided ID does not exist."
 			return nil, &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
 		}
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, err
 	}
 
-	cover, err := spaceStorage.PresignedGetObject(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), blog.Cover, week, nil)
+	cover, err := s.Storage.SignedURL(ctx, blog.Cover, week)
 	if err != nil {
-		log.Printf("error generating presigned url for cover image: %v\n", err)
+		slog.ErrorContext(ctx, "error generating presigned url for cover image", "error", err)
 	} else {
-		blog.Cover = cover.String()
+		blog.Cover = cover
 	}
 
 	// copied will reread it
 	doc, err := html.Parse(bytes.NewReader([]byte(blog.Content)))
 	if err != nil {
-		log.Printf("error parsing html: %v\n", err)
+		slog.ErrorContext(ctx, "error parsing html", "error", err)
 		return &blog, err
 	}
 
@@ -346,19 +414,25 @@ func (b *Blog) GetBlogById() (*Blog, error) {
 				}
 			}
 			if dataKey != "" {
-				// Generate presigned URL
-				isPresigned := true
-				presignedURL, err := spaceStorage.PresignedGetObject(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), dataKey, week, nil)
-				if err != nil {
-					log.Printf("Can't genrate url for image: %v\n", err)
-					isPresigned = false
+				// Generate presigned URL, only for media of the blog so content
+				// saved before paths were checked can't expose other objects
+				isPresigned := false
+				var presignedURL string
+				if s.blogMediaPathsValid(projectId, blog.Id, []string{dataKey}) {
+					var err error
+					presignedURL, err = s.Storage.SignedURL(ctx, dataKey, week)
+					if err != nil {
+						slog.ErrorContext(ctx, "Can't genrate url for image", "error", err)
+					} else {
+						isPresigned = true
+					}
 				}
 				// Add or update src attribute
 				hasSrc := false
 				for i := 0; i < len(n.Attr); i++ {
 					if n.Attr[i].Key == "src" {
 						if isPresigned {
-							n.Attr[i].Val = presignedURL.String()
+							n.Attr[i].Val = presignedURL
 						} else {
 							n.Attr[i].Val = "https://images.unsplash.com/photo-1713171158509-f2a6582581a0?q=80&w=2070&auto=format&fit=crop&ixlib=rb-4.0.3&ixid=M3wxMjA3fDB8MHxwaG90by1wYWdlfHx8fGVufDB8fHx8fA%3D%3D"
 						}
@@ -368,7 +442,7 @@ func (b *Blog) GetBlogById() (*Blog, error) {
 				}
 				if !hasSrc {
 					if isPresigned {
-						n.Attr = append(n.Attr, html.Attribute{Key: "src", Val: presignedURL.String()})
+						n.Attr = append(n.Attr, html.Attribute{Key: "src", Val: presignedURL})
 					} else {
 						n.Attr = append(n.Attr, html.Attribute{Key: "src", Val: "https://images.unsplash.com/photo-1713171158509-f2a6582581a0?q=80&w=2070&auto=format&fit=crop&ixlib=rb-4.0.3&ixid=M3wxMjA3fDB8MHxwaG90by1wYWdlfHx8fGVufDB8fHx8fA%3D%3D"})
 					}
@@ -384,7 +458,7 @@ func (b *Blog) GetBlogById() (*Blog, error) {
 	var buf bytes.Buffer
 	err = html.Render(&buf, doc)
 	if err != nil {
-		log.Printf("error getting html from buffer: %v\n", err)
+		slog.ErrorContext(ctx, "error getting html from buffer", "error", err)
 		return &blog, nil
 	}
 	updatedHTMLContent := buf.String()
@@ -393,9 +467,9 @@ func (b *Blog) GetBlogById() (*Blog, error) {
 	return &blog, nil
 }
 
-func (bm *BlogMetadata) PatchBlogMetadataById() error {
-	args := dbqueries.PatchBlogMetadataByIdArgs(bm.Title, bm.Summary, bm.Id, bm.Category)
-	_, err := db.Exec(ctx, dbqueries.PatchBlogMetadataById, args)
+func (s *Service) PatchBlogMetadataById(ctx context.Context, bm *BlogMetadata, projectId string) error {
+	args := dbqueries.PatchBlogMetadataByIdArgs(bm.Title, bm.Summary, bm.Id, bm.Category, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.PatchBlogMetadataById, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -406,15 +480,21 @@ func (bm *BlogMetadata) PatchBlogMetadataById() error {
 			}
 		}
 
-		log.Printf("Error updating blog data: %v\n", err)
+		slog.ErrorContext(ctx, "Error updating blog data", "error", err)
 		return err
 	}
+
+	if tag.RowsAffected() == 0 {
+		message := "Blog or category with the provided ID does not exist."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
 	return nil
 }
 
-func deleteBlogFromDatabase(b *Blog) error {
-	args := dbqueries.DeleteBlogByIdArgs(b.Id)
-	_, err := db.Exec(ctx, dbqueries.DeleteBlogById, args)
+func (s *Service) deleteBlogFromDatabase(ctx context.Context, b *Blog, projectId string) error {
+	args := dbqueries.DeleteBlogByIdArgs(b.Id, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.DeleteBlogById, args)
 
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -426,60 +506,44 @@ func deleteBlogFromDatabase(b *Blog) error {
 			}
 		}
 
-		log.Printf("Error deleting blog data: %v\n", err)
+		slog.ErrorContext(ctx, "Error deleting blog data", "error", err)
+		return err
 	}
 
-	return err
-}
-
-func deleteBlogMedia(projectId, blogId string) {
-	mediaPrefix := fmt.Sprintf("services/blogs/%v/%v", projectId, blogId)
-	if os.Getenv("ENV") == "dev" {
-		mediaPrefix = "dev/" + mediaPrefix
+	if tag.RowsAffected() == 0 {
+		message := "Blog with the provided ID does not exist."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
 	}
-	objectsCh := make(chan minio.ObjectInfo)
 
-	go func() {
-		defer close(objectsCh)
-
-		opts := minio.ListObjectsOptions{
-			Recursive: true,
-			Prefix:    mediaPrefix,
-		}
-		// List all objects from a bucket-name with a matching prefix.
-		for object := range spaceStorage.ListObjects(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), opts) {
-			if object.Err != nil {
-				log.Printf("error listing object: %v\n", object.Err)
-			} else {
-				objectsCh <- object
-			}
-		}
-	}()
+	return nil
+}
 
-	opts := minio.RemoveObjectsOptions{}
+func (s *Service) deleteBlogMedia(ctx context.Context, projectId, blogId string) {
+	mediaPrefix := s.objectPath("services/blogs/%v/%v", projectId, blogId)
 
-	for rErr := range spaceStorage.RemoveObjects(context.Background(), os.Getenv("SPACE_STORAGE_BUCKET_NAME"), objectsCh, opts) {
-		log.Println("Error detected during deletion: ", rErr)
+	err := s.Storage.DeletePrefix(ctx, mediaPrefix)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting blog media", "prefix", mediaPrefix, "error", err)
 	}
 }
 
-func (b *Blog) DeleteBlogById(projectId string) error {
+func (s *Service) DeleteBlogById(ctx context.Context, b *Blog, projectId string) error {
 
-	err := deleteBlogFromDatabase(b)
+	err := s.deleteBlogFromDatabase(ctx, b, projectId)
 	if err == nil {
-		go deleteBlogMedia(projectId, b.Id)
+		s.runInBackground(ctx, func(ctx context.Context) { s.deleteBlogMedia(ctx, projectId, b.Id) })
 	}
 
 	return err
 }
 
-func handleBlogCoverDatabase(cover, blogid string, wg *sync.WaitGroup, errChan chan error) {
+func (s *Service) handleBlogCoverDatabase(ctx context.Context, cover, blogid, projectId string, wg *sync.WaitGroup, errChan chan error) {
 	defer wg.Done()
 
-	args := dbqueries.PatchBlogCoverArgs(blogid, cover)
-	rows, err := db.Query(ctx, dbqueries.PatchBlogCover, args)
+	args := dbqueries.PatchBlogCoverArgs(blogid, cover, projectId)
+	rows, err := s.Store.Query(ctx, dbqueries.PatchBlogCover, args)
 	if err != nil {
-		log.Printf("error updating cover image in db: %v\n", err)
+		slog.ErrorContext(ctx, "error updating cover image in db", "error", err)
 		errChan <- err
 		return
 	}
@@ -504,35 +568,31 @@ func handleBlogCoverDatabase(cover, blogid string, wg *sync.WaitGroup, errChan c
 			}
 		}
 
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		errChan <- nil
 		return
 	}
 
-	go deleteFromCloudStorage(prevPath.Image)
+	s.runInBackground(ctx, func(ctx context.Context) { s.deleteFromCloudStorage(ctx, prevPath.Image) })
 
 	errChan <- nil
 }
 
-func (b *Blog) PatchBlogCover(r *http.Request, projectId string) error {
+func (s *Service) PatchBlogCover(ctx context.Context, b *Blog, r *http.Request, projectId string) error {
 	file, header, err := r.FormFile("cover")
 	if err != nil {
-		log.Printf("Error retriving file: %v\n ", err)
+		slog.ErrorContext(ctx, "Error retriving file", "error", err)
 		return err
 	}
 
 	defer file.Close()
 
-	fileToUpload, format, contentType, size, err := handleRequestImage(file, header)
+	fileToUpload, format, contentType, size, err := handleRequestImage(ctx, file, header)
 	if err != nil {
 		return err
 	}
 
-	objectName := fmt.Sprintf("services/blogs/%v/%v/%v.%v", projectId, b.Id, generateRandomString(), format)
-
-	if val := os.Getenv("ENV"); val == "dev" {
-		objectName = "dev/" + objectName
-	}
+	objectName := s.objectPath("services/blogs/%v/%v/%v.%v", projectId, b.Id, generateRandomString(), format)
 	b.Cover = objectName
 
 	wg := new(sync.WaitGroup)
@@ -540,14 +600,15 @@ func (b *Blog) PatchBlogCover(r *http.Request, projectId string) error {
 
 	wg.Add(2)
 
-	go uploadImageToCloudStorage(objectName, fileToUpload, size, contentType, wg, errChan)
-	go handleBlogCoverDatabase(objectName, b.Id, wg, errChan)
+	go s.uploadImageToCloudStorage(ctx, objectName, fileToUpload, size, contentType, wg, errChan)
+	go s.handleBlogCoverDatabase(ctx, objectName, b.Id, projectId, wg, errChan)
 
 	wg.Wait()
 	close(errChan)
 
 	for err := range errChan {
 		if err != nil {
+			s.runInBackground(ctx, func(ctx context.Context) { s.deleteFromCloudStorage(ctx, objectName) })
 			return err
 		}
 	}
@@ -555,9 +616,13 @@ func (b *Blog) PatchBlogCover(r *http.Request, projectId string) error {
 	return nil
 }
 
-func (b *Blog) PatchBlogContent() error {
-	args := dbqueries.PatchBlogContentArgs(b.Id, b.Content)
-	_, err := db.Exec(ctx, dbqueries.PatchBlogContent, args)
+func (s *Service) PatchBlogContent(ctx context.Context, b *Blog, projectId string) error {
+	if !s.blogContentPathsValid(ctx, projectId, b.Id, b.Content) {
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "Invalid media path."}
+	}
+
+	args := dbqueries.PatchBlogContentArgs(b.Id, b.Content, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.PatchBlogContent, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -568,7 +633,14 @@ func (b *Blog) PatchBlogContent() error {
 			}
 		}
 
-		log.Printf("error updating blog contnet: %v\n", err)
+		slog.ErrorContext(ctx, "error updating blog contnet", "error", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		message := "Blog with the provided ID does not exist."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
 	}
-	return err
+
+	return nil
 }
@@ -1,9 +1,10 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 
@@ -26,7 +27,7 @@ type CategoryId struct {
 	CategoryId string `json:"categoryId" db:"category_id"`
 }
 
-func (c *Category) PostCategoryByProjectId(projectId string) (*CategoryId, error) {
+func (s *Service) PostCategoryByProjectId(ctx context.Context, c *Category, projectId string) (*CategoryId, error) {
 	if c.ParentId == "" || c.CategoryName == "" {
 		return nil, &custom.MalformedRequest{
 			Status:  http.StatusBadRequest,
@@ -35,7 +36,7 @@ func (c *Category) PostCategoryByProjectId(projectId string) (*CategoryId, error
 	}
 
 	args := dbqueries.PostCategoryByProjectIdArgs(c.ParentId, projectId, c.CategoryName)
-	row, err := db.Query(ctx, dbqueries.PostCategoryByProjectId, args)
+	row, err := s.Store.Query(ctx, dbqueries.PostCategoryByProjectId, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -64,21 +65,37 @@ func (c *Category) PostCategoryByProjectId(projectId string) (*CategoryId, error
 			}
 		}
 
-		log.Printf("Error creating new category: %v/n", err)
+		slog.ErrorContext(ctx, "Error creating new category", "error", err)
 		return nil, err
 	}
 	defer row.Close()
 
 	category, err := pgx.CollectOneRow(row, pgx.RowToStructByName[CategoryId])
 	if err != nil {
-		log.Printf("error reading row: %v\n", err)
+		// the parent belongs to another project or doesn't exist
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &custom.MalformedRequest{
+				Status:  http.StatusBadRequest,
+				Message: "Invalid parent for the category.",
+			}
+		}
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			return nil, &custom.MalformedRequest{
+				Status:  http.StatusBadRequest,
+				Message: "Invalid category details.",
+			}
+		}
+
+		slog.ErrorContext(ctx, "error reading row", "error", err)
 		return nil, err
 	}
 
 	return &category, nil
 }
 
-func (c *Category) PatchCategoryById(categoryId string) error {
+func (s *Service) PatchCategoryById(ctx context.Context, c *Category, categoryId, projectId string) error {
 	if c.CategoryName == "" {
 		return &custom.MalformedRequest{
 			Status:  http.StatusBadRequest,
@@ -86,8 +103,8 @@ func (c *Category) PatchCategoryById(categoryId string) error {
 		}
 	}
 
-	args := dbqueries.PatchCategoryByIdArgs(c.CategoryName, categoryId)
-	_, err := db.Exec(ctx, dbqueries.PatchCategoryById, args)
+	args := dbqueries.PatchCategoryByIdArgs(c.CategoryName, categoryId, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.PatchCategoryById, args)
 
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -102,18 +119,23 @@ func (c *Category) PatchCategoryById(categoryId string) error {
 			}
 		}
 
-		log.Printf("Error updating category detail: %v/n", err)
+		slog.ErrorContext(ctx, "Error updating category detail", "error", err)
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		message := "Category with the provided ID does not exist."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
 	return nil
 }
 
-func (c *Category) GetCategoryByProjectId(projectId string) (*CategoryDetail, error) {
+func (s *Service) GetCategoryByProjectId(ctx context.Context, c *Category, projectId string) (*CategoryDetail, error) {
 	args := dbqueries.GetCategoryByProjectIdArgs(projectId)
-	row, err := db.Query(ctx, dbqueries.GetCategoryByProjectId, args)
+	row, err := s.Store.Query(ctx, dbqueries.GetCategoryByProjectId, args)
 	if err != nil {
-		log.Printf("Error fetching category details from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching category details from db", "error", err)
 		return nil, err
 	}
 	defer row.Close()
@@ -133,16 +155,16 @@ func (c *Category) GetCategoryByProjectId(projectId string) (*CategoryDetail, er
 			}
 		}
 
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, err
 	}
 
 	return &categories, err
 }
 
-func (c *Category) DeleteCategoryById(categoryId string) error {
-	args := dbqueries.DeleteCategoryByIdArgs(categoryId)
-	_, err := db.Exec(ctx, dbqueries.DeleteCategoryById, args)
+func (s *Service) DeleteCategoryById(ctx context.Context, c *Category, categoryId, projectId string) error {
+	args := dbqueries.DeleteCategoryByIdArgs(categoryId, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.DeleteCategoryById, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -156,9 +178,14 @@ func (c *Category) DeleteCategoryById(categoryId string) error {
 			}
 		}
 
-		log.Printf("Error deleting category from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error deleting category from db", "error", err)
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		message := "Category with the provided ID does not exist."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
 	return nil
 }
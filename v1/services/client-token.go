@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rohan031/adgytec-api/v1/custom"
+	"github.com/rohan031/adgytec-api/v1/dbqueries"
+	"github.com/rohan031/adgytec-api/v1/validation"
+)
+
+// ClientToken is the stored part of a client token, Token is only set right
+// after the token is created or rotated
+type ClientToken struct {
+	Id        string     `json:"id" db:"token_id"`
+	Label     string     `json:"label" db:"label"`
+	Scopes    []string   `json:"scopes" db:"scopes"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	ExpiresAt *time.Time `json:"expiresAt" db:"expires_at"`
+	RevokedAt *time.Time `json:"revokedAt" db:"revoked_at"`
+	Token     string     `json:"token,omitempty" db:"-"`
+}
+
+type ClientTokenInput struct {
+	Label     string     `json:"label"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// HashClientToken is how client tokens are stored and looked up
+func HashClientToken(token string) string {
+	return hashToken(token)
+}
+
+func newClientToken(ctx context.Context) (token, hash string, err error) {
+	token, err = generateSecureToken(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, HashClientToken(token), nil
+}
+
+// validate checks the label and expiry, and the scopes when withScopes is set,
+// as scopes can't change after a token is created
+func (t *ClientTokenInput) validate(withScopes bool) error {
+	t.Label = strings.TrimSpace(t.Label)
+	if t.Label == "" || len(t.Label) > 100 {
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "Client token label must be between 1 and 100 characters."}
+	}
+
+	if t.ExpiresAt != nil {
+		if !t.ExpiresAt.After(time.Now()) {
+			return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "Client token expiry must be in the future."}
+		}
+
+		// timestamps are stored without a zone
+		expiresAt := t.ExpiresAt.UTC()
+		t.ExpiresAt = &expiresAt
+	}
+
+	if !withScopes {
+		return nil
+	}
+
+	if len(t.Scopes) == 0 {
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "Client token needs at least one scope."}
+	}
+
+	seen := make(map[string]bool, len(t.Scopes))
+	scopes := make([]string, 0, len(t.Scopes))
+	for _, scope := range t.Scopes {
+		if !validation.ValidateScope(scope) {
+			return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "Invalid client token scope: " + scope}
+		}
+
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	t.Scopes = scopes
+
+	return nil
+}
+
+func clientTokenNotFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		message := "Client token with the provided ID does not exist."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+		message := "Invalid client token id."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	return nil
+}
+
+func (s *Service) CreateClientToken(ctx context.Context, t *ClientTokenInput, projectId string) (*ClientToken, error) {
+	err := t.validate(true)
+	if err != nil {
+		return nil, err
+	}
+
+	token, hash, err := newClientToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := dbqueries.CreateClientTokenArgs(projectId, hash, t.Label, t.Scopes, t.ExpiresAt)
+	rows, err := s.Store.Query(ctx, dbqueries.CreateClientToken, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating client token", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	created, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[ClientToken])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			// foreign key violation code 23503
+			if pgErr.Code == "23503" {
+				message := "Project with the provided ID does not exist."
+				return nil, &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+			}
+
+			if pgErr.Code == "22P02" {
+				message := "Invalid project id."
+				return nil, &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+			}
+		}
+
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, err
+	}
+
+	created.Token = token
+	return &created, nil
+}
+
+func (s *Service) GetClientTokensByProjectId(ctx context.Context, t *ClientToken, projectId string) (*[]ClientToken, error) {
+	args := dbqueries.GetClientTokensByProjectIdArgs(projectId)
+	rows, err := s.Store.Query(ctx, dbqueries.GetClientTokensByProjectId, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching client tokens from db", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens, err := pgx.CollectRows(rows, pgx.RowToStructByName[ClientToken])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid project id."
+			return nil, &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, err
+	}
+
+	return &tokens, nil
+}
+
+// PatchClientTokenById changes the label and expiry of an active token, a nil
+// expiry makes the token never expire
+func (s *Service) PatchClientTokenById(ctx context.Context, t *ClientTokenInput, tokenId, projectId string) (*ClientToken, error) {
+	err := t.validate(false)
+	if err != nil {
+		return nil, err
+	}
+
+	args := dbqueries.PatchClientTokenByIdArgs(tokenId, projectId, t.Label, t.ExpiresAt)
+	rows, err := s.Store.Query(ctx, dbqueries.PatchClientTokenById, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating client token", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	updated, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[ClientToken])
+	if err != nil {
+		if mr := clientTokenNotFound(err); mr != nil {
+			return nil, mr
+		}
+
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// RevokeClientTokenById stops the token from working immediately, the row is
+// kept so the dashboard can show when it was revoked
+func (s *Service) RevokeClientTokenById(ctx context.Context, t *ClientToken, tokenId, projectId string) error {
+	args := dbqueries.RevokeClientTokenByIdArgs(tokenId, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.RevokeClientTokenById, args)
+	if err != nil {
+		if mr := clientTokenNotFound(err); mr != nil {
+			return mr
+		}
+
+		slog.ErrorContext(ctx, "Error revoking client token", "error", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return clientTokenNotFound(pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// RotateClientTokenById revokes the token and returns a replacement with the
+// same label, scopes and expiry
+func (s *Service) RotateClientTokenById(ctx context.Context, t *ClientToken, tokenId, projectId string) (*ClientToken, error) {
+	token, hash, err := newClientToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := dbqueries.RotateClientTokenByIdArgs(tokenId, projectId, hash)
+	rows, err := s.Store.Query(ctx, dbqueries.RotateClientTokenById, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error rotating client token", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	rotated, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[ClientToken])
+	if err != nil {
+		if mr := clientTokenNotFound(err); mr != nil {
+			return nil, mr
+		}
+
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, err
+	}
+
+	rotated.Token = token
+	return &rotated, nil
+}
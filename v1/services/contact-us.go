@@ -1,10 +1,15 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rohan031/adgytec-api/v1/custom"
 	"github.com/rohan031/adgytec-api/v1/dbqueries"
-	"log"
+	"log/slog"
+	"net/http"
 	"time"
 )
 
@@ -14,23 +19,23 @@ type ContactUs struct {
 	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
 }
 
-func (c *ContactUs) PostContactUs(projectId string, data map[string]interface{}) error {
+func (s *Service) PostContactUs(ctx context.Context, c *ContactUs, projectId string, data map[string]interface{}) error {
 	args := dbqueries.CreateContactUsItemArgs(projectId, data)
 
-	_, err := db.Exec(ctx, dbqueries.CreateContactUsItem, args)
+	_, err := s.Store.Exec(ctx, dbqueries.CreateContactUsItem, args)
 	if err != nil {
-		log.Printf("Error adding contact us record to database: %v\n", err)
+		slog.ErrorContext(ctx, "Error adding contact us record to database", "error", err)
 	}
 
 	return err
 }
 
-func (c *ContactUs) GetContactUs(projectId, cursor string, limit int) (*[]ContactUs, *PageInfo, error) {
+func (s *Service) GetContactUs(ctx context.Context, c *ContactUs, projectId, cursor string, limit int) (*[]ContactUs, *PageInfo, error) {
 	args := dbqueries.GetContactUsItemsArgs(projectId, cursor, limit+1)
-	rows, err := db.Query(ctx, dbqueries.GetContactUsItems, args)
+	rows, err := s.Store.Query(ctx, dbqueries.GetContactUsItems, args)
 
 	if err != nil {
-		log.Printf("Error fetching contact us items from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching contact us items from db", "error", err)
 		return nil, nil, err
 	}
 
@@ -38,7 +43,7 @@ func (c *ContactUs) GetContactUs(projectId, cursor string, limit int) (*[]Contac
 
 	items, err := pgx.CollectRows(rows, pgx.RowToStructByName[ContactUs])
 	if err != nil {
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, nil, err
 	}
 
@@ -56,14 +61,25 @@ func (c *ContactUs) GetContactUs(projectId, cursor string, limit int) (*[]Contac
 	return &items, &pageInfo, nil
 }
 
-func (c *ContactUs) DeleteContactUsById() error {
-	args := dbqueries.DeleteContactUsByIdArgs(c.Id)
+func (s *Service) DeleteContactUsById(ctx context.Context, c *ContactUs, projectId string) error {
+	args := dbqueries.DeleteContactUsByIdArgs(c.Id, projectId)
 
-	_, err := db.Exec(ctx, dbqueries.DeleteContactUsById, args)
+	tag, err := s.Store.Exec(ctx, dbqueries.DeleteContactUsById, args)
 	if err != nil {
-		log.Printf("Error deleting contact us record from db: %v\n", err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid record id."
+			return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error deleting contact us record from db", "error", err)
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		message := "Record with the provided ID does not exist."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
 	return nil
 }
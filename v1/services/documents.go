@@ -1,16 +1,14 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/minio/minio-go/v7"
 	"github.com/rohan031/adgytec-api/v1/custom"
 	"github.com/rohan031/adgytec-api/v1/dbqueries"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"time"
 )
 
@@ -20,7 +18,7 @@ type DocumentCover struct {
 	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 }
 
-func (d *DocumentCover) PostDocumentCoverByProjectId(projectId, userId string) error {
+func (s *Service) PostDocumentCoverByProjectId(ctx context.Context, d *DocumentCover, projectId, userId string) error {
 	if d.Name == "" {
 		return &custom.MalformedRequest{
 			Status:  http.StatusBadRequest,
@@ -29,63 +27,52 @@ func (d *DocumentCover) PostDocumentCoverByProjectId(projectId, userId string) e
 	}
 
 	args := dbqueries.PostDocumentCoverByProjectIdArgs(projectId, d.Name, userId)
-	_, err := db.Exec(ctx, dbqueries.PostDocumentCoverByProjectId, args)
+	_, err := s.Store.Exec(ctx, dbqueries.PostDocumentCoverByProjectId, args)
 	if err != nil {
-		log.Printf("Error adding document cover in database: %v\n", err)
+		slog.ErrorContext(ctx, "Error adding document cover in database", "error", err)
 		return err
 	}
 
 	return nil
 }
 
-func deleteDocumentsFromDocumentCover(coverId, projectId string) {
-	mediaPrefix := fmt.Sprintf("services/documents/%v/%v/", projectId, coverId)
-	if val := os.Getenv("ENV"); val == "dev" {
-		mediaPrefix = "dev/" + mediaPrefix
-	}
-	objectsCh := make(chan minio.ObjectInfo)
-
-	go func() {
-		defer close(objectsCh)
-
-		opts := minio.ListObjectsOptions{
-			Recursive: true,
-			Prefix:    mediaPrefix,
-		}
-		// List all objects from a bucket-name with a matching prefix.
-		for object := range spaceStorage.ListObjects(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), opts) {
-			if object.Err != nil {
-				log.Printf("error listing object: %v\n", object.Err)
-			} else {
-				objectsCh <- object
-			}
-		}
-	}()
+func (s *Service) deleteDocumentsFromDocumentCover(ctx context.Context, coverId, projectId string) {
+	mediaPrefix := s.objectPath("services/documents/%v/%v/", projectId, coverId)
 
-	opts := minio.RemoveObjectsOptions{}
-
-	for rErr := range spaceStorage.RemoveObjects(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), objectsCh, opts) {
-		fmt.Println("Error detected during deletion: ", rErr)
+	err := s.Storage.DeletePrefix(ctx, mediaPrefix)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting documents", "prefix", mediaPrefix, "error", err)
 	}
 }
 
-func (d *DocumentCover) DeleteDocumentCoverById(projectId string) error {
-	args := dbqueries.DeleteDocumentCoverByIdArgs(d.Id)
-	_, err := db.Exec(ctx, dbqueries.DeleteDocumentCoverBytId, args)
+func (s *Service) DeleteDocumentCoverById(ctx context.Context, d *DocumentCover, projectId string) error {
+	args := dbqueries.DeleteDocumentCoverByIdArgs(d.Id, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.DeleteDocumentCoverBytId, args)
 	if err != nil {
-		log.Printf("Error deleting document cover: %v\n", err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid document cover to delete."
+			return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error deleting document cover", "error", err)
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		message := "Document cover with the provided ID does not exist."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
 	// delete everything in that document cover
-	go deleteDocumentsFromDocumentCover(d.Id, projectId)
+	s.runInBackground(ctx, func(ctx context.Context) { s.deleteDocumentsFromDocumentCover(ctx, d.Id, projectId) })
 
 	return nil
 }
 
-func (d *DocumentCover) PatchDocumentCoverById() error {
-	args := dbqueries.PatchDocumentCoverByIdArgs(d.Id, d.Name)
-	_, err := db.Exec(ctx, dbqueries.PatchDocumentCoverById, args)
+func (s *Service) PatchDocumentCoverById(ctx context.Context, d *DocumentCover, projectId string) error {
+	args := dbqueries.PatchDocumentCoverByIdArgs(d.Id, d.Name, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.PatchDocumentCoverById, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -96,25 +83,31 @@ func (d *DocumentCover) PatchDocumentCoverById() error {
 			}
 		}
 
-		log.Printf("Error updating document cover data: %v\n", err)
+		slog.ErrorContext(ctx, "Error updating document cover data", "error", err)
 		return err
 	}
+
+	if tag.RowsAffected() == 0 {
+		message := "Document cover with the provided ID does not exist."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
 	return nil
 }
 
-func (d *DocumentCover) GetDocumentCoverByProjectId(projectId, cursor string) (*[]DocumentCover, error) {
+func (s *Service) GetDocumentCoverByProjectId(ctx context.Context, d *DocumentCover, projectId, cursor string) (*[]DocumentCover, error) {
 	args := dbqueries.GetDocumentCoverByProjectIdArgs(projectId, cursor)
-	rows, err := db.Query(ctx, dbqueries.GetDocumentCoverByProjectId, args)
+	rows, err := s.Store.Query(ctx, dbqueries.GetDocumentCoverByProjectId, args)
 
 	if err != nil {
-		log.Printf("Error fetching document cover from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching document cover from db", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 
 	documentCovers, err := pgx.CollectRows(rows, pgx.RowToStructByName[DocumentCover])
 	if err != nil {
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, err
 	}
 
@@ -2,63 +2,39 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"html/template"
-	"log"
-	"net/smtp"
-	"os"
-)
-
-type Constraint interface {
-	any
-}
+	"log/slog"
 
-func SendEmail[T Constraint](data T, templatePath string, to []string, subject string, isPrivate ...int) error {
-	smtpServer := "smtp.gmail.com"
-	smtpPort := "587"
-
-	from := os.Getenv("FROM")
-	password := os.Getenv("PASS")
-
-	if len(isPrivate) >= 1 {
-		from = os.Getenv("PVTEMAIL")
-		password = os.Getenv("PVTPASS")
-	}
+	"github.com/rohan031/adgytec-api/mailer"
+)
 
+func (s *Service) SendEmail(ctx context.Context, data any, templatePath string, to []string, subject string, isPrivate ...int) error {
 	// mail content
 	templateData := data
 
 	t, err := template.ParseFiles(templatePath)
 	if err != nil {
-		log.Println("error trying to parse email template", err)
+		slog.ErrorContext(ctx, "error trying to parse email template", "template", templatePath, "error", err)
 		return err
 	}
 
 	var body bytes.Buffer
 	if err := t.Execute(&body, templateData); err != nil {
-		log.Println("error trying to execute email template", err)
+		slog.ErrorContext(ctx, "error trying to execute email template", "template", templatePath, "error", err)
 		return err
 	}
 
-	auth := smtp.PlainAuth("", from, password, smtpServer)
-
-	mime := "MIME-version: 1.0;\nContent-Type: multipart/related; boundary=\"MIMEBOUNDARY\"\n\n"
-	mime += "--MIMEBOUNDARY\n"
-	mime += "Content-Type: text/html; charset=\"UTF-8\"\n\n"
-	mime += body.String() + "\n"
-	mime += "--MIMEBOUNDARY--"
-
-	toHeader := "To: " + to[0] + "\r\n"
-	subjectHeader := "Subject: " + subject + "\r\n"
-	headers := toHeader + subjectHeader
-
-	msg := []byte(headers + mime)
-
-	err = smtp.SendMail(smtpServer+":"+smtpPort, auth, from, to, msg)
+	err = s.Mailer.Send(ctx, mailer.Message{
+		To:      to,
+		Subject: subject,
+		HTML:    body.String(),
+		Private: len(isPrivate) >= 1,
+	})
 	if err != nil {
-		log.Println("error sending mail", err)
+		slog.ErrorContext(ctx, "error sending mail", "error", err)
 		return err
 	}
 
 	return nil
-
 }
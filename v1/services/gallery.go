@@ -1,17 +1,15 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/minio/minio-go/v7"
 	"github.com/rohan031/adgytec-api/v1/custom"
 	"github.com/rohan031/adgytec-api/v1/dbqueries"
 )
@@ -37,11 +35,11 @@ type PhotoDelete struct {
 	Id []string
 }
 
-func addAlbumToDatabase(a *Album, userId, projectId string, wg *sync.WaitGroup, errChan chan error) {
+func (s *Service) addAlbumToDatabase(ctx context.Context, a *Album, userId, projectId string, wg *sync.WaitGroup, errChan chan error) {
 	defer wg.Done()
 
 	args := dbqueries.PostAlbumByProjectIdArgs(a.Id, projectId, userId, a.Name, a.Cover)
-	_, err := db.Exec(ctx, dbqueries.PostAlbumByProjectId, args)
+	_, err := s.Store.Exec(ctx, dbqueries.PostAlbumByProjectId, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -61,31 +59,27 @@ func addAlbumToDatabase(a *Album, userId, projectId string, wg *sync.WaitGroup,
 			}
 		}
 
-		log.Printf("Error adding album in database: %v\n", err)
+		slog.ErrorContext(ctx, "Error adding album in database", "error", err)
 	}
 
 	errChan <- err
 }
 
-func (a *Album) CreateAlbum(r *http.Request, projectId, userId string) error {
+func (s *Service) CreateAlbum(ctx context.Context, a *Album, r *http.Request, projectId, userId string) error {
 	file, header, err := r.FormFile("cover")
 	if err != nil {
-		log.Printf("Error retriving file: %v\n ", err)
+		slog.ErrorContext(ctx, "Error retriving file", "error", err)
 		return err
 	}
 	defer file.Close()
 
-	fileToUpload, format, contentType, size, err := handleRequestImage(file, header)
+	fileToUpload, format, contentType, size, err := handleRequestImage(ctx, file, header)
 	if err != nil {
 		return err
 	}
 
 	albumId := GenerateUUID().String()
-	objectName := fmt.Sprintf("services/gallery/%v/%v/%v.%v", projectId, albumId, generateRandomString(), format)
-
-	if val := os.Getenv("ENV"); val == "dev" {
-		objectName = "dev/" + objectName
-	}
+	objectName := s.objectPath("services/gallery/%v/%v/%v.%v", projectId, albumId, generateRandomString(), format)
 	a.Cover = objectName
 	a.Id = albumId
 
@@ -93,16 +87,16 @@ func (a *Album) CreateAlbum(r *http.Request, projectId, userId string) error {
 	errChan := make(chan error, 2)
 
 	wg.Add(2)
-	go uploadImageToCloudStorage(objectName, fileToUpload, size, contentType, wg, errChan)
-	go addAlbumToDatabase(a, userId, projectId, wg, errChan)
+	go s.uploadImageToCloudStorage(ctx, objectName, fileToUpload, size, contentType, wg, errChan)
+	go s.addAlbumToDatabase(ctx, a, userId, projectId, wg, errChan)
 
 	wg.Wait()
 	close(errChan)
 
 	for err := range errChan {
 		if err != nil {
-			go deleteFromCloudStorage(objectName)
-			go a.DeleteAlbumById(projectId)
+			s.runInBackground(ctx, func(ctx context.Context) { s.deleteFromCloudStorage(ctx, objectName) })
+			s.runInBackground(ctx, func(ctx context.Context) { s.DeleteAlbumById(ctx, a, projectId) })
 			return err
 		}
 	}
@@ -110,54 +104,43 @@ func (a *Album) CreateAlbum(r *http.Request, projectId, userId string) error {
 	return nil
 }
 
-func deleteImagesFromAlbum(albumId, projectId string) {
-	mediaPrefix := fmt.Sprintf("services/gallery/%v/%v/", projectId, albumId)
-	if val := os.Getenv("ENV"); val == "dev" {
-		mediaPrefix = "dev/" + mediaPrefix
-	}
-	objectsCh := make(chan minio.ObjectInfo)
-
-	go func() {
-		defer close(objectsCh)
-
-		opts := minio.ListObjectsOptions{
-			Recursive: true,
-			Prefix:    mediaPrefix,
-		}
-		// List all objects from a bucket-name with a matching prefix.
-		for object := range spaceStorage.ListObjects(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), opts) {
-			if object.Err != nil {
-				log.Printf("error listing object: %v\n", object.Err)
-			} else {
-				objectsCh <- object
-			}
-		}
-	}()
+func (s *Service) deleteImagesFromAlbum(ctx context.Context, albumId, projectId string) {
+	mediaPrefix := s.objectPath("services/gallery/%v/%v/", projectId, albumId)
 
-	opts := minio.RemoveObjectsOptions{}
-
-	for rErr := range spaceStorage.RemoveObjects(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), objectsCh, opts) {
-		fmt.Println("Error detected during deletion: ", rErr)
+	err := s.Storage.DeletePrefix(ctx, mediaPrefix)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting album images", "prefix", mediaPrefix, "error", err)
 	}
 }
 
-func (a *Album) DeleteAlbumById(projectId string) error {
-	args := dbqueries.DeleteAlbumByIdArgs(a.Id)
-	_, err := db.Exec(ctx, dbqueries.DeleteAlbumById, args)
+func (s *Service) DeleteAlbumById(ctx context.Context, a *Album, projectId string) error {
+	args := dbqueries.DeleteAlbumByIdArgs(a.Id, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.DeleteAlbumById, args)
 	if err != nil {
-		log.Printf("Error deleting album from db: %v\n", err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid album to delete."
+			return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error deleting album from db", "error", err)
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		message := "Album with the provided ID does not exist."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
 	// delete everything in that album
-	go deleteImagesFromAlbum(a.Id, projectId)
+	s.runInBackground(ctx, func(ctx context.Context) { s.deleteImagesFromAlbum(ctx, a.Id, projectId) })
 
 	return nil
 }
 
-func (a *Album) PatchAlbumMetadataById() error {
-	args := dbqueries.PatchAlbumMetadataByIdArgs(a.Id, a.Name)
-	_, err := db.Exec(ctx, dbqueries.PatchAlbumMetadataById, args)
+func (s *Service) PatchAlbumMetadataById(ctx context.Context, a *Album, projectId string) error {
+	args := dbqueries.PatchAlbumMetadataByIdArgs(a.Id, a.Name, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.PatchAlbumMetadataById, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -168,19 +151,25 @@ func (a *Album) PatchAlbumMetadataById() error {
 			}
 		}
 
-		log.Printf("Error updating album data: %v\n", err)
+		slog.ErrorContext(ctx, "Error updating album data", "error", err)
 		return err
 	}
+
+	if tag.RowsAffected() == 0 {
+		message := "Album with the provided ID does not exist."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
 	return nil
 }
 
-func handleAlbumCoverDatabase(cover, albumId string, wg *sync.WaitGroup, errChan chan error) {
+func (s *Service) handleAlbumCoverDatabase(ctx context.Context, cover, albumId, projectId string, wg *sync.WaitGroup, errChan chan error) {
 	defer wg.Done()
 
-	args := dbqueries.PatchAlbumCoverByIdArgs(albumId, cover)
-	rows, err := db.Query(ctx, dbqueries.PatchAlbumCoverById, args)
+	args := dbqueries.PatchAlbumCoverByIdArgs(albumId, cover, projectId)
+	rows, err := s.Store.Query(ctx, dbqueries.PatchAlbumCoverById, args)
 	if err != nil {
-		log.Printf("error updating cover image in db: %v\n", err)
+		slog.ErrorContext(ctx, "error updating cover image in db", "error", err)
 		errChan <- err
 		return
 	}
@@ -205,35 +194,31 @@ func handleAlbumCoverDatabase(cover, albumId string, wg *sync.WaitGroup, errChan
 			}
 		}
 
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		errChan <- nil
 		return
 	}
 
-	go deleteFromCloudStorage(prevPath.Image)
+	s.runInBackground(ctx, func(ctx context.Context) { s.deleteFromCloudStorage(ctx, prevPath.Image) })
 
 	errChan <- nil
 }
 
-func (a *Album) PatchAlbumCoverById(r *http.Request, projectId string) error {
+func (s *Service) PatchAlbumCoverById(ctx context.Context, a *Album, r *http.Request, projectId string) error {
 	file, header, err := r.FormFile("cover")
 	if err != nil {
-		log.Printf("Error retriving file: %v\n ", err)
+		slog.ErrorContext(ctx, "Error retriving file", "error", err)
 		return err
 	}
 
 	defer file.Close()
 
-	fileToUpload, format, contentType, size, err := handleRequestImage(file, header)
+	fileToUpload, format, contentType, size, err := handleRequestImage(ctx, file, header)
 	if err != nil {
 		return err
 	}
 
-	objectName := fmt.Sprintf("services/gallery/%v/%v/%v.%v", projectId, a.Id, generateRandomString(), format)
-
-	if val := os.Getenv("ENV"); val == "dev" {
-		objectName = "dev/" + objectName
-	}
+	objectName := s.objectPath("services/gallery/%v/%v/%v.%v", projectId, a.Id, generateRandomString(), format)
 	a.Cover = objectName
 
 	wg := new(sync.WaitGroup)
@@ -241,14 +226,15 @@ func (a *Album) PatchAlbumCoverById(r *http.Request, projectId string) error {
 
 	wg.Add(2)
 
-	go uploadImageToCloudStorage(objectName, fileToUpload, size, contentType, wg, errChan)
-	go handleAlbumCoverDatabase(objectName, a.Id, wg, errChan)
+	go s.uploadImageToCloudStorage(ctx, objectName, fileToUpload, size, contentType, wg, errChan)
+	go s.handleAlbumCoverDatabase(ctx, objectName, a.Id, projectId, wg, errChan)
 
 	wg.Wait()
 	close(errChan)
 
 	for err := range errChan {
 		if err != nil {
+			s.runInBackground(ctx, func(ctx context.Context) { s.deleteFromCloudStorage(ctx, objectName) })
 			return err
 		}
 	}
@@ -256,19 +242,19 @@ func (a *Album) PatchAlbumCoverById(r *http.Request, projectId string) error {
 	return nil
 }
 
-func (a *Album) GetAlbumsByProjectId(projectId, cursor string, limit int) (*[]Album, *PageInfo, error) {
+func (s *Service) GetAlbumsByProjectId(ctx context.Context, a *Album, projectId, cursor string, limit int) (*[]Album, *PageInfo, error) {
 	args := dbqueries.GetAlbumsByProjectIdArgs(projectId, cursor, limit+1)
-	rows, err := db.Query(ctx, dbqueries.GetAlbumsByProjectId, args)
+	rows, err := s.Store.Query(ctx, dbqueries.GetAlbumsByProjectId, args)
 
 	if err != nil {
-		log.Printf("Error fetching albums from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching albums from db", "error", err)
 		return nil, nil, err
 	}
 	defer rows.Close()
 
 	albums, err := pgx.CollectRows(rows, pgx.RowToStructByName[Album])
 	if err != nil {
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, nil, err
 	}
 
@@ -289,7 +275,7 @@ func (a *Album) GetAlbumsByProjectId(projectId, cursor string, limit int) (*[]Al
 		wg.Add(1)
 
 		img := item.Cover
-		go generatePresignedUrl(img, ind, week, wg, urlChan)
+		go s.generatePresignedUrl(ctx, img, ind, week, wg, urlChan)
 	}
 
 	wg.Wait()
@@ -303,9 +289,9 @@ func (a *Album) GetAlbumsByProjectId(projectId, cursor string, limit int) (*[]Al
 	return &albums, &pageInfo, nil
 }
 
-func (a *Album) GetAlbumNameById() (string, error) {
-	args := dbqueries.GetAlbumNameByIdArgs(a.Id)
-	rows, err := db.Query(ctx, dbqueries.GetAlbumNameById, args)
+func (s *Service) GetAlbumNameById(ctx context.Context, a *Album, projectId string) (string, error) {
+	args := dbqueries.GetAlbumNameByIdArgs(a.Id, projectId)
+	rows, err := s.Store.Query(ctx, dbqueries.GetAlbumNameById, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -314,7 +300,7 @@ func (a *Album) GetAlbumNameById() (string, error) {
 				return "", &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
 			}
 		}
-		log.Printf("Error fetching album name: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching album name", "error", err)
 		return "", err
 	}
 	defer rows.Close()
@@ -328,7 +314,7 @@ func (a *Album) GetAlbumNameById() (string, error) {
 			message := "Album with the provided ID does not exist."
 			return "", &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
 		}
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return "", err
 	}
 
@@ -337,11 +323,11 @@ func (a *Album) GetAlbumNameById() (string, error) {
 
 // photos
 
-func addPhotoToDatabase(p *Photos, userId, albumId string, wg *sync.WaitGroup, errChan chan error) {
+func (s *Service) addPhotoToDatabase(ctx context.Context, p *Photos, userId, albumId, projectId string, wg *sync.WaitGroup, errChan chan error) {
 	defer wg.Done()
 
-	args := dbqueries.PostPhotoByAlbumIdArgs(p.Id, albumId, p.Path, userId)
-	_, err := db.Exec(ctx, dbqueries.PostPhotoByAlbumId, args)
+	args := dbqueries.PostPhotoByAlbumIdArgs(p.Id, albumId, p.Path, userId, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.PostPhotoByAlbumId, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -359,35 +345,47 @@ func addPhotoToDatabase(p *Photos, userId, albumId string, wg *sync.WaitGroup, e
 				errChan <- err
 				return
 			}
+
+			if pgErr.Code == "22P02" {
+				message := "Invalid album id."
+				err = &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+				errChan <- err
+				return
+			}
 		}
 
-		log.Printf("Error adding photo in database: %v\n", err)
+		slog.ErrorContext(ctx, "Error adding photo in database", "error", err)
+		errChan <- err
+		return
 	}
 
-	errChan <- err
+	// the album belongs to another project or doesn't exist
+	if tag.RowsAffected() == 0 {
+		message := "Album with the provided ID does not exist."
+		errChan <- &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+		return
+	}
+
+	errChan <- nil
 
 }
 
-func (p *Photos) PostPhotoByAlbumId(r *http.Request, projectId, albumId, userId string) (string, error) {
+func (s *Service) PostPhotoByAlbumId(ctx context.Context, p *Photos, r *http.Request, projectId, albumId, userId string) (string, error) {
 	photoId := GenerateUUID().String()
 
 	file, header, err := r.FormFile("photo")
 	if err != nil {
-		log.Printf("Error retriving file: %v\n ", err)
+		slog.ErrorContext(ctx, "Error retriving file", "error", err)
 		return "", err
 	}
 	defer file.Close()
 
-	fileToUpload, format, contentType, size, err := handleRequestImage(file, header)
+	fileToUpload, format, contentType, size, err := handleRequestImage(ctx, file, header)
 	if err != nil {
 		return "", err
 	}
 
-	objectName := fmt.Sprintf("services/gallery/%v/%v/photos/%v.%v", projectId, albumId, photoId, format)
-
-	if val := os.Getenv("ENV"); val == "dev" {
-		objectName = "dev/" + objectName
-	}
+	objectName := s.objectPath("services/gallery/%v/%v/photos/%v.%v", projectId, albumId, photoId, format)
 	p.Path = objectName
 	p.Id = photoId
 
@@ -396,16 +394,16 @@ func (p *Photos) PostPhotoByAlbumId(r *http.Request, projectId, albumId, userId
 
 	wg.Add(2)
 
-	go uploadImageToCloudStorage(objectName, fileToUpload, size, contentType, wg, errChan)
-	go addPhotoToDatabase(p, userId, albumId, wg, errChan)
+	go s.uploadImageToCloudStorage(ctx, objectName, fileToUpload, size, contentType, wg, errChan)
+	go s.addPhotoToDatabase(ctx, p, userId, albumId, projectId, wg, errChan)
 
 	wg.Wait()
 	close(errChan)
 
 	for err := range errChan {
 		if err != nil {
-			go deleteFromCloudStorage(objectName)
-			go p.DeletePhotoById([]string{p.Id})
+			s.runInBackground(ctx, func(ctx context.Context) { s.deleteFromCloudStorage(ctx, objectName) })
+			s.runInBackground(ctx, func(ctx context.Context) { s.DeletePhotoById(ctx, p, []string{p.Id}, albumId, projectId) })
 			return "", err
 		}
 	}
@@ -413,9 +411,9 @@ func (p *Photos) PostPhotoByAlbumId(r *http.Request, projectId, albumId, userId
 	return photoId, nil
 }
 
-func (p *Photos) DeletePhotoById(photoId []string) error {
-	args := dbqueries.DeletePhotosByIdArgs(photoId)
-	rows, err := db.Query(ctx, dbqueries.DeletePhotosById, args)
+func (s *Service) DeletePhotoById(ctx context.Context, p *Photos, photoId []string, albumId, projectId string) error {
+	args := dbqueries.DeletePhotosByIdArgs(photoId, albumId, projectId)
+	rows, err := s.Store.Query(ctx, dbqueries.DeletePhotosById, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -424,14 +422,14 @@ func (p *Photos) DeletePhotoById(photoId []string) error {
 				return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
 			}
 		}
-		log.Printf("Error deleting photos from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error deleting photos from db", "error", err)
 		return err
 	}
 	defer rows.Close()
 
 	photos, err := pgx.CollectRows(rows, pgx.RowToStructByName[PhotosPath])
 	if err != nil {
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return err
 	}
 
@@ -439,41 +437,33 @@ func (p *Photos) DeletePhotoById(photoId []string) error {
 		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: "Photos not found"}
 	}
 
-	objectChan := make(chan minio.ObjectInfo)
-	go func() {
-		defer close(objectChan)
-		for _, img := range photos {
-			objectChan <- minio.ObjectInfo{Key: img.Path}
-		}
-	}()
-	e := spaceStorage.RemoveObjects(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), objectChan, minio.RemoveObjectsOptions{})
-
-	isErr := false
-	for err := range e {
-		log.Printf("Error deleting objects in space storage, %v\n", err)
-		isErr = true
+	paths := make([]string, 0, len(photos))
+	for _, img := range photos {
+		paths = append(paths, img.Path)
 	}
 
-	if isErr {
+	err = s.Storage.Delete(ctx, paths...)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting objects in space storage", "error", err)
 		return errors.New("error deleting image from space storage")
 	}
 
 	return nil
 }
 
-func (p *Photos) GetPhotosByAlbumId(albumId, cursor string, limit int) (*[]Photos, *PageInfo, error) {
-	args := dbqueries.GetPhotosByAlbumIdArgs(albumId, cursor, limit+1)
-	rows, err := db.Query(ctx, dbqueries.GetPhotosByAlbumId, args)
+func (s *Service) GetPhotosByAlbumId(ctx context.Context, p *Photos, albumId, projectId, cursor string, limit int) (*[]Photos, *PageInfo, error) {
+	args := dbqueries.GetPhotosByAlbumIdArgs(albumId, projectId, cursor, limit+1)
+	rows, err := s.Store.Query(ctx, dbqueries.GetPhotosByAlbumId, args)
 
 	if err != nil {
-		log.Printf("Error fetching photos from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching photos from db", "error", err)
 		return nil, nil, err
 	}
 	defer rows.Close()
 
 	photos, err := pgx.CollectRows(rows, pgx.RowToStructByName[Photos])
 	if err != nil {
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, nil, err
 	}
 
@@ -495,7 +485,7 @@ func (p *Photos) GetPhotosByAlbumId(albumId, cursor string, limit int) (*[]Photo
 		wg.Add(1)
 
 		img := item.Path
-		go generatePresignedUrl(img, ind, week, wg, urlChan)
+		go s.generatePresignedUrl(ctx, img, ind, week, wg, urlChan)
 	}
 
 	wg.Wait()
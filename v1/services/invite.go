@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rohan031/adgytec-api/identity"
+	"github.com/rohan031/adgytec-api/v1/custom"
+	"github.com/rohan031/adgytec-api/v1/dbqueries"
+)
+
+const inviteTemplate = "./assets/invite.html"
+
+// InviteDetails is what the invite email is rendered from, the link holds the
+// only copy of the token
+type InviteDetails struct {
+	Name      string
+	Email     string
+	Link      string
+	ExpiresAt time.Time
+}
+
+type InviteAccept struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type invite struct {
+	InviteId   string     `db:"invite_id"`
+	UserId     string     `db:"user_id"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	AcceptedAt *time.Time `db:"accepted_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+func (s *Service) newInvite(ctx context.Context, u *User) (*InviteDetails, string, error) {
+	token, err := generateSecureToken(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	details := &InviteDetails{
+		Name:  u.Name,
+		Email: u.Email,
+		Link:  s.Config.DashboardURL + "/accept-invite?token=" + url.QueryEscape(token),
+		// timestamps are stored without a zone
+		ExpiresAt: time.Now().Add(s.Config.InviteTTL).UTC(),
+	}
+
+	return details, hashToken(token), nil
+}
+
+// InviteUser creates the account with a password nobody knows and an invite
+// to choose one, the invite has to be sent with SendInvite
+func (s *Service) InviteUser(ctx context.Context, u *User, invitedBy string) (*InviteDetails, error) {
+	password, err := generateSecureToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uid, err := s.createIdentityUser(ctx, u, password)
+	if err != nil {
+		return nil, err
+	}
+
+	details, tokenHash, err := s.newInvite(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	args := dbqueries.CreateInvitedUserArgs(uid, u.Email, u.Name, u.Role, tokenHash, invitedBy, details.ExpiresAt)
+	_, err = s.Store.Exec(ctx, dbqueries.CreateInvitedUser, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error adding invited user in database", "error", err)
+		return nil, err
+	}
+
+	u.UserId = uid
+	u.InviteStatus = "pending"
+	return details, nil
+}
+
+func (s *Service) SendInvite(ctx context.Context, details *InviteDetails) error {
+	subject := "Invitation to Adgytec"
+	return s.SendEmail(ctx, details, inviteTemplate, []string{details.Email}, subject)
+}
+
+// ResendInvite replaces the open invites of the user with a new one, the old
+// links stop working
+func (s *Service) ResendInvite(ctx context.Context, u *User, invitedBy string) (*InviteDetails, error) {
+	if u.InviteStatus == "accepted" {
+		message := "The user has already accepted the invite."
+		return nil, &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	details, tokenHash, err := s.newInvite(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	args := dbqueries.ReissueInviteArgs(u.UserId, tokenHash, invitedBy, details.ExpiresAt)
+	tag, err := s.Store.Exec(ctx, dbqueries.ReissueInvite, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reissuing invite", "error", err)
+		return nil, err
+	}
+
+	// accepted in the meantime
+	if tag.RowsAffected() == 0 {
+		message := "The user has already accepted the invite."
+		return nil, &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	return details, nil
+}
+
+func (s *Service) RevokeInvite(ctx context.Context, u *User) error {
+	args := dbqueries.RevokeInviteArgs(u.UserId)
+	tag, err := s.Store.Exec(ctx, dbqueries.RevokeInvite, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error revoking invite", "error", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		message := "The user has no pending invite."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	return nil
+}
+
+// AcceptInvite sets the password chosen by the invitee, each invite works once
+func (s *Service) AcceptInvite(ctx context.Context, in *InviteAccept) error {
+	if len(in.Password) < 8 || len(in.Password) > 128 {
+		message := "Password must be between 8 and 128 characters."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	invalid := &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "The invite link is invalid or has already been used."}
+	if in.Token == "" {
+		return invalid
+	}
+
+	args := dbqueries.GetInviteByHashArgs(hashToken(in.Token))
+	rows, err := s.Store.Query(ctx, dbqueries.GetInviteByHash, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching invite from db", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	inv, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[invite])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return invalid
+		}
+
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return err
+	}
+
+	if inv.AcceptedAt != nil || inv.RevokedAt != nil {
+		return invalid
+	}
+
+	if !inv.ExpiresAt.After(time.Now()) {
+		message := "The invite link has expired, ask for a new one."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	tag, err := s.Store.Exec(ctx, dbqueries.ClaimInvite, dbqueries.InviteIdArgs(inv.InviteId))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error accepting invite", "error", err)
+		return err
+	}
+
+	// accepted or revoked by a concurrent request
+	if tag.RowsAffected() == 0 {
+		return invalid
+	}
+
+	authCtx, cancel := s.authContext(ctx)
+	defer cancel()
+
+	err = s.Identity.UpdateUser(authCtx, inv.UserId, identity.UserToUpdate{Password: in.Password})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error setting password of invited user", "error", err)
+
+		_, releaseErr := s.Store.Exec(ctx, dbqueries.ReleaseInvite, dbqueries.InviteIdArgs(inv.InviteId))
+		if releaseErr != nil {
+			slog.ErrorContext(ctx, "Error releasing invite", "error", releaseErr)
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -4,35 +4,43 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
 	"io"
-	"log"
+	"log/slog"
 	mathRand "math/rand/v2"
 	"mime/multipart"
 	"net/http"
-	"net/url"
-	"os"
 	"strings"
 	"sync"
 	"time"
 
-	"firebase.google.com/go/v4/auth"
 	"github.com/disintegration/imaging"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/minio/minio-go/v7"
+	"github.com/rohan031/adgytec-api/app"
+	"github.com/rohan031/adgytec-api/metrics"
 	"github.com/rohan031/adgytec-api/v1/custom"
 	"github.com/rwcarlsen/goexif/exif"
 	// "golang.org/x/image/webp"
 )
 
-var db *pgxpool.Pool
-var ctx context.Context = context.Background()
-var spaceStorage *minio.Client
-var firebaseClient *auth.Client
+// Service implements the business logic on top of the application
+// dependencies
+type Service struct {
+	*app.App
+
+	// background tracks fire-and-forget work (media cleanup, rollbacks of
+	// failed uploads) so a graceful shutdown can wait for it to finish
+	background sync.WaitGroup
+}
+
+func New(a *app.App) *Service {
+	return &Service{App: a}
+}
 
 var expires time.Duration = time.Second * 60 * 60 // 1hr
 var week time.Duration = 604800 * time.Second
@@ -51,22 +59,70 @@ type PageInfo struct {
 	Cursor   *time.Time `json:"cursor"`
 }
 
-func SetExternalConnection(pool *pgxpool.Pool, storage *minio.Client, client *auth.Client) {
-	db = pool
-	spaceStorage = storage
-	firebaseClient = client
+// objectPath builds an object name inside the bucket, taking care of the
+// environment prefix
+func (s *Service) objectPath(format string, a ...any) string {
+	return s.Config.Storage.Prefix + fmt.Sprintf(format, a...)
 }
 
-func generateSecureToken() (string, error) {
+// runInBackground runs task detached from the request cancellation, so it can
+// finish after the response is sent, but bounded by the background timeout.
+// The request values are kept for logging
+func (s *Service) runInBackground(ctx context.Context, task func(ctx context.Context)) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.Config.Timeouts.Background)
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		defer cancel()
+		task(ctx)
+	}()
+}
+
+// storageContext bounds a single object storage call
+func (s *Service) storageContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, s.Config.Timeouts.Storage)
+}
+
+// authContext bounds a single identity provider call
+func (s *Service) authContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, s.Config.Timeouts.Auth)
+}
+
+// WaitForBackgroundTasks blocks until every background task has finished or
+// the context is done, whichever happens first
+func (s *Service) WaitForBackgroundTasks(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func generateSecureToken(ctx context.Context) (string, error) {
 	b := make([]byte, 12)
 	if _, err := rand.Read(b); err != nil {
-		log.Printf("Error generating token: %v\n", err)
+		slog.ErrorContext(ctx, "Error generating token", "error", err)
 
 		return "", err
 	}
 	return hex.EncodeToString(b), nil
 }
 
+// hashToken is how single use and api tokens are stored, they are random
+// enough that a fast hash doesn't make them guessable
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func generateRandomString() string {
 	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	b := make([]byte, 10)
@@ -89,7 +145,7 @@ func isImageFile(header *multipart.FileHeader) (string, error) {
 	return contentType, nil
 }
 
-func reverseOrientation(img image.Image, o string) *image.NRGBA {
+func reverseOrientation(ctx context.Context, img image.Image, o string) *image.NRGBA {
 	switch o {
 	case "1":
 		return imaging.Clone(img)
@@ -108,14 +164,14 @@ func reverseOrientation(img image.Image, o string) *image.NRGBA {
 	case "8":
 		return imaging.Rotate90(img)
 	}
-	log.Printf("unknown orientation %s, expect 1-8", o)
+	slog.WarnContext(ctx, "unknown orientation, expect 1-8", "orientation", o)
 	return imaging.Clone(img)
 }
 
-func handleImage(img image.Image, buf *bytes.Buffer, format string, file multipart.File) error {
+func handleImage(ctx context.Context, img image.Image, buf *bytes.Buffer, format string, file multipart.File) error {
 	_, err := file.Seek(0, io.SeekStart)
 	if err != nil {
-		log.Printf("failed to seek file: %v\n", err)
+		slog.ErrorContext(ctx, "failed to seek file", "error", err)
 	}
 
 	switch strings.ToLower(format) {
@@ -123,17 +179,17 @@ func handleImage(img image.Image, buf *bytes.Buffer, format string, file multipa
 		// resizedImg := resize.Thumbnail(1920, 1080, img, resize.Lanczos3)
 		x, err := exif.Decode(file)
 		if err != nil {
-			log.Printf("failed reading exif data in %s\n", err.Error())
+			slog.DebugContext(ctx, "failed reading exif data", "error", err)
 		}
 		if x != nil && err == nil {
 			orient, _ := x.Get(exif.Orientation)
 			if orient != nil {
-				img = reverseOrientation(img, orient.String())
+				img = reverseOrientation(ctx, img, orient.String())
 			}
 		}
 		err = jpeg.Encode(buf, img, &jpeg.Options{Quality: 80})
 		if err != nil {
-			log.Printf("failed to encode JPEG image: %v", err)
+			slog.ErrorContext(ctx, "failed to encode JPEG image", "error", err)
 			return err
 		}
 	case "png":
@@ -141,11 +197,11 @@ func handleImage(img image.Image, buf *bytes.Buffer, format string, file multipa
 		encoder := png.Encoder{CompressionLevel: png.BestCompression}
 		err := encoder.Encode(buf, img)
 		if err != nil {
-			log.Printf("failed to encode PNG image: %v", err)
+			slog.ErrorContext(ctx, "failed to encode PNG image", "error", err)
 			return err
 		}
 	default:
-		log.Printf("unsupported image format: %s", format)
+		slog.WarnContext(ctx, "unsupported image format", "format", format)
 		message := "unsupported image format"
 		return &custom.MalformedRequest{
 			Status: http.StatusUnsupportedMediaType, Message: message,
@@ -156,28 +212,25 @@ func handleImage(img image.Image, buf *bytes.Buffer, format string, file multipa
 
 }
 
-func uploadImageToCloudStorage(objectName string, buf io.Reader, size int64, contentType string, wg *sync.WaitGroup, errChan chan error) {
+func (s *Service) uploadImageToCloudStorage(ctx context.Context, objectName string, buf io.Reader, size int64, contentType string, wg *sync.WaitGroup, errChan chan error) {
 	defer wg.Done()
 
-	_, err := spaceStorage.PutObject(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), objectName, buf, size, minio.PutObjectOptions{ContentType: contentType})
+	ctx, cancel := s.storageContext(ctx)
+	defer cancel()
+
+	err := s.Storage.Put(ctx, objectName, buf, size, contentType)
 	if err != nil {
-		log.Printf("failed to upload image: %v", err)
+		slog.ErrorContext(ctx, "failed to upload image", "error", err)
 	}
 	errChan <- err
 }
 
-func generatePresignedUrl(objectName string, ind int, expires time.Duration, wg *sync.WaitGroup, urlChan chan IndexedValue) {
+func (s *Service) generatePresignedUrl(ctx context.Context, objectName string, ind int, expires time.Duration, wg *sync.WaitGroup, urlChan chan IndexedValue) {
 	defer wg.Done()
 
-	reqParams := make(url.Values)
-	presignedURL, err := spaceStorage.PresignedGetObject(ctx,
-		os.Getenv("SPACE_STORAGE_BUCKET_NAME"),
-		objectName,
-		expires,
-		reqParams,
-	)
+	presignedURL, err := s.Storage.SignedURL(ctx, objectName, expires)
 	if err != nil {
-		log.Printf("error generating presigned url for the image: %v\n", err)
+		slog.ErrorContext(ctx, "error generating presigned url for the image", "error", err)
 		urlChan <- IndexedValue{
 			Index: ind,
 			Url:   "",
@@ -187,7 +240,7 @@ func generatePresignedUrl(objectName string, ind int, expires time.Duration, wg
 
 	urlChan <- IndexedValue{
 		Index: ind,
-		Url:   presignedURL.String(),
+		Url:   presignedURL,
 	}
 }
 
@@ -195,10 +248,13 @@ func GenerateUUID() uuid.UUID {
 	return uuid.New()
 }
 
-func deleteFromCloudStorage(objectName string) error {
-	err := spaceStorage.RemoveObject(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), objectName, minio.RemoveObjectOptions{})
+func (s *Service) deleteFromCloudStorage(ctx context.Context, objectName string) error {
+	ctx, cancel := s.storageContext(ctx)
+	defer cancel()
+
+	err := s.Storage.Delete(ctx, objectName)
 	if err != nil {
-		log.Printf("Error deleting image from space storage: %v\n", err)
+		slog.ErrorContext(ctx, "Error deleting image from space storage", "error", err)
 		return err
 	}
 
@@ -207,7 +263,7 @@ func deleteFromCloudStorage(objectName string) error {
 
 // return type
 // file to upload, file format, file content type, size of file, error if any
-func handleRequestImage(file multipart.File, header *multipart.FileHeader) (io.Reader, string, string, int64, error) {
+func handleRequestImage(ctx context.Context, file multipart.File, header *multipart.FileHeader) (io.Reader, string, string, int64, error) {
 	contentType, err := isImageFile(header)
 	if err != nil {
 		return nil, "", "", 0, err
@@ -230,13 +286,15 @@ func handleRequestImage(file multipart.File, header *multipart.FileHeader) (io.R
 		return file, format, contentType, header.Size, nil
 	}
 
+	start := time.Now()
 	img, format, err = image.Decode(file)
 	if err != nil {
-		log.Printf("Error decoding image: %v\n", err)
+		slog.ErrorContext(ctx, "Error decoding image", "error", err)
 		return nil, "", "", 0, err
 	}
+	defer metrics.ObserveImageProcessing(format, start)
 
-	err = handleImage(img, buf, format, file)
+	err = handleImage(ctx, img, buf, format, file)
 	if err != nil {
 		return nil, "", "", 0, err
 	}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rohan031/adgytec-api/v1/custom"
+	"github.com/rohan031/adgytec-api/v1/dbqueries"
+	"github.com/rohan031/adgytec-api/v1/validation"
+)
+
+// capabilities outside of projects, each one names a group of routes
+const (
+	CapabilityManageUsers    = "users.manage"
+	CapabilityManageAdmins   = "users.manage_admins"
+	CapabilityReconcileUsers = "users.reconcile"
+	CapabilityManageProjects = "projects.manage"
+	// within a project, services add "<service>.<permission>"
+	CapabilityManageMembers = "members.manage"
+)
+
+// Me is the signed in user as the dashboard needs it, the capabilities are
+// computed with the same rules the middleware enforces
+type Me struct {
+	Profile      *User        `json:"profile"`
+	Capabilities []string     `json:"capabilities"`
+	Memberships  []Membership `json:"memberships"`
+}
+
+type Membership struct {
+	ProjectId    string            `json:"projectId" db:"project_id"`
+	ProjectName  string            `json:"projectName" db:"project_name"`
+	Role         string            `json:"role" db:"role"`
+	Services     []ServicesDetails `json:"services" db:"services"`
+	Capabilities []string          `json:"capabilities" db:"-"`
+
+	// a restricted member is limited to the granted service permissions
+	Restricted  bool                `json:"-" db:"restricted"`
+	Permissions map[string][]string `json:"-" db:"permissions"`
+}
+
+type MeUpdate struct {
+	Name string `json:"name"`
+}
+
+func userCapabilities(role string) []string {
+	capabilities := []string{}
+
+	if role == validation.User {
+		return capabilities
+	}
+
+	capabilities = append(capabilities, CapabilityManageUsers, CapabilityManageProjects)
+	if role == validation.SuperAdmin {
+		capabilities = append(capabilities, CapabilityManageAdmins, CapabilityReconcileUsers)
+	}
+
+	return capabilities
+}
+
+// projectCapabilities mirrors ProjectOwnerAuthorization,
+// ServicesRoleAuthorization and ServicePermissionAuthorization
+func projectCapabilities(userRole string, m *Membership) []string {
+	admin := userRole != validation.User
+	capabilities := []string{}
+
+	if admin || m.Role == validation.ProjectOwner {
+		capabilities = append(capabilities, CapabilityManageMembers)
+	}
+
+	for _, service := range validation.ProjectServices {
+		for _, permission := range validation.Permissions {
+			allowed := admin
+			if !admin {
+				allowed = validation.ProjectRoleAllowsPermission(m.Role, permission) &&
+					(!m.Restricted || containsString(m.Permissions[service], permission))
+			}
+
+			if allowed {
+				capabilities = append(capabilities, service+"."+permission)
+			}
+		}
+	}
+
+	return capabilities
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Service) GetMe(ctx context.Context, userId string) (*Me, error) {
+	user, err := s.GetUserById(ctx, &User{UserId: userId})
+	if err != nil {
+		return nil, err
+	}
+
+	args := dbqueries.GetMembershipsByUserIdArgs(userId)
+	rows, err := s.Store.Query(ctx, dbqueries.GetMembershipsByUserId, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching memberships from db", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships, err := pgx.CollectRows(rows, pgx.RowToStructByName[Membership])
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, err
+	}
+
+	for i := range memberships {
+		memberships[i].Capabilities = projectCapabilities(user.Role, &memberships[i])
+	}
+
+	return &Me{
+		Profile:      user,
+		Capabilities: userCapabilities(user.Role),
+		Memberships:  memberships,
+	}, nil
+}
+
+// UpdateMe changes what users may change about themselves, the display name
+func (s *Service) UpdateMe(ctx context.Context, userId string, m *MeUpdate) error {
+	if !validation.ValidateName(m.Name) {
+		message := "The request body contains invalid input values."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	return s.UpdateUserName(ctx, &User{UserId: userId, Name: m.Name})
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/validation"
+)
+
+func TestUserCapabilities(t *testing.T) {
+	tests := []struct {
+		role     string
+		expected []string
+	}{
+		{validation.User, []string{}},
+		{validation.Admin, []string{CapabilityManageUsers, CapabilityManageProjects}},
+		{validation.SuperAdmin, []string{CapabilityManageUsers, CapabilityManageProjects, CapabilityManageAdmins, CapabilityReconcileUsers}},
+	}
+
+	for _, tt := range tests {
+		if got := userCapabilities(tt.role); !slices.Equal(got, tt.expected) {
+			t.Errorf("userCapabilities(%q) = %v want %v", tt.role, got, tt.expected)
+		}
+	}
+}
+
+func TestProjectCapabilities(t *testing.T) {
+	all := len(validation.ProjectServices) * len(validation.Permissions)
+
+	tests := []struct {
+		name       string
+		userRole   string
+		membership Membership
+		has        []string
+		hasNot     []string
+		count      int
+	}{
+		{
+			name:       "admin",
+			userRole:   validation.Admin,
+			membership: Membership{Restricted: true},
+			has:        []string{CapabilityManageMembers, "news.delete"},
+			count:      all + 1,
+		},
+		{
+			name:       "owner",
+			userRole:   validation.User,
+			membership: Membership{Role: validation.ProjectOwner},
+			has:        []string{CapabilityManageMembers, "gallery.delete"},
+			count:      all + 1,
+		},
+		{
+			name:       "viewer",
+			userRole:   validation.User,
+			membership: Membership{Role: validation.ProjectViewer},
+			has:        []string{"blogs.read"},
+			hasNot:     []string{CapabilityManageMembers, "blogs.write"},
+			count:      len(validation.ProjectServices),
+		},
+		{
+			name:       "author",
+			userRole:   validation.User,
+			membership: Membership{Role: validation.ProjectAuthor},
+			has:        []string{"documents.write"},
+			hasNot:     []string{"documents.delete"},
+			count:      len(validation.ProjectServices) * 2,
+		},
+		{
+			// grants never go past the role
+			name:     "restricted author",
+			userRole: validation.User,
+			membership: Membership{Role: validation.ProjectAuthor, Restricted: true, Permissions: map[string][]string{
+				validation.ServiceGallery: {validation.PermissionRead, validation.PermissionDelete},
+			}},
+			has:    []string{"gallery.read"},
+			hasNot: []string{"gallery.delete", "news.read"},
+			count:  1,
+		},
+		{
+			// restricted without grants after the last one was removed
+			name:       "restricted without grants",
+			userRole:   validation.User,
+			membership: Membership{Role: validation.ProjectEditor, Restricted: true},
+			count:      0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := projectCapabilities(tt.userRole, &tt.membership)
+
+			if len(got) != tt.count {
+				t.Errorf("unexpected number of capabilities: got %v want %v: %v", len(got), tt.count, got)
+			}
+			for _, c := range tt.has {
+				if !slices.Contains(got, c) {
+					t.Errorf("missing capability %q: %v", c, got)
+				}
+			}
+			for _, c := range tt.hasNot {
+				if slices.Contains(got, c) {
+					t.Errorf("unexpected capability %q: %v", c, got)
+				}
+			}
+		})
+	}
+}
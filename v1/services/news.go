@@ -1,17 +1,15 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/minio/minio-go/v7"
 	"github.com/rohan031/adgytec-api/v1/custom"
 	"github.com/rohan031/adgytec-api/v1/dbqueries"
 )
@@ -40,35 +38,31 @@ type NewsPut struct {
 	Id    string `json:"-"`
 }
 
-func addNewsToDatabase(n *News, projectId string, wg *sync.WaitGroup, errChan chan error) {
+func (s *Service) addNewsToDatabase(ctx context.Context, n *News, projectId string, wg *sync.WaitGroup, errChan chan error) {
 	defer wg.Done()
 
 	args := dbqueries.CreateNewsItemArgs(n.Title, n.Link, n.Text, n.Image, projectId)
-	_, err := db.Exec(ctx, dbqueries.CreateNewsItem, args)
+	_, err := s.Store.Exec(ctx, dbqueries.CreateNewsItem, args)
 	if err != nil {
-		log.Printf("Error adding news item in database: %v\n", err)
+		slog.ErrorContext(ctx, "Error adding news item in database", "error", err)
 	}
 	errChan <- err
 }
 
-func (n *News) CreateNewsItem(r *http.Request, projectId string) error {
+func (s *Service) CreateNewsItem(ctx context.Context, n *News, r *http.Request, projectId string) error {
 	file, header, err := r.FormFile("image")
 	if err != nil {
-		log.Printf("Error retriving file: %v\n ", err)
+		slog.ErrorContext(ctx, "Error retriving file", "error", err)
 		return err
 	}
 	defer file.Close()
 
-	fileToUpload, format, contentType, size, err := handleRequestImage(file, header)
+	fileToUpload, format, contentType, size, err := handleRequestImage(ctx, file, header)
 	if err != nil {
 		return err
 	}
 
-	objectName := fmt.Sprintf("services/news/%v/%v.%v", projectId, generateRandomString(), format)
-
-	if val := os.Getenv("ENV"); val == "dev" {
-		objectName = "dev/" + objectName
-	}
+	objectName := s.objectPath("services/news/%v/%v.%v", projectId, generateRandomString(), format)
 
 	n.Image = objectName
 
@@ -77,8 +71,8 @@ func (n *News) CreateNewsItem(r *http.Request, projectId string) error {
 
 	wg.Add(2)
 
-	go uploadImageToCloudStorage(objectName, fileToUpload, size, contentType, wg, errChan)
-	go addNewsToDatabase(n, projectId, wg, errChan)
+	go s.uploadImageToCloudStorage(ctx, objectName, fileToUpload, size, contentType, wg, errChan)
+	go s.addNewsToDatabase(ctx, n, projectId, wg, errChan)
 
 	wg.Wait()
 	close(errChan)
@@ -92,19 +86,19 @@ func (n *News) CreateNewsItem(r *http.Request, projectId string) error {
 	return nil
 }
 
-func (n *News) GetAllNewsByProjectId(projectId string, limit int) (*[]News, error) {
+func (s *Service) GetAllNewsByProjectId(ctx context.Context, n *News, projectId string, limit int) (*[]News, error) {
 	args := dbqueries.GetAllNewsByProjectIdArgs(projectId, limit)
-	rows, err := db.Query(ctx, dbqueries.GetAllNewsByProjectId, args)
+	rows, err := s.Store.Query(ctx, dbqueries.GetAllNewsByProjectId, args)
 
 	if err != nil {
-		log.Printf("Error fetching news from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching news from db", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 
 	news, err := pgx.CollectRows(rows, pgx.RowToStructByName[News])
 	if err != nil {
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, err
 	}
 
@@ -115,7 +109,7 @@ func (n *News) GetAllNewsByProjectId(projectId string, limit int) (*[]News, erro
 		wg.Add(1)
 
 		img := item.Image
-		go generatePresignedUrl(img, ind, expires, wg, urlChan)
+		go s.generatePresignedUrl(ctx, img, ind, expires, wg, urlChan)
 	}
 
 	wg.Wait()
@@ -129,11 +123,11 @@ func (n *News) GetAllNewsByProjectId(projectId string, limit int) (*[]News, erro
 	return &news, nil
 }
 
-func (n *News) DeleteNews() error {
-	args := dbqueries.DeleteNewsByIdArgs(n.Id)
-	rows, err := db.Query(ctx, dbqueries.DeleteNewsById, args)
+func (s *Service) DeleteNews(ctx context.Context, n *News, projectId string) error {
+	args := dbqueries.DeleteNewsByIdArgs(n.Id, projectId)
+	rows, err := s.Store.Query(ctx, dbqueries.DeleteNewsById, args)
 	if err != nil {
-		log.Printf("Error deleting news from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error deleting news from db", "error", err)
 		return err
 	}
 	defer rows.Close()
@@ -145,22 +139,22 @@ func (n *News) DeleteNews() error {
 			return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
 
 		}
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return err
 	}
 
 	// delete from space storage
-	// err = spaceStorage.RemoveObject(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), news.Image, minio.RemoveObjectOptions{})
+	// err = s.Storage.RemoveObject(ctx, s.Config.Storage.Bucket, news.Image, minio.RemoveObjectOptions{})
 	// if err != nil {
-	// 	log.Printf("Error deleting image from space storage: %v\n", err)
+	// 	slog.ErrorContext(ctx, "Error deleting image from space storage", "error", err)
 	// 	// return err
 	// }
-	go deleteFromCloudStorage(news.Image)
+	s.runInBackground(ctx, func(ctx context.Context) { s.deleteFromCloudStorage(ctx, news.Image) })
 
 	return nil
 }
 
-func (n *NewsDelete) DeleteNewsMultiple(projectId string) error {
+func (s *Service) DeleteNewsMultiple(ctx context.Context, n *NewsDelete, projectId string) error {
 	deleteAll := len(n.NewsId) == 0
 
 	var args pgx.NamedArgs
@@ -174,10 +168,10 @@ func (n *NewsDelete) DeleteNewsMultiple(projectId string) error {
 		// return &custom.MalformedRequest{Status: http.StatusServiceUnavailable, Message: "This method is not available for the time being"}
 	} else {
 		query = dbqueries.DeleteMultipleNewsById
-		args = dbqueries.DeleteMultipleNewsByIdArgs(n.NewsId)
+		args = dbqueries.DeleteMultipleNewsByIdArgs(n.NewsId, projectId)
 	}
 
-	rows, err := db.Query(ctx, query, args)
+	rows, err := s.Store.Query(ctx, query, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -186,14 +180,14 @@ func (n *NewsDelete) DeleteNewsMultiple(projectId string) error {
 				return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
 			}
 		}
-		log.Printf("Error deleting news from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error deleting news from db", "error", err)
 		return err
 	}
 	defer rows.Close()
 
 	news, err := pgx.CollectRows(rows, pgx.RowToStructByName[NewsImage])
 	if err != nil {
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return err
 	}
 
@@ -201,35 +195,27 @@ func (n *NewsDelete) DeleteNewsMultiple(projectId string) error {
 		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: "News not found"}
 	}
 
-	objectChan := make(chan minio.ObjectInfo)
-	go func() {
-		defer close(objectChan)
-		for _, img := range news {
-			objectChan <- minio.ObjectInfo{Key: img.Image}
-		}
-	}()
-	e := spaceStorage.RemoveObjects(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), objectChan, minio.RemoveObjectsOptions{})
-
-	isErr := false
-	for err := range e {
-		log.Printf("Error deleting objects in space storage, %v\n", err)
-		isErr = true
+	paths := make([]string, 0, len(news))
+	for _, img := range news {
+		paths = append(paths, img.Image)
 	}
 
-	if isErr {
+	err = s.Storage.Delete(ctx, paths...)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting objects in space storage", "error", err)
 		return errors.New("error deleting image from space storage")
 	}
 
 	return nil
 }
 
-func (n *NewsPut) NewsUpdate() error {
+func (s *Service) NewsUpdate(ctx context.Context, n *NewsPut, projectId string) error {
 	if len(n.Id) == 0 || len(n.Title) == 0 || len(n.Link) == 0 || len(n.Text) == 0 {
 		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "All news details not provided."}
 	}
 
-	args := dbqueries.UpdateNewsByIdArgs(n.Id, n.Title, n.Link, n.Text)
-	_, err := db.Exec(ctx, dbqueries.UpdateNewsById, args)
+	args := dbqueries.UpdateNewsByIdArgs(n.Id, n.Title, n.Link, n.Text, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.UpdateNewsById, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -240,8 +226,14 @@ func (n *NewsPut) NewsUpdate() error {
 			}
 		}
 
-		log.Printf("Error updating news in database: %v\n", err)
+		slog.ErrorContext(ctx, "Error updating news in database", "error", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		message := "News item not found"
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
 	}
 
-	return err
+	return nil
 }
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rohan031/adgytec-api/v1/custom"
+	"github.com/rohan031/adgytec-api/v1/dbqueries"
+	"github.com/rohan031/adgytec-api/v1/validation"
+)
+
+type ProjectOrigin struct {
+	OriginId  string    `json:"originId" db:"origin_id"`
+	Origin    string    `json:"origin" db:"origin"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+func (o *ProjectOrigin) normalize() error {
+	origin, ok := validation.NormalizeOrigin(o.Origin)
+	if !ok {
+		message := "Invalid origin, expected scheme and host e.g. https://example.com"
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	o.Origin = origin
+	return nil
+}
+
+func (s *Service) GetOriginsByProjectId(ctx context.Context, o *ProjectOrigin, projectId string) (*[]ProjectOrigin, error) {
+	args := dbqueries.GetOriginsByProjectIdArgs(projectId)
+	rows, err := s.Store.Query(ctx, dbqueries.GetOriginsByProjectId, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching project origins from db", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	origins, err := pgx.CollectRows(rows, pgx.RowToStructByName[ProjectOrigin])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid project id."
+			return nil, &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, err
+	}
+
+	return &origins, nil
+}
+
+func (s *Service) AddOriginToProject(ctx context.Context, o *ProjectOrigin, projectId string) error {
+	err := o.normalize()
+	if err != nil {
+		return err
+	}
+
+	args := dbqueries.AddOriginToProjectArgs(projectId, o.Origin)
+	_, err = s.Store.Exec(ctx, dbqueries.AddOriginToProject, args)
+	if err != nil {
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == "23503" {
+				message := "Project with the provided ID does not exist."
+				return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+			}
+
+			if pgErr.Code == "22P02" {
+				message := "Invalid project id."
+				return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+			}
+
+			if pgErr.Code == "23505" {
+				message := "The origin is already allowed for the project."
+				return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+			}
+		}
+
+		slog.ErrorContext(ctx, "Error adding origin to project", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteOriginFromProject(ctx context.Context, o *ProjectOrigin, projectId string) error {
+	args := dbqueries.DeleteOriginFromProjectArgs(projectId, o.OriginId)
+	err := s.Store.QueryRow(ctx, dbqueries.DeleteOriginFromProject, args).Scan(&o.Origin)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message := "The origin isn't allowed for the project."
+			return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+		}
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid project id or origin id."
+			return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error removing origin from project", "error", err)
+		return err
+	}
+
+	return nil
+}
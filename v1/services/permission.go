@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rohan031/adgytec-api/v1/custom"
+	"github.com/rohan031/adgytec-api/v1/dbqueries"
+	"github.com/rohan031/adgytec-api/v1/validation"
+)
+
+// ServicePermission limits a project member to the listed permissions on a
+// service, see the 0006 migration
+type ServicePermission struct {
+	Service     string    `json:"service" db:"service"`
+	Permissions []string  `json:"permissions" db:"permissions"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+func (p *ServicePermission) validate() error {
+	if !validation.ValidateProjectService(p.Service) {
+		message := "Invalid service, expected news, blogs, gallery, documents or contact-us."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	if len(p.Permissions) == 0 {
+		message := "Service permission needs at least one of read, write or delete."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	seen := make(map[string]bool, len(p.Permissions))
+	permissions := make([]string, 0, len(p.Permissions))
+	for _, permission := range p.Permissions {
+		if !validation.ValidatePermission(permission) {
+			return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "Invalid permission: " + permission}
+		}
+
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	p.Permissions = permissions
+
+	return nil
+}
+
+func (s *Service) GetServicePermissionsByUserId(ctx context.Context, p *ServicePermission, userId, projectId string) (*[]ServicePermission, error) {
+	args := dbqueries.GetServicePermissionsByUserIdArgs(userId, projectId)
+	rows, err := s.Store.Query(ctx, dbqueries.GetServicePermissionsByUserId, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching service permissions from db", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions, err := pgx.CollectRows(rows, pgx.RowToStructByName[ServicePermission])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid project id."
+			return nil, &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, err
+	}
+
+	return &permissions, nil
+}
+
+// PutServicePermission sets the permissions of the member on one service,
+// replacing any earlier grant
+func (s *Service) PutServicePermission(ctx context.Context, p *ServicePermission, userId, projectId string) (*ServicePermission, error) {
+	err := p.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	args := dbqueries.UpsertServicePermissionArgs(userId, projectId, p.Service, p.Permissions)
+	rows, err := s.Store.Query(ctx, dbqueries.UpsertServicePermission, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating service permission", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	permission, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[ServicePermission])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			// foreign key violation code 23503
+			if pgErr.Code == "23503" {
+				message := "User isn't a member of the project."
+				return nil, &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+			}
+
+			if pgErr.Code == "22P02" {
+				message := "Invalid project id."
+				return nil, &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+			}
+		}
+
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, err
+	}
+
+	return &permission, nil
+}
+
+func (s *Service) DeleteServicePermission(ctx context.Context, p *ServicePermission, userId, projectId string) error {
+	args := dbqueries.DeleteServicePermissionArgs(userId, projectId, p.Service)
+	tag, err := s.Store.Exec(ctx, dbqueries.DeleteServicePermission, args)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid project id."
+			return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error removing service permission", "error", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		message := "No permission for the service was granted to the user."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
+	return nil
+}
+
+// LiftServicePermissions removes every grant of the member and gives back the
+// access of their project role
+func (s *Service) LiftServicePermissions(ctx context.Context, userId, projectId string) error {
+	args := dbqueries.LiftServicePermissionsArgs(userId, projectId)
+	tag, err := s.Store.Exec(ctx, dbqueries.LiftServicePermissions, args)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid project id."
+			return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error lifting service permissions", "error", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		message := "The user isn't limited to service permissions in the project."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
+	return nil
+}
@@ -1,12 +1,12 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"log"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
-	"os"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +15,7 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/rohan031/adgytec-api/v1/custom"
 	"github.com/rohan031/adgytec-api/v1/dbqueries"
+	"github.com/rohan031/adgytec-api/v1/validation"
 )
 
 type Project struct {
@@ -22,6 +23,8 @@ type Project struct {
 	Id          string    `json:"projectId,omitempty" db:"project_id"`
 	CreatedAt   time.Time `json:"createdAt,omitempty" db:"created_at"`
 	Cover       string    `json:"cover" db:"cover_image"`
+	// only set when the project is created, tokens are stored hashed
+	ClientToken string `json:"clientToken,omitempty" db:"-"`
 }
 
 type ProjectDetail struct {
@@ -29,12 +32,26 @@ type ProjectDetail struct {
 	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
 	Users     json.RawMessage `json:"users" db:"user_data"`
 	Services  json.RawMessage `json:"services" db:"service_data"`
-	Token     string          `json:"publicToken" db:"token"`
 	Cover     string          `json:"cover" db:"cover_image"`
 }
 
 type ProjectUserMap struct {
 	UserId string `json:"userId"`
+	Role   string `json:"role,omitempty"`
+}
+
+// validateRole defaults new members to the least privileged role
+func (pu *ProjectUserMap) validateRole() error {
+	if pu.Role == "" {
+		pu.Role = validation.ProjectViewer
+	}
+
+	if !validation.ValidateProjectRole(pu.Role) {
+		message := "Invalid project role, expected owner, editor, author or viewer."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	return nil
 }
 
 type ProjectServiceMap struct {
@@ -57,11 +74,12 @@ type ProjectImage struct {
 	Cover string `db:"cover_image"`
 }
 
-func addProjectToDatabase(p *Project, clientToken string, wg *sync.WaitGroup, errChan chan error) {
+func (s *Service) addProjectToDatabase(ctx context.Context, p *Project, tokenHash string, wg *sync.WaitGroup, errChan chan error) {
 	defer wg.Done()
 
-	args := dbqueries.CreateProjectArgs(p.ProjectName, p.Cover, p.Id, clientToken)
-	_, err := db.Exec(ctx, dbqueries.CreateProject, args)
+	// the first token of a project can use every public endpoint
+	args := dbqueries.CreateProjectArgs(p.ProjectName, p.Cover, p.Id, tokenHash, validation.Scopes)
+	_, err := s.Store.Exec(ctx, dbqueries.CreateProject, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -75,57 +93,60 @@ func addProjectToDatabase(p *Project, clientToken string, wg *sync.WaitGroup, er
 			}
 		}
 
-		log.Printf("Error adding project in database: %v\n", err)
+		slog.ErrorContext(ctx, "Error adding project in database", "error", err)
 	}
 
 	errChan <- err
 }
 
 // admin only
-func (p *Project) CreateProject(r *http.Request) error {
+func (s *Service) CreateProject(ctx context.Context, p *Project, r *http.Request) error {
 	file, header, err := r.FormFile("cover")
 	if err != nil {
-		log.Printf("Error retriving file: %v\n ", err)
+		slog.ErrorContext(ctx, "Error retriving file", "error", err)
 		return err
 	}
 	defer file.Close()
 
-	fileToUpload, format, contentType, size, err := handleRequestImage(file, header)
+	return s.CreateProjectWithCover(ctx, p, file, header)
+}
+
+// CreateProjectWithCover is CreateProject for callers holding the cover
+// outside of a multipart request, like the admin cli
+func (s *Service) CreateProjectWithCover(ctx context.Context, p *Project, file multipart.File, header *multipart.FileHeader) error {
+	fileToUpload, format, contentType, size, err := handleRequestImage(ctx, file, header)
 	if err != nil {
 		return err
 	}
 
 	projectId := GenerateUUID().String()
 
-	objectName := fmt.Sprintf("projects/%v/cover.%v", projectId, format)
+	objectName := s.objectPath("projects/%v/cover.%v", projectId, format)
 
-	if val := os.Getenv("ENV"); val == "dev" {
-		objectName = "dev/" + objectName
-	}
-
-	clientToken, err := generateSecureToken()
+	clientToken, tokenHash, err := newClientToken(ctx)
 	if err != nil {
 		return err
 	}
 
 	p.Cover = objectName
 	p.Id = projectId
+	p.ClientToken = clientToken
 
 	wg := new(sync.WaitGroup)
 	errChan := make(chan error, 2)
 
 	wg.Add(2)
 
-	go uploadImageToCloudStorage(objectName, fileToUpload, size, contentType, wg, errChan)
-	go addProjectToDatabase(p, clientToken, wg, errChan)
+	go s.uploadImageToCloudStorage(ctx, objectName, fileToUpload, size, contentType, wg, errChan)
+	go s.addProjectToDatabase(ctx, p, tokenHash, wg, errChan)
 
 	wg.Wait()
 	close(errChan)
 
 	for err := range errChan {
 		if err != nil {
-			go deleteFromCloudStorage(objectName)
-			go p.DeleteProjectById()
+			s.runInBackground(ctx, func(ctx context.Context) { s.deleteFromCloudStorage(ctx, objectName) })
+			s.runInBackground(ctx, func(ctx context.Context) { s.DeleteProjectById(ctx, p) })
 			return err
 		}
 	}
@@ -133,17 +154,17 @@ func (p *Project) CreateProject(r *http.Request) error {
 	return nil
 }
 
-func (p *Project) GetAllProjects() (*[]Project, error) {
-	rows, err := db.Query(ctx, dbqueries.GetAllProjects)
+func (s *Service) GetAllProjects(ctx context.Context, p *Project) (*[]Project, error) {
+	rows, err := s.Store.Query(ctx, dbqueries.GetAllProjects)
 	if err != nil {
-		log.Printf("Error fetching projects from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching projects from db", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 
 	projects, err := pgx.CollectRows(rows, pgx.RowToStructByName[Project])
 	if err != nil {
-		log.Printf("Error reading rows: %v", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, err
 	}
 
@@ -154,7 +175,7 @@ func (p *Project) GetAllProjects() (*[]Project, error) {
 		wg.Add(1)
 
 		img := item.Cover
-		go generatePresignedUrl(img, ind, expires, wg, urlChan)
+		go s.generatePresignedUrl(ctx, img, ind, expires, wg, urlChan)
 	}
 
 	wg.Wait()
@@ -169,11 +190,11 @@ func (p *Project) GetAllProjects() (*[]Project, error) {
 	return &projects, err
 }
 
-func (p *Project) GetProjectById() (*ProjectDetail, error) {
+func (s *Service) GetProjectById(ctx context.Context, p *Project) (*ProjectDetail, error) {
 	args := dbqueries.GetProjectDetailsByIdArgs(p.Id)
-	rows, err := db.Query(ctx, dbqueries.GetProjectDetailsById, args)
+	rows, err := s.Store.Query(ctx, dbqueries.GetProjectDetailsById, args)
 	if err != nil {
-		log.Printf("Error fetching project details from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching project details from db", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -193,7 +214,7 @@ func (p *Project) GetProjectById() (*ProjectDetail, error) {
 			}
 		}
 
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, err
 	}
 
@@ -203,7 +224,7 @@ func (p *Project) GetProjectById() (*ProjectDetail, error) {
 	wg.Add(1)
 
 	img := project.Cover
-	go generatePresignedUrl(img, 1, expires, wg, urlChan)
+	go s.generatePresignedUrl(ctx, img, 1, expires, wg, urlChan)
 
 	wg.Wait()
 	close(urlChan)
@@ -215,11 +236,11 @@ func (p *Project) GetProjectById() (*ProjectDetail, error) {
 	return &project, err
 }
 
-func (p *Project) DeleteProjectById() error {
+func (s *Service) DeleteProjectById(ctx context.Context, p *Project) error {
 	args := dbqueries.DeleteProjectByIdArgs(p.Id)
-	rows, err := db.Query(ctx, dbqueries.DeleteProjectById, args)
+	rows, err := s.Store.Query(ctx, dbqueries.DeleteProjectById, args)
 	if err != nil {
-		log.Printf("Error deleting project from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error deleting project from db", "error", err)
 		return err
 	}
 	defer rows.Close()
@@ -242,41 +263,41 @@ func (p *Project) DeleteProjectById() error {
 			}
 		}
 
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return err
 	}
 
 	// delete from space storage
-	// err = spaceStorage.RemoveObject(ctx, os.Getenv("SPACE_STORAGE_BUCKET_NAME"), project.Cover, minio.RemoveObjectOptions{})
+	// err = s.Storage.RemoveObject(ctx, s.Config.Storage.Bucket, project.Cover, minio.RemoveObjectOptions{})
 	// if err != nil {
-	// 	log.Printf("Error deleting image from space storage: %v\n", err)
+	// 	slog.ErrorContext(ctx, "Error deleting image from space storage", "error", err)
 	// 	// return err
 	// }
-	go deleteFromCloudStorage(project.Cover)
+	s.runInBackground(ctx, func(ctx context.Context) { s.deleteFromCloudStorage(ctx, project.Cover) })
 
 	return nil
 }
 
-func (p *Project) GetAllServices() (*[]ServicesDetails, error) {
-	rows, err := db.Query(ctx, dbqueries.GetAllServices)
+func (s *Service) GetAllServices(ctx context.Context, p *Project) (*[]ServicesDetails, error) {
+	rows, err := s.Store.Query(ctx, dbqueries.GetAllServices)
 	if err != nil {
-		log.Printf("Error fetching services from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching services from db", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 
 	services, err := pgx.CollectRows(rows, pgx.RowToStructByName[ServicesDetails])
 	if err != nil {
-		log.Printf("Error reading rows: %v", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, err
 	}
 
 	return &services, err
 }
 
-func (ps *ProjectServiceMap) CreateProjectServiceMap(projectId string) error {
+func (s *Service) CreateProjectServiceMap(ctx context.Context, ps *ProjectServiceMap, projectId string) error {
 	query := dbqueries.AddServicesToProject(projectId, ps.Services)
-	_, err := db.Exec(ctx, query)
+	_, err := s.Store.Exec(ctx, query)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -303,16 +324,16 @@ func (ps *ProjectServiceMap) CreateProjectServiceMap(projectId string) error {
 			}
 		}
 
-		log.Printf("Error adding services to project: %v\n", err)
+		slog.ErrorContext(ctx, "Error adding services to project", "error", err)
 		return err
 	}
 
 	return nil
 }
 
-func (ps *ProjectServiceMap) DeleteProjectServiceMap(projectId string) error {
+func (s *Service) DeleteProjectServiceMap(ctx context.Context, ps *ProjectServiceMap, projectId string) error {
 	args := dbqueries.DeleteServiceFromProjectArgs(ps.Services[0], projectId)
-	_, err := db.Exec(ctx, dbqueries.DeleteServiceFromProject, args)
+	_, err := s.Store.Exec(ctx, dbqueries.DeleteServiceFromProject, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -323,16 +344,21 @@ func (ps *ProjectServiceMap) DeleteProjectServiceMap(projectId string) error {
 			}
 		}
 
-		log.Printf("Error removing service from project: %v\n", err)
+		slog.ErrorContext(ctx, "Error removing service from project", "error", err)
 		return err
 	}
 
 	return nil
 }
 
-func (pu *ProjectUserMap) CreateUserProjectMap(projectId string) error {
-	args := dbqueries.AddUserToProjectArgs(pu.UserId, projectId)
-	_, err := db.Exec(ctx, dbqueries.AddUserToProject, args)
+func (s *Service) CreateUserProjectMap(ctx context.Context, pu *ProjectUserMap, projectId string) error {
+	err := pu.validateRole()
+	if err != nil {
+		return err
+	}
+
+	args := dbqueries.AddUserToProjectArgs(pu.UserId, projectId, pu.Role)
+	_, err = s.Store.Exec(ctx, dbqueries.AddUserToProject, args)
 
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -360,16 +386,112 @@ func (pu *ProjectUserMap) CreateUserProjectMap(projectId string) error {
 			}
 		}
 
-		log.Printf("Error adding user to project: %v\n", err)
+		slog.ErrorContext(ctx, "Error adding user to project", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// keepProjectOwner refuses to demote or remove the last owner of the project,
+// role is empty for a removal
+func (s *Service) keepProjectOwner(ctx context.Context, tx pgx.Tx, projectId, userId, role string) error {
+	if role == validation.ProjectOwner {
+		return nil
+	}
+
+	rows, err := tx.Query(ctx, dbqueries.LockProjectOwners, dbqueries.LockProjectOwnersArgs(projectId))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching project owners from db", "error", err)
+		return err
+	}
+	owners, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid project id or user id."
+			return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return err
 	}
 
+	if len(owners) == 1 && owners[0] == userId {
+		message := "The project needs an owner, make another member owner first."
+		return &custom.MalformedRequest{Status: http.StatusConflict, Message: message}
+	}
+
 	return nil
 }
 
-func (pu *ProjectUserMap) DeleteUserProjectMap(projectId string) error {
+// PatchUserProjectMap changes the role of an existing member
+func (s *Service) PatchUserProjectMap(ctx context.Context, pu *ProjectUserMap, projectId string) error {
+	if pu.Role == "" {
+		message := "Project role not provided."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	err := pu.validateRole()
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.Store.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	err = s.keepProjectOwner(ctx, tx, projectId, pu.UserId, pu.Role)
+	if err != nil {
+		return err
+	}
+
+	args := dbqueries.UpdateUserProjectRoleArgs(pu.UserId, projectId, pu.Role)
+	tag, err := tx.Exec(ctx, dbqueries.UpdateUserProjectRole, args)
+	if err != nil {
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			message := "Invalid project id or user id."
+			return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
+
+		slog.ErrorContext(ctx, "Error updating user role in project", "error", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		message := "User isn't a member of the project."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteUserProjectMap(ctx context.Context, pu *ProjectUserMap, projectId string) error {
+	tx, err := s.Store.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	err = s.keepProjectOwner(ctx, tx, projectId, pu.UserId, "")
+	if err != nil {
+		return err
+	}
+
 	args := dbqueries.DeleteUserFromProjectArgs(pu.UserId, projectId)
-	_, err := db.Exec(ctx, dbqueries.DeleteUserFromProject, args)
+	_, err = tx.Exec(ctx, dbqueries.DeleteUserFromProject, args)
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -380,7 +502,13 @@ func (pu *ProjectUserMap) DeleteUserProjectMap(projectId string) error {
 			}
 		}
 
-		log.Printf("Error removing user from project: %v\n", err)
+		slog.ErrorContext(ctx, "Error removing user from project", "error", err)
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
 		return err
 	}
 
@@ -388,18 +516,18 @@ func (pu *ProjectUserMap) DeleteUserProjectMap(projectId string) error {
 }
 
 // admin and user
-func (p *Project) GetProjectsByUserId(userId string) (*[]Project, error) {
+func (s *Service) GetProjectsByUserId(ctx context.Context, p *Project, userId string) (*[]Project, error) {
 	args := dbqueries.GetProjectByUserIdArgs(userId)
-	rows, err := db.Query(ctx, dbqueries.GetProjectByUserId, args)
+	rows, err := s.Store.Query(ctx, dbqueries.GetProjectByUserId, args)
 	if err != nil {
-		log.Printf("Error fetching projects from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching projects from db", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 
 	projects, err := pgx.CollectRows(rows, pgx.RowToStructByName[Project])
 	if err != nil {
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, err
 	}
 
@@ -410,7 +538,7 @@ func (p *Project) GetProjectsByUserId(userId string) (*[]Project, error) {
 		wg.Add(1)
 
 		img := item.Cover
-		go generatePresignedUrl(img, ind, expires, wg, urlChan)
+		go s.generatePresignedUrl(ctx, img, ind, expires, wg, urlChan)
 	}
 
 	wg.Wait()
@@ -425,11 +553,11 @@ func (p *Project) GetProjectsByUserId(userId string) (*[]Project, error) {
 	return &projects, err
 }
 
-func (p *Project) GetMetadataByProjectId() (*MetaDataByProject, error) {
+func (s *Service) GetMetadataByProjectId(ctx context.Context, p *Project) (*MetaDataByProject, error) {
 	args := dbqueries.GetMetadataByProjectIdArgs(p.Id)
-	rows, err := db.Query(ctx, dbqueries.GetMetadataByProjectId, args)
+	rows, err := s.Store.Query(ctx, dbqueries.GetMetadataByProjectId, args)
 	if err != nil {
-		log.Printf("Error fetching project details from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching project details from db", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -449,7 +577,7 @@ func (p *Project) GetMetadataByProjectId() (*MetaDataByProject, error) {
 			}
 		}
 
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, err
 	}
 
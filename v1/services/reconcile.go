@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/rohan031/adgytec-api/identity"
+	"github.com/rohan031/adgytec-api/v1/dbqueries"
+)
+
+/*
+consistency between the identity provider and the users table
+*/
+
+// kinds of drift, the users table is the source of truth
+const (
+	driftMissingAccount = "missing_account"
+	driftMissingUser    = "missing_user"
+	driftRole           = "role"
+	driftName           = "name"
+	driftSuspension     = "suspension"
+)
+
+type UserInconsistency struct {
+	UserId   string `json:"userId"`
+	Email    string `json:"email"`
+	Problem  string `json:"problem"`
+	Repaired bool   `json:"repaired"`
+
+	kind    string
+	account *identity.User
+	user    *User
+}
+
+// CheckUserConsistency compares every account of the identity provider with
+// the users table, reporting accounts missing on either side and role claims,
+// names or suspensions out of sync with the stored ones
+func (s *Service) CheckUserConsistency(ctx context.Context) ([]UserInconsistency, error) {
+	rows, err := s.Store.Query(ctx, dbqueries.GetUsersForReconcile)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching users from db", "error", err)
+		return nil, err
+	}
+	dbUsers, err := pgx.CollectRows(rows, pgx.RowToStructByName[User])
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, err
+	}
+
+	authCtx, cancel := s.authContext(ctx)
+	defer cancel()
+
+	identityUsers, err := s.Identity.ListUsers(authCtx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing users from identity provider", "error", err)
+		return nil, err
+	}
+
+	accounts := make(map[string]*identity.User, len(identityUsers))
+	for _, account := range identityUsers {
+		accounts[account.UID] = account
+	}
+
+	var problems []UserInconsistency
+	stored := make(map[string]bool, len(dbUsers))
+	for i := range dbUsers {
+		user := &dbUsers[i]
+		stored[user.UserId] = true
+
+		account, ok := accounts[user.UserId]
+		if !ok {
+			problems = append(problems, UserInconsistency{
+				UserId:  user.UserId,
+				Email:   user.Email,
+				Problem: "missing from identity provider",
+				kind:    driftMissingAccount,
+				user:    user,
+			})
+			continue
+		}
+
+		if role, _ := account.Claims["role"].(string); role != user.Role {
+			problems = append(problems, UserInconsistency{
+				UserId:  user.UserId,
+				Email:   user.Email,
+				Problem: fmt.Sprintf("role claim %q doesn't match role %q", role, user.Role),
+				kind:    driftRole,
+				account: account,
+				user:    user,
+			})
+		} else if account.Name != user.Name {
+			problems = append(problems, UserInconsistency{
+				UserId:  user.UserId,
+				Email:   user.Email,
+				Problem: fmt.Sprintf("name %q doesn't match name %q", account.Name, user.Name),
+				kind:    driftName,
+				account: account,
+				user:    user,
+			})
+		} else if suspended := user.Status == "suspended"; account.Disabled != suspended {
+			problems = append(problems, UserInconsistency{
+				UserId:  user.UserId,
+				Email:   user.Email,
+				Problem: fmt.Sprintf("account disabled is %t for status %q", account.Disabled, user.Status),
+				kind:    driftSuspension,
+				account: account,
+				user:    user,
+			})
+		}
+	}
+
+	for _, account := range identityUsers {
+		if !stored[account.UID] {
+			problems = append(problems, UserInconsistency{
+				UserId:  account.UID,
+				Email:   account.Email,
+				Problem: "missing from users table",
+				kind:    driftMissingUser,
+				account: account,
+			})
+		}
+	}
+
+	return problems, nil
+}
+
+// ReconcileUsers repairs the drift found by CheckUserConsistency from the
+// users table: accounts get the stored name, role and suspension and accounts
+// without a users row are deleted. Users without an account can't be
+// recreated with the same id and are only reported
+func (s *Service) ReconcileUsers(ctx context.Context) ([]UserInconsistency, error) {
+	problems, err := s.CheckUserConsistency(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	authCtx, cancel := s.authContext(ctx)
+	defer cancel()
+
+	for i := range problems {
+		p := &problems[i]
+
+		switch p.kind {
+		case driftRole:
+			err = s.setIdentityUser(authCtx, p.account, p.user.Name, p.user.Role)
+			if err == nil {
+				// tokens still carry the drifted role
+				err = s.RevokeUserSessions(ctx, p.user)
+			}
+
+		case driftName:
+			err = s.setIdentityUser(authCtx, p.account, p.user.Name, p.user.Role)
+
+		case driftSuspension:
+			suspended := p.user.Status == "suspended"
+			err = s.Identity.UpdateUser(authCtx, p.UserId, identity.UserToUpdate{Disabled: &suspended})
+
+		case driftMissingUser:
+			err = s.deleteOrphanAccount(ctx, p.UserId)
+
+		default:
+			continue
+		}
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Error repairing user", "userId", p.UserId, "problem", p.Problem, "error", err)
+			continue
+		}
+		p.Repaired = true
+	}
+
+	return problems, nil
+}
+
+// deleteOrphanAccount deletes an account without a users row, the row is
+// looked up again as invites create the account right before the row
+func (s *Service) deleteOrphanAccount(ctx context.Context, userId string) error {
+	var exists bool
+	args := dbqueries.GetUserByIDArgs(userId)
+	err := s.Store.QueryRow(ctx, dbqueries.UserExists, args).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("users row was created in the meantime")
+	}
+
+	ctx, cancel := s.authContext(ctx)
+	defer cancel()
+
+	err = s.Identity.DeleteUser(ctx, userId)
+	if errors.Is(err, identity.ErrUserNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// StartUserReconciliation repairs the drift every interval until ctx is done,
+// a graceful shutdown waits for a running repair
+func (s *Service) StartUserReconciliation(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.Config.Timeouts.Background)
+			problems, err := s.ReconcileUsers(runCtx)
+			cancel()
+			if err != nil {
+				slog.ErrorContext(ctx, "Error reconciling users", "error", err)
+				continue
+			}
+
+			for _, p := range problems {
+				slog.WarnContext(ctx, "User drift", "userId", p.UserId, "problem", p.Problem, "repaired", p.Repaired)
+			}
+		}
+	}()
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/rohan031/adgytec-api/identity"
+	"github.com/rohan031/adgytec-api/v1/dbqueries"
+)
+
+// revocationTime is when tokens stop being accepted, kept at full precision.
+// Tokens carry their issue time in seconds so TokenAuthentication compares
+// whole seconds
+func revocationTime() time.Time {
+	// timestamps are stored without a zone
+	return time.Now().UTC()
+}
+
+// RevokeUserSessions rejects every token issued to the user so far and makes
+// them sign in again
+func (s *Service) RevokeUserSessions(ctx context.Context, u *User) error {
+	args := dbqueries.RevokeUserSessionsArgs(u.UserId, revocationTime())
+	tag, err := s.Store.Exec(ctx, dbqueries.RevokeUserSessions, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error revoking user sessions in database", "error", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return userNotFound
+	}
+	s.Sessions.Invalidate(u.UserId)
+
+	authCtx, cancel := s.authContext(ctx)
+	defer cancel()
+
+	// without it firebase would hand out fresh tokens on refresh
+	err = s.Identity.RevokeSessions(authCtx, u.UserId)
+	if err != nil && !errors.Is(err, identity.ErrUserNotFound) {
+		slog.ErrorContext(ctx, "Error revoking user sessions in identity provider", "error", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,480 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rohan031/adgytec-api/v1/custom"
+	"github.com/rohan031/adgytec-api/v1/dbqueries"
+	"github.com/rohan031/adgytec-api/v1/validation"
+)
+
+// an import is meant for onboarding the staff of a client, not migrations
+const maxImportRows = 500
+
+// statuses of an import row, rows are only valid in a dry run. A partial row
+// has its user created without the memberships
+const (
+	ImportRowValid   = "valid"
+	ImportRowCreated = "created"
+	ImportRowPartial = "partial"
+	ImportRowFailed  = "failed"
+)
+
+// columns of the import, projects is optional and every other column is
+// ignored so an export can be imported back
+var importColumns = []string{"email", "name", "role"}
+
+var exportColumns = []string{"user_id", "email", "name", "role", "status", "invite_status", "created_at", "projects"}
+
+type ImportMembership struct {
+	ProjectId string `json:"projectId"`
+	Role      string `json:"role"`
+}
+
+// ImportRow is the result of a line of the file, a row is created only when
+// it has no errors
+type ImportRow struct {
+	Line        int                `json:"line"`
+	Email       string             `json:"email"`
+	Name        string             `json:"name"`
+	Role        string             `json:"role"`
+	Memberships []ImportMembership `json:"memberships"`
+	Status      string             `json:"status"`
+	UserId      string             `json:"userId,omitempty"`
+	Errors      []string           `json:"errors,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun  bool        `json:"dryRun"`
+	Total   int         `json:"total"`
+	Valid   int         `json:"valid"`
+	Created int         `json:"created"`
+	Partial int         `json:"partial"`
+	Failed  int         `json:"failed"`
+	Rows    []ImportRow `json:"rows"`
+}
+
+type UserExport struct {
+	UserId       string    `db:"user_id"`
+	Email        string    `db:"email"`
+	Name         string    `db:"name"`
+	Role         string    `db:"role"`
+	Status       string    `db:"status"`
+	InviteStatus string    `db:"invite_status"`
+	CreatedAt    time.Time `db:"created_at"`
+	Projects     string    `db:"projects"`
+}
+
+func invalidImport(message string) error {
+	return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+}
+
+// parseProjects reads assignments written as "project-id:role;project-id:role"
+func (row *ImportRow) parseProjects(projects string) {
+	row.Memberships = []ImportMembership{}
+
+	for _, assignment := range strings.Split(projects, ";") {
+		assignment = strings.TrimSpace(assignment)
+		if assignment == "" {
+			continue
+		}
+
+		projectId, role, ok := strings.Cut(assignment, ":")
+		if !ok {
+			row.Errors = append(row.Errors, fmt.Sprintf("Invalid project assignment %q, expected project-id:role.", assignment))
+			continue
+		}
+
+		row.Memberships = append(row.Memberships, ImportMembership{
+			ProjectId: strings.ToLower(strings.TrimSpace(projectId)),
+			Role:      strings.TrimSpace(role),
+		})
+	}
+}
+
+// parseImport reads the rows of the file, only errors with the file itself
+// are returned, errors of a row are kept in the row
+func parseImport(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	// rows with missing columns are reported per row
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, invalidImport("The file is empty.")
+		}
+
+		return nil, invalidImport("The file isn't a valid csv file.")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		// spreadsheet exports often start with a byte order mark
+		column = strings.TrimPrefix(column, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	for _, column := range importColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, invalidImport(fmt.Sprintf("The file is missing the %s column.", column))
+		}
+	}
+	projectsColumn, hasProjects := columns["projects"]
+
+	rows := []ImportRow{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, invalidImport(fmt.Sprintf("The file isn't a valid csv file, error on line %d.", parseErr.Line))
+			}
+
+			return nil, err
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, invalidImport(fmt.Sprintf("The file has more than %d users.", maxImportRows))
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(column int) string {
+			if column >= len(record) {
+				return ""
+			}
+
+			return unescapeCell(strings.TrimSpace(record[column]))
+		}
+
+		row := ImportRow{
+			Line:  line,
+			Email: strings.ToLower(field(columns["email"])),
+			Name:  field(columns["name"]),
+			Role:  field(columns["role"]),
+		}
+
+		projects := ""
+		if hasProjects {
+			projects = field(projectsColumn)
+		}
+		row.parseProjects(projects)
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, invalidImport("The file has no users.")
+	}
+
+	return rows, nil
+}
+
+// validate checks a row the way PostUser and PostProjectAndUser check their
+// bodies
+func (row *ImportRow) validate(myRole string) {
+	if !validation.ValidateEmail(row.Email) {
+		row.Errors = append(row.Errors, "Invalid email.")
+	}
+
+	if !validation.ValidateName(row.Name) {
+		row.Errors = append(row.Errors, "Invalid name.")
+	}
+
+	if !validation.ValidateRole(row.Role) {
+		row.Errors = append(row.Errors, "Invalid role, expected super_admin, admin or user.")
+	} else if !validation.AuthorizeRole(myRole, row.Role) {
+		row.Errors = append(row.Errors, "Insufficient privileges to create a user account with the specified role.")
+	}
+
+	assigned := make(map[string]bool, len(row.Memberships))
+	for i := range row.Memberships {
+		m := &row.Memberships[i]
+
+		id, err := uuid.Parse(m.ProjectId)
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("Invalid project id: %s", m.ProjectId))
+			continue
+		}
+		// compared with the ids postgres returns
+		m.ProjectId = id.String()
+
+		if !validation.ValidateProjectRole(m.Role) {
+			row.Errors = append(row.Errors, fmt.Sprintf("Invalid project role: %s, expected owner, editor, author or viewer.", m.Role))
+		}
+
+		if assigned[m.ProjectId] {
+			row.Errors = append(row.Errors, fmt.Sprintf("The project %s is assigned more than once.", m.ProjectId))
+		}
+		assigned[m.ProjectId] = true
+	}
+}
+
+// collectStrings runs a query returning a single text column
+func (s *Service) collectStrings(ctx context.Context, query string, args pgx.NamedArgs) (map[string]bool, error) {
+	rows, err := s.Store.Query(ctx, query, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching import references from db", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	values, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, err
+	}
+
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+
+	return set, nil
+}
+
+// checkReferences reports rows whose email is taken, within the file or by
+// an existing account, and rows assigned to projects that don't exist
+func (s *Service) checkReferences(ctx context.Context, rows []ImportRow) error {
+	emails := []string{}
+	projectIds := []string{}
+	for _, row := range rows {
+		emails = append(emails, row.Email)
+		for _, m := range row.Memberships {
+			if _, err := uuid.Parse(m.ProjectId); err == nil {
+				projectIds = append(projectIds, m.ProjectId)
+			}
+		}
+	}
+
+	existingEmails, err := s.collectStrings(ctx, dbqueries.GetExistingEmails, dbqueries.GetExistingEmailsArgs(emails))
+	if err != nil {
+		return err
+	}
+
+	existingProjects, err := s.collectStrings(ctx, dbqueries.GetExistingProjects, dbqueries.GetExistingProjectsArgs(projectIds))
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]int, len(rows))
+	for i := range rows {
+		row := &rows[i]
+
+		if line, ok := seen[row.Email]; ok {
+			row.Errors = append(row.Errors, fmt.Sprintf("The email address is already used on line %d.", line))
+		} else if existingEmails[row.Email] {
+			row.Errors = append(row.Errors, "The email address provided is already associated with an existing user account.")
+		}
+		seen[row.Email] = row.Line
+
+		for _, m := range row.Memberships {
+			if _, err := uuid.Parse(m.ProjectId); err == nil && !existingProjects[m.ProjectId] {
+				row.Errors = append(row.Errors, fmt.Sprintf("Project id doesn't exist: %s", m.ProjectId))
+			}
+		}
+	}
+
+	return nil
+}
+
+// createImportedUser invites the user of the row and adds the memberships,
+// the memberships are added together or not at all
+func (s *Service) createImportedUser(ctx context.Context, row *ImportRow, invitedBy string) (*InviteDetails, error) {
+	u := User{Email: row.Email, Name: row.Name, Role: row.Role}
+	details, err := s.InviteUser(ctx, &u, invitedBy)
+	if err != nil {
+		return nil, err
+	}
+	row.UserId = u.UserId
+
+	if len(row.Memberships) == 0 {
+		return details, nil
+	}
+
+	tx, err := s.Store.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return details, err
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	for _, m := range row.Memberships {
+		_, err = tx.Exec(ctx, dbqueries.AddUserToProject, dbqueries.AddUserToProjectArgs(u.UserId, m.ProjectId, m.Role))
+		if err != nil {
+			slog.ErrorContext(ctx, "Error adding imported user to project", "error", err)
+			return details, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return details, err
+	}
+
+	return details, nil
+}
+
+// ImportUsers creates a user for every valid row of the csv file and invites
+// them, in a dry run the rows are only validated
+func (s *Service) ImportUsers(ctx context.Context, file io.Reader, myRole, invitedBy string, dryRun bool) (*ImportReport, error) {
+	rows, err := parseImport(file)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		rows[i].validate(myRole)
+	}
+
+	err = s.checkReferences(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: dryRun, Total: len(rows), Rows: rows}
+	invites := []*InviteDetails{}
+	for i := range rows {
+		row := &rows[i]
+
+		if len(row.Errors) > 0 {
+			row.Status = ImportRowFailed
+			report.Failed++
+			continue
+		}
+
+		if dryRun {
+			row.Status = ImportRowValid
+			report.Valid++
+			continue
+		}
+
+		details, err := s.createImportedUser(ctx, row, invitedBy)
+		if details != nil {
+			invites = append(invites, details)
+		}
+
+		if err != nil && details == nil {
+			message := "The user couldn't be created."
+			var mr *custom.MalformedRequest
+			if errors.As(err, &mr) {
+				message = mr.Message
+			}
+
+			row.Status = ImportRowFailed
+			row.Errors = append(row.Errors, message)
+			report.Failed++
+			continue
+		}
+
+		// the account exists, only the memberships are missing
+		if err != nil {
+			row.Status = ImportRowPartial
+			row.Errors = append(row.Errors, "The user was created but couldn't be added to the projects.")
+			report.Valid++
+			report.Partial++
+			continue
+		}
+
+		row.Status = ImportRowCreated
+		report.Valid++
+		report.Created++
+	}
+
+	// invites can be resent from the dashboard when sending fails
+	if len(invites) > 0 {
+		s.runInBackground(ctx, func(ctx context.Context) {
+			for _, details := range invites {
+				err := s.SendInvite(ctx, details)
+				if err != nil {
+					slog.ErrorContext(ctx, "Error sending invite of imported user", "error", err)
+				}
+			}
+		})
+	}
+
+	return report, nil
+}
+
+func (s *Service) ExportUsers(ctx context.Context) ([]UserExport, error) {
+	rows, err := s.Store.Query(ctx, dbqueries.ExportUsers)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching users from db", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByName[UserExport])
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// cells starting with these are run as formulas by spreadsheets
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeCell keeps user controlled values from running as formulas when the
+// export is opened in a spreadsheet
+func escapeCell(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+// unescapeCell reverses escapeCell so an export can be imported back
+func unescapeCell(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+
+	return value
+}
+
+// WriteUsersCSV writes the export in the format ImportUsers reads
+func WriteUsersCSV(w io.Writer, users []UserExport) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write(exportColumns)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		err = writer.Write([]string{
+			escapeCell(u.UserId),
+			escapeCell(u.Email),
+			escapeCell(u.Name),
+			escapeCell(u.Role),
+			u.Status,
+			u.InviteStatus,
+			u.CreatedAt.UTC().Format(time.RFC3339),
+			escapeCell(u.Projects),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rohan031/adgytec-api/v1/custom"
+)
+
+func TestParseImport(t *testing.T) {
+	file := "\ufeffEmail, Name ,ROLE,Projects,ignored\n" +
+		"First.Import@Gmail.com,First Import,user,11111111-1111-1111-1111-111111111111:author; 22222222-2222-2222-2222-222222222222:viewer,x\n" +
+		"second@gmail.com,'=Second,admin\n" +
+		"third@gmail.com,Third,user,not-an-assignment\n"
+
+	rows, err := parseImport(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Error parsing import: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("unexpected number of rows: got %v want 3", len(rows))
+	}
+
+	first := rows[0]
+	if first.Line != 2 || first.Email != "first.import@gmail.com" || first.Name != "First Import" || first.Role != "user" {
+		t.Errorf("unexpected first row: %+v", first)
+	}
+	if len(first.Memberships) != 2 || first.Memberships[1].ProjectId != "22222222-2222-2222-2222-222222222222" || first.Memberships[1].Role != "viewer" {
+		t.Errorf("unexpected memberships: %+v", first.Memberships)
+	}
+
+	// missing columns are empty, escaped formulas are read back
+	second := rows[1]
+	if second.Name != "=Second" || len(second.Memberships) != 0 || len(second.Errors) != 0 {
+		t.Errorf("unexpected second row: %+v", second)
+	}
+
+	if len(rows[2].Errors) != 1 {
+		t.Errorf("invalid assignment wasn't reported: %+v", rows[2])
+	}
+}
+
+func TestParseImportInvalidFile(t *testing.T) {
+	tooMany := &strings.Builder{}
+	tooMany.WriteString("email,name,role\n")
+	for i := 0; i <= maxImportRows; i++ {
+		fmt.Fprintf(tooMany, "user%d@gmail.com,User,user\n", i)
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		expected string
+	}{
+		{"empty", "", "The file is empty."},
+		{"missing column", "email,name\nuser@gmail.com,User\n", "The file is missing the role column."},
+		{"no users", "email,name,role\n", "The file has no users."},
+		{"unterminated quote", "email,name,role\nuser@gmail.com,\"User,user\n", "The file isn't a valid csv file, error on line 2."},
+		{"too many users", tooMany.String(), fmt.Sprintf("The file has more than %d users.", maxImportRows)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseImport(strings.NewReader(tt.file))
+
+			var mr *custom.MalformedRequest
+			if !errors.As(err, &mr) || mr.Message != tt.expected {
+				t.Errorf("unexpected error: got %v want %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteUsersCSV(t *testing.T) {
+	users := []UserExport{{
+		UserId:       "uid",
+		Email:        "user@gmail.com",
+		Name:         `=HYPERLINK("https://attacker.example")`,
+		Role:         "user",
+		Status:       "active",
+		InviteStatus: "accepted",
+		CreatedAt:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Projects:     "11111111-1111-1111-1111-111111111111:editor",
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteUsersCSV(&buf, users); err != nil {
+		t.Fatalf("Error writing csv: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), `'=HYPERLINK(`) {
+		t.Errorf("formula wasn't escaped: %s", buf.String())
+	}
+
+	// the export can be imported back
+	rows, err := parseImport(&buf)
+	if err != nil {
+		t.Fatalf("Error parsing export: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Name != users[0].Name || len(rows[0].Memberships) != 1 {
+		t.Errorf("export wasn't read back: %+v", rows)
+	}
+}
+
+func TestEscapeCell(t *testing.T) {
+	for value, expected := range map[string]string{
+		"":           "",
+		"Plain Name": "Plain Name",
+		"=1+1":       "'=1+1",
+		"+1":         "'+1",
+		"-1":         "'-1",
+		"@SUM(A1)":   "'@SUM(A1)",
+		"\tname":     "'\tname",
+		"\rname":     "'\rname",
+		"'quoted":    "'quoted",
+	} {
+		escaped := escapeCell(value)
+		if escaped != expected {
+			t.Errorf("escapeCell(%q) = %q want %q", value, escaped, expected)
+		}
+		if got := unescapeCell(escaped); got != value {
+			t.Errorf("unescapeCell(%q) = %q want %q", escaped, got, value)
+		}
+	}
+}
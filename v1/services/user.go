@@ -1,29 +1,24 @@
 package services
 
 import (
+	"context"
 	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"math/big"
 	"net/http"
 	"strings"
-	"sync"
 	"time"
 
-	"firebase.google.com/go/v4/auth"
-
 	"github.com/jackc/pgx/v5"
+	"github.com/rohan031/adgytec-api/identity"
 	"github.com/rohan031/adgytec-api/v1/custom"
 	"github.com/rohan031/adgytec-api/v1/dbqueries"
 	"github.com/rohan031/adgytec-api/v1/validation"
 )
 
-type UserCreationDetails struct {
-	Name     string
-	Email    string
-	Password string
-}
-
 type User struct {
 	Name      string    `json:"name" db:"name"`
 	Email     string    `json:"email" db:"email"`
@@ -31,6 +26,18 @@ type User struct {
 	UserId    string    `json:"userId,omitempty" db:"user_id"`
 	CreatedAt time.Time `json:"createdAt,omitempty" db:"created_at"`
 	Cursor    int       `json:"-" db:"cursor"`
+	// pending until the invite is accepted, see InviteUser
+	InviteStatus string `json:"inviteStatus,omitempty" db:"invite_status"`
+	// suspended users can't sign in, see SuspendUser
+	Status      string     `json:"status,omitempty" db:"status"`
+	SuspendedAt *time.Time `json:"suspendedAt,omitempty" db:"suspended_at"`
+	// tokens issued until then are rejected, see RevokeUserSessions
+	SessionsRevokedAt *time.Time `json:"sessionsRevokedAt,omitempty" db:"sessions_revoked_at"`
+}
+
+// UserDeletion names the user inheriting the content of the deleted one
+type UserDeletion struct {
+	InheritorId string `json:"inheritorId"`
 }
 
 /*
@@ -63,17 +70,17 @@ func generateRandomPassword() (string, error) {
 
 /*
 if user exists in db return true
-else delete user from firebase and re-add it
+else delete user from identity provider and re-add it
 return false, err // internal server error
 return true, nil // user exits
-return false, nil // delete user from firebase and create new user
+return false, nil // delete user from identity provider and create new user
 */
-func userExistsInDb(email string) (bool, error) {
+func (s *Service) userExistsInDb(ctx context.Context, email string) (bool, error) {
 	// fetching the user from db
 	args := dbqueries.GetUserByEmailArgs(email)
-	rows, err := db.Query(ctx, dbqueries.GetUserByEmail, args)
+	rows, err := s.Store.Query(ctx, dbqueries.GetUserByEmail, args)
 	if err != nil {
-		log.Printf("Error fetching user from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching user from db", "error", err)
 		return false, err
 	}
 	defer rows.Close()
@@ -82,42 +89,43 @@ func userExistsInDb(email string) (bool, error) {
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			// user doesn't exist in db
-			u, err := firebaseClient.GetUserByEmail(ctx, email)
+			ctx, cancel := s.authContext(ctx)
+			defer cancel()
+
+			u, err := s.Identity.GetUserByEmail(ctx, email)
 			if err != nil {
-				log.Printf("Error getting user data from firebase: %v\n", err)
+				slog.ErrorContext(ctx, "Error getting user data from identity provider", "error", err)
 				return false, err
 			}
 
-			err = firebaseClient.DeleteUser(ctx, u.UID)
+			err = s.Identity.DeleteUser(ctx, u.UID)
 			if err != nil {
-				log.Printf("Error deleting user from firebase: %v\n", err)
+				slog.ErrorContext(ctx, "Error deleting user from identity provider", "error", err)
 				return false, err
 			}
 
 			return false, nil
 		}
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return false, err
 	}
 
 	return true, nil
 }
 
-func (u *User) CreateUser() (string, error) {
-	// creating random password
-	password, err := generateRandomPassword()
-	if err != nil {
-		log.Printf("Error generating password: %v\n", err)
-		return "", err
-	}
+// createIdentityUser creates the account with the role claim, an account
+// left behind without a users row is replaced
+func (s *Service) createIdentityUser(ctx context.Context, u *User, password string) (string, error) {
+	authCtx, cancel := s.authContext(ctx)
+	defer cancel()
 
-	// creating user in firebase
-	params := (&auth.UserToCreate{}).Email(u.Email).DisplayName(u.Name).Password(password)
-	userRecord, err := firebaseClient.CreateUser(ctx, params)
+	// creating user in identity provider
+	params := identity.UserToCreate{Email: u.Email, Name: u.Name, Password: password}
+	userRecord, err := s.Identity.CreateUser(authCtx, params)
 	if err != nil {
-		if auth.IsEmailAlreadyExists(err) {
+		if errors.Is(err, identity.ErrEmailExists) {
 			// find user in db
-			ispresent, err := userExistsInDb(u.Email)
+			ispresent, err := s.userExistsInDb(ctx, u.Email)
 			if err != nil {
 				return "", err
 			}
@@ -129,30 +137,49 @@ func (u *User) CreateUser() (string, error) {
 			}
 
 			// create new user with given details
-			return u.CreateUser()
+			return s.createIdentityUser(ctx, u, password)
 		}
 
-		log.Printf("Error creating user in firebase: %v\n", err)
+		slog.ErrorContext(ctx, "Error creating user in identity provider", "error", err)
 		return "", err
 	}
 
 	// setting custom claims for newly created user
 	uid := userRecord.UID
 	claims := map[string]interface{}{"role": u.Role}
-	err = firebaseClient.SetCustomUserClaims(ctx, uid, claims)
+	err = s.Identity.SetClaims(authCtx, uid, claims)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error setting custom claims", "error", err)
+		return "", err
+	}
+
+	return uid, nil
+}
+
+// CreateUser creates an account with a generated password, only meant for
+// bootstrapping from the admin cli, the dashboard invites users instead
+func (s *Service) CreateUser(ctx context.Context, u *User) (string, error) {
+	// creating random password
+	password, err := generateRandomPassword()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error generating password", "error", err)
+		return "", err
+	}
+
+	uid, err := s.createIdentityUser(ctx, u, password)
 	if err != nil {
-		log.Printf("Error setting custom claims: %v\n", err)
 		return "", err
 	}
 
 	// inserting into database user table
 	args := dbqueries.CreateUserArgs(uid, u.Email, u.Name, u.Role)
-	_, err = db.Exec(ctx, dbqueries.CreateUser, args)
+	_, err = s.Store.Exec(ctx, dbqueries.CreateUser, args)
 	if err != nil {
-		log.Printf("Error adding user in database: %v\n", err)
+		slog.ErrorContext(ctx, "Error adding user in database", "error", err)
 		return "", err
 	}
 
+	u.UserId = uid
 	return password, nil
 }
 
@@ -184,159 +211,274 @@ func (u *User) ValidateUpdateInput() bool {
 	// 	validation.ValidateName(u.Name))
 }
 
-func updateUserFirebase(userId, name, role string, wg *sync.WaitGroup, errchan chan error) {
-	defer wg.Done()
+// userNotFound is returned when the account to update doesn't exist
+var userNotFound = &custom.MalformedRequest{Status: http.StatusNotFound, Message: "No user found."}
 
-	// updating user name
-	params := (&auth.UserToUpdate{}).DisplayName(name)
-	_, err := firebaseClient.UpdateUser(ctx, userId, params)
+// setIdentityUser writes the name and, when role isn't empty, the role claim.
+// Other claims of the account are kept
+func (s *Service) setIdentityUser(ctx context.Context, account *identity.User, name, role string) error {
+	err := s.Identity.UpdateUser(ctx, account.UID, identity.UserToUpdate{Name: name})
 	if err != nil {
-		log.Printf("Error updating user: %v\n", err)
-		errchan <- err
-		return
+		return err
 	}
 
-	// updating custom claims
-	newClaims := map[string]interface{}{"role": role}
-	err = firebaseClient.SetCustomUserClaims(ctx, userId, newClaims)
-	if err != nil {
-		log.Printf("Error setting custom claims: %v\n", err)
+	if role == "" {
+		return nil
+	}
+
+	claims := make(map[string]any, len(account.Claims)+1)
+	for k, v := range account.Claims {
+		claims[k] = v
 	}
+	claims["role"] = role
 
-	errchan <- err
+	return s.Identity.SetClaims(ctx, account.UID, claims)
 }
 
-func updateUserDatabase(userId, name, role string, wg *sync.WaitGroup, errchan chan error) {
-	defer wg.Done()
+// restoreIdentityUser compensates a failed update by writing the account as
+// it was before, what can't be restored is left to the reconciliation
+func (s *Service) restoreIdentityUser(ctx context.Context, prev *identity.User) {
+	ctx, cancel := s.authContext(context.WithoutCancel(ctx))
+	defer cancel()
 
-	args := dbqueries.UpdateUserArgs(name, role, userId)
-	_, err := db.Exec(ctx, dbqueries.UpdateUser, args)
+	err := s.Identity.UpdateUser(ctx, prev.UID, identity.UserToUpdate{Name: prev.Name, Disabled: &prev.Disabled})
+	if err == nil {
+		err = s.Identity.SetClaims(ctx, prev.UID, prev.Claims)
+	}
 	if err != nil {
-		log.Printf("Error updating user in database: %v\n", err)
+		slog.ErrorContext(ctx, "Error restoring user in identity provider, left for reconciliation", "userId", prev.UID, "error", err)
 	}
-
-	errchan <- err
 }
 
-func (u *User) UpdateUser() error {
-	errchan := make(chan error, 2)
-	wg := new(sync.WaitGroup)
-
-	wg.Add(2)
-	go updateUserFirebase(u.UserId, u.Name, u.Role, wg, errchan)
-	go updateUserDatabase(u.UserId, u.Name, u.Role, wg, errchan)
-
-	wg.Wait()
-	close(errchan)
+// updateUser changes the users row and the identity account as one. The row
+// stays locked in a transaction until the identity provider succeeded, when
+// the commit fails the account is restored. An empty role keeps the role
+func (s *Service) updateUser(ctx context.Context, userId, name, role string) error {
+	authCtx, cancel := s.authContext(ctx)
+	defer cancel()
 
-	for err := range errchan {
-		if err != nil {
-			return err
+	prev, err := s.Identity.GetUser(authCtx, userId)
+	if err != nil {
+		if errors.Is(err, identity.ErrUserNotFound) {
+			return userNotFound
 		}
+
+		slog.ErrorContext(ctx, "Error getting user from identity provider", "error", err)
+		return err
 	}
 
-	return nil
-}
+	tx, err := s.Store.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return err
+	}
+	// no-op once committed
+	defer tx.Rollback(context.WithoutCancel(ctx))
 
-func updateUserNameFirebase(userId, name string, wg *sync.WaitGroup, errchan chan error) {
-	defer wg.Done()
+	query, args := dbqueries.UpdateUserName, dbqueries.UpdateUserNameArgs(name, userId)
+	if role != "" {
+		query, args = dbqueries.UpdateUser, dbqueries.UpdateUserArgs(name, role, userId, revocationTime())
+	}
 
-	params := (&auth.UserToUpdate{}).DisplayName(name)
-	_, err := firebaseClient.UpdateUser(ctx, userId, params)
+	tag, err := tx.Exec(ctx, query, args)
 	if err != nil {
-		log.Printf("Error updating user: %v\n", err)
-
+		slog.ErrorContext(ctx, "Error updating user in database", "error", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return userNotFound
 	}
-	errchan <- err
-}
 
-func updateUserNameDatabase(userId, name string, wg *sync.WaitGroup, errchan chan error) {
-	defer wg.Done()
+	err = s.setIdentityUser(authCtx, prev, name, role)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating user in identity provider", "error", err)
+		s.restoreIdentityUser(ctx, prev)
+		return err
+	}
 
-	args := dbqueries.UpdateUserNameArgs(name, userId)
-	_, err := db.Exec(ctx, dbqueries.UpdateUserName, args)
+	err = tx.Commit(ctx)
 	if err != nil {
-		log.Printf("Error updating user in database: %v\n", err)
+		slog.ErrorContext(ctx, "Error committing user update", "error", err)
+		s.restoreIdentityUser(ctx, prev)
+		return err
 	}
+	s.Sessions.Invalidate(userId)
 
-	errchan <- err
+	return nil
 }
 
-func (u *User) UpdateUserName() error {
-	errchan := make(chan error, 2)
-	wg := new(sync.WaitGroup)
+func (s *Service) UpdateUser(ctx context.Context, u *User) error {
+	return s.updateUser(ctx, u.UserId, u.Name, u.Role)
+}
 
-	wg.Add(2)
-	go updateUserNameFirebase(u.UserId, u.Name, wg, errchan)
-	go updateUserNameDatabase(u.UserId, u.Name, wg, errchan)
+func (s *Service) UpdateUserName(ctx context.Context, u *User) error {
+	return s.updateUser(ctx, u.UserId, u.Name, "")
+}
 
-	wg.Wait()
-	close(errchan)
+// setUserSuspended runs query on the users row and disables or enables the
+// account, with the same compensation as updateUser
+func (s *Service) setUserSuspended(ctx context.Context, userId string, suspend bool, query string, args pgx.NamedArgs) error {
+	authCtx, cancel := s.authContext(ctx)
+	defer cancel()
 
-	for err := range errchan {
-		if err != nil {
-			return err
+	prev, err := s.Identity.GetUser(authCtx, userId)
+	if err != nil {
+		if errors.Is(err, identity.ErrUserNotFound) {
+			return userNotFound
 		}
+
+		slog.ErrorContext(ctx, "Error getting user from identity provider", "error", err)
+		return err
 	}
 
-	return nil
-}
+	tx, err := s.Store.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
 
-/*
-delete user
-*/
-func deleteUserFromFirebase(userId string, wg *sync.WaitGroup, errchan chan error) {
-	defer wg.Done()
+	tag, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating user status in database", "error", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		message := "The user isn't suspended."
+		if suspend {
+			message = "The user is already suspended."
+		}
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	err = s.Identity.UpdateUser(authCtx, userId, identity.UserToUpdate{Disabled: &suspend})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating user in identity provider", "error", err)
+		s.restoreIdentityUser(ctx, prev)
+		return err
+	}
 
-	err := firebaseClient.DeleteUser(ctx, userId)
+	err = tx.Commit(ctx)
 	if err != nil {
-		log.Printf("Error deleting user from firebase: %v\n", err)
+		slog.ErrorContext(ctx, "Error committing user status", "error", err)
+		s.restoreIdentityUser(ctx, prev)
+		return err
 	}
-	errchan <- err
+	s.Sessions.Invalidate(userId)
+
+	return nil
 }
 
-func deleteUserFromDatabase(userId string, wg *sync.WaitGroup, errchan chan error) {
-	// delete user from users table
-	// delete user to project mapping for that user
-	defer wg.Done()
+// SuspendUser stops the user from signing in, their memberships and content
+// stay as they are
+func (s *Service) SuspendUser(ctx context.Context, u *User) error {
+	// timestamps are stored without a zone
+	args := dbqueries.SuspendUserArgs(u.UserId, time.Now().UTC())
+	return s.setUserSuspended(ctx, u.UserId, true, dbqueries.SuspendUser, args)
+}
 
-	args := dbqueries.DeleteUserArgs(userId)
-	_, err := db.Exec(ctx, dbqueries.DeleteUser, args)
+func (s *Service) ReactivateUser(ctx context.Context, u *User) error {
+	args := dbqueries.GetUserByIDArgs(u.UserId)
+	return s.setUserSuspended(ctx, u.UserId, false, dbqueries.ReactivateUser, args)
+}
+
+// DeleteUser removes the users row, with the project memberships, and the
+// identity account. The authored content goes to the inheritor in the same
+// transaction, which is committed only after the account is gone. A deleted
+// account can't be restored so a failed commit is left for the reconciliation
+func (s *Service) DeleteUser(ctx context.Context, u *User, d *UserDeletion) error {
+	if d.InheritorId == "" {
+		message := "Choose a user to inherit the content of the deleted user."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+	if d.InheritorId == u.UserId {
+		message := "The deleted user can't inherit their own content."
+		return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	tx, err := s.Store.Begin(ctx)
 	if err != nil {
-		log.Printf("Error deleting user in database: %v\n", err)
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return err
 	}
-	errchan <- err
-}
+	defer tx.Rollback(context.WithoutCancel(ctx))
 
-// delete user
-func (u *User) DeleteUser() error {
-	errchan := make(chan error, 2)
-	wg := new(sync.WaitGroup)
+	var inheritorId string
+	err = tx.QueryRow(ctx, dbqueries.LockContentInheritor, dbqueries.LockContentInheritorArgs(d.InheritorId)).Scan(&inheritorId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message := "The user inheriting the content doesn't exist."
+			return &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+		}
 
-	wg.Add(2)
-	go deleteUserFromFirebase(u.UserId, wg, errchan)
-	go deleteUserFromDatabase(u.UserId, wg, errchan)
+		slog.ErrorContext(ctx, "Error fetching content inheritor from db", "error", err)
+		return err
+	}
 
-	wg.Wait()
-	close(errchan)
+	_, err = tx.Exec(ctx, dbqueries.TransferUserContent, dbqueries.TransferUserContentArgs(u.UserId, inheritorId))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error transferring user content", "error", err)
+		return err
+	}
 
-	for err := range errchan {
+	// memberships go with the user, the projects they own need another owner
+	rows, err := tx.Query(ctx, dbqueries.GetOwnedProjectIds, dbqueries.GetUserByIDArgs(u.UserId))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching owned projects from db", "error", err)
+		return err
+	}
+	projectIds, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return err
+	}
+
+	for _, projectId := range projectIds {
+		err = s.keepProjectOwner(ctx, tx, projectId, u.UserId, "")
 		if err != nil {
 			return err
 		}
 	}
 
+	args := dbqueries.DeleteUserArgs(u.UserId)
+	tag, err := tx.Exec(ctx, dbqueries.DeleteUser, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting user in database", "error", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		message := "No user found for deletion."
+		return &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
+	}
+
+	authCtx, cancel := s.authContext(ctx)
+	defer cancel()
+
+	err = s.Identity.DeleteUser(authCtx, u.UserId)
+	if err != nil && !errors.Is(err, identity.ErrUserNotFound) {
+		slog.ErrorContext(ctx, "Error deleting user from identity provider", "error", err)
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error committing user deletion, left for reconciliation", "userId", u.UserId, "error", err)
+		return err
+	}
+	s.Sessions.Invalidate(u.UserId)
+
 	return nil
 }
 
 /*
 get user
 */
-func (u *User) GetUserById() (*User, error) {
+func (s *Service) GetUserById(ctx context.Context, u *User) (*User, error) {
 	args := dbqueries.GetUserByIDArgs(u.UserId)
-	rows, err := db.Query(ctx, dbqueries.GetUserByID, args)
+	rows, err := s.Store.Query(ctx, dbqueries.GetUserByID, args)
 	if err != nil {
-		log.Printf("Error fetching user from db: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching user from db", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -347,44 +489,129 @@ func (u *User) GetUserById() (*User, error) {
 			message := "User with the provided ID does not exist."
 			return nil, &custom.MalformedRequest{Status: http.StatusNotFound, Message: message}
 		}
-		log.Printf("Error reading rows: %v\n", err)
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
 		return nil, err
 	}
 
 	return &user, nil
 }
 
-func (u *User) GetAllUsers() (*[]User, error) {
-	rows, err := db.Query(ctx, dbqueries.GetUsers)
+// UserFilter selects a page of users, an empty field doesn't filter
+type UserFilter struct {
+	Search    string
+	Role      string
+	ProjectId string
+	Sort      string
+	Cursor    string
+	Limit     int
+}
+
+type UserPageInfo struct {
+	NextPage bool    `json:"nextPage"`
+	Cursor   *string `json:"cursor"`
+}
+
+// userCursor is the keyset of the last row of a page, handed out opaque so
+// clients don't depend on it
+type userCursor struct {
+	Sort   string `json:"s"`
+	Value  string `json:"v,omitempty"`
+	Cursor int    `json:"c"`
+}
+
+var invalidUserCursor = &custom.MalformedRequest{Status: http.StatusBadRequest, Message: "Invalid cursor."}
+
+func (c *userCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeUserCursor reads a cursor, which only continues the sort it was made for
+func decodeUserCursor(cursor, sort string) (*userCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
 	if err != nil {
-		log.Printf("Error fetching user from db: %v\n", err)
-		return nil, err
+		return nil, invalidUserCursor
 	}
-	defer rows.Close()
 
-	users, err := pgx.CollectRows(rows, pgx.RowToStructByName[User])
-	if err != nil {
-		log.Printf("Error reading rows: %v\n", err)
-		return nil, err
+	var c userCursor
+	err = json.Unmarshal(data, &c)
+	if err != nil || c.Sort != sort || c.Cursor < 1 {
+		return nil, invalidUserCursor
+	}
+
+	return &c, nil
+}
+
+// sortValue is the value of u for the column sort orders by
+func (u *User) sortValue(sort string) string {
+	switch strings.TrimPrefix(sort, "-") {
+	case "name":
+		return u.Name
+	case "email":
+		return u.Email
 	}
 
-	return &users, nil
+	return ""
 }
 
-func (u *User) GetAllUsersByRole(role string) (*[]User, error) {
-	args := dbqueries.GetUsersByRoleArgs(role)
-	rows, err := db.Query(ctx, dbqueries.GetUsersByRole, args)
+// search terms match anywhere in the name or email, literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (s *Service) GetUsersPage(ctx context.Context, f *UserFilter) (*[]User, *UserPageInfo, error) {
+	if f.Sort == "" {
+		f.Sort = "created"
+	}
+	if !dbqueries.ValidUserSort(f.Sort) {
+		message := "Invalid sort, expected created, name or email, prefixed with - for descending order."
+		return nil, nil, &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	if f.Role != "" && !validation.ValidateRole(f.Role) {
+		message := "Invalid role filter."
+		return nil, nil, &custom.MalformedRequest{Status: http.StatusBadRequest, Message: message}
+	}
+
+	search := strings.TrimSpace(f.Search)
+	if search != "" {
+		search = "%" + likeEscaper.Replace(search) + "%"
+	}
+
+	cursor := &userCursor{Sort: f.Sort}
+	if f.Cursor != "" {
+		var err error
+		cursor, err = decodeUserCursor(f.Cursor, f.Sort)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	args := dbqueries.GetUsersPageArgs(search, f.Role, f.ProjectId, cursor.Value, cursor.Cursor, f.Limit+1)
+	rows, err := s.Store.Query(ctx, dbqueries.GetUsersPage(f.Sort), args)
 	if err != nil {
-		log.Printf("Error fetching user from db: %v\n", err)
-		return nil, err
+		slog.ErrorContext(ctx, "Error fetching users from db", "error", err)
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	users, err := pgx.CollectRows(rows, pgx.RowToStructByName[User])
 	if err != nil {
-		log.Printf("Error reading rows: %v\n", err)
-		return nil, err
+		slog.ErrorContext(ctx, "Error reading rows", "error", err)
+		return nil, nil, err
+	}
+
+	pageInfo := UserPageInfo{
+		NextPage: false,
+		Cursor:   nil,
+	}
+
+	if len(users) > f.Limit {
+		users = users[:len(users)-1]
+		pageInfo.NextPage = true
+
+		last := users[len(users)-1]
+		next := (&userCursor{Sort: f.Sort, Value: last.sortValue(f.Sort), Cursor: last.Cursor}).encode()
+		pageInfo.Cursor = &next
 	}
 
-	return &users, nil
+	return &users, &pageInfo, nil
 }
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/rohan031/adgytec-api/v1/custom"
+)
+
+func TestUserCursor(t *testing.T) {
+	cursor := (&userCursor{Sort: "-name", Value: "Project User", Cursor: 42}).encode()
+
+	got, err := decodeUserCursor(cursor, "-name")
+	if err != nil {
+		t.Fatalf("Error decoding cursor: %v", err)
+	}
+	if got.Sort != "-name" || got.Value != "Project User" || got.Cursor != 42 {
+		t.Errorf("unexpected decoded cursor: %+v", got)
+	}
+
+	invalid := []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{"other sort", cursor, "name"},
+		{"not base64", "not a cursor!", "-name"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("42")), "-name"},
+		{"no row", (&userCursor{Sort: "created"}).encode(), "created"},
+		{"raw cursor column", "42", "created"},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeUserCursor(tt.cursor, tt.sort)
+
+			var mr *custom.MalformedRequest
+			if !errors.As(err, &mr) || mr.Message != "Invalid cursor." {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestUserSortValue(t *testing.T) {
+	u := User{Name: "Project User", Email: "user@adgytec.in"}
+
+	for sort, expected := range map[string]string{
+		"name":     u.Name,
+		"-name":    u.Name,
+		"email":    u.Email,
+		"-email":   u.Email,
+		"created":  "",
+		"-created": "",
+	} {
+		if got := u.sortValue(sort); got != expected {
+			t.Errorf("sortValue(%q) = %q want %q", sort, got, expected)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package validation
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NormalizeOrigin returns origin as browsers send it in the Origin header,
+// scheme and host in lower case without a trailing slash
+func NormalizeOrigin(origin string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(origin))
+	if err != nil {
+		return "", false
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+
+	if u.Host == "" || u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return "", false
+	}
+
+	// wildcards are only accepted from the config
+	if strings.Contains(u.Host, "*") {
+		return "", false
+	}
+
+	return strings.ToLower(u.Scheme + "://" + u.Host), true
+}
+
+// MatchOrigin reports if origin matches pattern, a pattern can hold a single
+// * matching any part of the origin e.g. https://*.adgytec.in
+func MatchOrigin(pattern, origin string) bool {
+	pattern = strings.ToLower(pattern)
+	origin = strings.ToLower(origin)
+
+	prefix, suffix, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return pattern == origin
+	}
+
+	return len(origin) >= len(prefix)+len(suffix) &&
+		strings.HasPrefix(origin, prefix) &&
+		strings.HasSuffix(origin, suffix)
+}
@@ -0,0 +1,52 @@
+package validation
+
+import "testing"
+
+func TestNormalizeOrigin(t *testing.T) {
+	tests := []struct {
+		origin   string
+		expected string
+		ok       bool
+	}{
+		{"https://client.example", "https://client.example", true},
+		{" HTTPS://Client.Example/ ", "https://client.example", true},
+		{"http://localhost:3000", "http://localhost:3000", true},
+		{"https://client.example/path", "", false},
+		{"https://client.example?query=1", "", false},
+		{"https://client.example#fragment", "", false},
+		{"https://user@client.example", "", false},
+		{"https://*.client.example", "", false},
+		{"ftp://client.example", "", false},
+		{"client.example", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := NormalizeOrigin(tt.origin)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("NormalizeOrigin(%q) = %q, %v want %q, %v", tt.origin, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		origin   string
+		expected bool
+	}{
+		{"https://dashboard.adgytec.in", "https://dashboard.adgytec.in", true},
+		{"https://dashboard.adgytec.in", "https://other.adgytec.in", false},
+		{"https://*.adgytec.in", "https://dashboard.adgytec.in", true},
+		{"https://*.adgytec.in", "HTTPS://Dashboard.Adgytec.in", true},
+		{"https://*.adgytec.in", "https://adgytec.in", false},
+		{"https://*.adgytec.in", "http://dashboard.adgytec.in", false},
+		{"https://*.adgytec.in", "https://adgytec.in.attacker.example", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchOrigin(tt.pattern, tt.origin); got != tt.expected {
+			t.Errorf("MatchOrigin(%q, %q) = %v want %v", tt.pattern, tt.origin, got, tt.expected)
+		}
+	}
+}
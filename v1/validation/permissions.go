@@ -0,0 +1,65 @@
+package validation
+
+import "net/http"
+
+// services of a project that permissions can be granted on, one per
+// dashboard route group
+const (
+	ServiceNews      string = "news"
+	ServiceBlogs     string = "blogs"
+	ServiceGallery   string = "gallery"
+	ServiceDocuments string = "documents"
+	ServiceContactUs string = "contact-us"
+)
+
+const (
+	PermissionRead   string = "read"
+	PermissionWrite  string = "write"
+	PermissionDelete string = "delete"
+)
+
+var ProjectServices = []string{
+	ServiceNews,
+	ServiceBlogs,
+	ServiceGallery,
+	ServiceDocuments,
+	ServiceContactUs,
+}
+
+var Permissions = []string{
+	PermissionRead,
+	PermissionWrite,
+	PermissionDelete,
+}
+
+func ValidateProjectService(service string) bool {
+	for _, s := range ProjectServices {
+		if s == service {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ValidatePermission(permission string) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PermissionForMethod is the permission a services request needs
+func PermissionForMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return PermissionRead
+	case http.MethodDelete:
+		return PermissionDelete
+	default:
+		return PermissionWrite
+	}
+}
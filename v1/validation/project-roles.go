@@ -0,0 +1,46 @@
+package validation
+
+// roles of a member in a project, each role can do everything the roles
+// below it can
+const (
+	ProjectOwner  string = "owner"
+	ProjectEditor string = "editor"
+	ProjectAuthor string = "author"
+	ProjectViewer string = "viewer"
+)
+
+var projectRoleRank = map[string]int{
+	ProjectViewer: 1,
+	ProjectAuthor: 2,
+	ProjectEditor: 3,
+	ProjectOwner:  4,
+}
+
+func ValidateProjectRole(role string) bool {
+	_, ok := projectRoleRank[role]
+	return ok
+}
+
+// ProjectRoleAtLeast reports if role grants everything min does
+func ProjectRoleAtLeast(role, min string) bool {
+	return projectRoleRank[role] >= projectRoleRank[min]
+}
+
+// ProjectRoleAllowsPermission maps a services permission to the least role
+// needed: viewers read, authors add and edit content, editors can also delete
+// it
+func ProjectRoleAllowsPermission(role, permission string) bool {
+	switch permission {
+	case PermissionRead:
+		return ProjectRoleAtLeast(role, ProjectViewer)
+	case PermissionWrite:
+		return ProjectRoleAtLeast(role, ProjectAuthor)
+	default:
+		return ProjectRoleAtLeast(role, ProjectEditor)
+	}
+}
+
+// ProjectRoleAllowsMethod checks the http method of a services request
+func ProjectRoleAllowsMethod(role, method string) bool {
+	return ProjectRoleAllowsPermission(role, PermissionForMethod(method))
+}
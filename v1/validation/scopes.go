@@ -0,0 +1,28 @@
+package validation
+
+// scopes a client token can be granted, one per public route group
+const (
+	ScopeReadNews       string = "read:news"
+	ScopeReadBlogs      string = "read:blogs"
+	ScopeReadGallery    string = "read:gallery"
+	ScopeReadDocuments  string = "read:documents"
+	ScopeWriteContactUs string = "write:contact-us"
+)
+
+var Scopes = []string{
+	ScopeReadNews,
+	ScopeReadBlogs,
+	ScopeReadGallery,
+	ScopeReadDocuments,
+	ScopeWriteContactUs,
+}
+
+func ValidateScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}